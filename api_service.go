@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"pingai/internal/api"
+	"pingai/internal/checker"
+	"pingai/internal/stats"
+	"pingai/internal/store"
+)
+
+// apiService 将 App 的绑定方法适配为 api.Service
+type apiService struct {
+	a *App
+}
+
+func (s apiService) Providers() []api.Provider {
+	providers := s.a.GetProviders()
+	out := make([]api.Provider, 0, len(providers))
+	for _, p := range providers {
+		out = append(out, api.Provider(p))
+	}
+	return out
+}
+
+// Check 以已保存的供应商配置为基础，请求中的非空字段覆盖对应配置
+// 覆盖 Base URL 时必须同时提供 Key，避免把已保存的 Key 发送到任意地址
+// ctx 取消 (请求断开或程序退出) 时中止检测，结果不写入历史
+func (s apiService) Check(ctx context.Context, req api.CheckRequest) (checker.FullCheckResult, error) {
+	if req.BaseURL != "" && req.APIKey == "" {
+		return checker.FullCheckResult{}, errors.New("覆盖 Base URL 时必须同时提供 API Key")
+	}
+	t, err := s.a.resolveTarget(req.ProviderID)
+	if err != nil && (req.BaseURL == "" || req.Model == "") {
+		return checker.FullCheckResult{}, err
+	}
+	if req.BaseURL != "" {
+		t.BaseURL = req.BaseURL
+	}
+	if req.APIKey != "" {
+		t.APIKey = req.APIKey
+	}
+	if req.Model != "" {
+		t.Model = req.Model
+	}
+	if req.Protocol != "" {
+		t.Protocol = req.Protocol
+	}
+	if req.ProviderName != "" {
+		t.Name = req.ProviderName
+	}
+	result, rec := s.a.runFullCheck(ctx, t.BaseURL, t.APIKey, t.Model, req.ProviderID, t.Name, t.Protocol)
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	s.a.recordResult(result, rec)
+	return result, nil
+}
+
+func (s apiService) History(q store.HistoryQuery) (api.HistoryPage, error) {
+	res, err := s.a.GetHistory(q)
+	if err != nil {
+		return api.HistoryPage{}, err
+	}
+	page := api.HistoryPage{Items: make([]api.HistoryItem, 0, len(res.Items)), Total: res.Total}
+	for _, h := range res.Items {
+		page.Items = append(page.Items, api.HistoryItem{
+			ID:        h.ID,
+			Status:    h.Status,
+			CreatedAt: h.CreatedAt,
+			FullCheckResult: checker.FullCheckResult{
+				ProviderID:   h.ProviderID,
+				ProviderName: h.ProviderName,
+				BaseURL:      h.BaseURL,
+				Model:        h.Model,
+				Protocol:     h.Protocol,
+				Results:      h.Results,
+				ModelList:    h.ModelList,
+				TotalLatency: h.TotalLatency,
+			},
+		})
+	}
+	return page, nil
+}
+
+func (s apiService) Stats(q stats.Query) (stats.ProviderStats, error) {
+	return s.a.GetProviderStats(q)
+}
+
+func (s apiService) Compare(from, to string) ([]stats.ProviderRank, error) {
+	return s.a.CompareProviders(from, to)
+}
+
+func (s apiService) BatchConcurrency() int {
+	return s.a.GetBatchConcurrency()
+}
@@ -1,12 +1,28 @@
 package main
 
 import (
+	"pingai/internal/api"
+	"pingai/internal/backup"
 	"pingai/internal/checker"
+	"pingai/internal/fingerprint"
+	"pingai/internal/keys"
+	"pingai/internal/metrics"
+	"pingai/internal/mockprovider"
+	"pingai/internal/notify"
+	"pingai/internal/protocol"
 	"pingai/internal/provider"
+	"pingai/internal/proxy"
+	"pingai/internal/scheduler"
+	"pingai/internal/stats"
 	"pingai/internal/store"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -14,28 +30,193 @@ import (
 
 // App 应用核心
 type App struct {
-	ctx     context.Context
-	checker *checker.Checker
+	ctx       context.Context
+	checker   *checker.Checker
+	scheduler *scheduler.Scheduler
+	notifier  *notify.Notifier
+	metrics   *metrics.Registry
+	catalog   *provider.Catalog
+	headless  bool // 守护进程模式，没有 Wails 运行时
+
+	// 后台任务 (历史清理、定时检测) 的生命周期
+	bg       context.Context
+	cancelBg context.CancelFunc
+	bgWG     sync.WaitGroup
+
+	metricsMu  sync.Mutex
+	metricsSrv *metrics.Server
+
+	apiMu   sync.Mutex
+	apiSrv  *api.Server
+	apiAddr string // 非空时覆盖已保存的 API 监听地址 (守护进程 --api)
+
+	proxyMu   sync.Mutex
+	proxySrv  *proxy.Proxy
+	proxyAddr string // 非空时覆盖已保存的代理监听地址 (守护进程 --proxy)
+
+	demoMu sync.Mutex
+	demo   *mockprovider.Listener
+
+	// 保存历史与读取最近状态串行执行，保证状态变化判断只看到截至本条的历史
+	historyMu sync.Mutex
 }
 
 // NewApp 创建应用实例
 func NewApp() *App {
-	return &App{
-		checker: checker.NewChecker(),
+	a := &App{
+		checker:  checker.NewChecker(),
+		notifier: notify.New(),
+		metrics:  metrics.NewRegistry(),
+		catalog:  provider.NewCatalog(provider.DefaultAliases()),
 	}
+	a.checker.AddObserver(a.metrics.Observe)
+	a.checker.SetModelInfo(lookupModelInfo)
+	a.bg, a.cancelBg = context.WithCancel(context.Background())
+	a.scheduler = scheduler.New(a.runScheduled, 0)
+	return a
 }
 
+// 自动清理历史的间隔
+const pruneInterval = 6 * time.Hour
+
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 	if err := store.Init(); err != nil {
 		runtime.LogErrorf(ctx, "数据库初始化失败: %v", err)
+		if !store.IsOpen() {
+			runtime.EventsEmit(ctx, "db:error", err.Error())
+			return
+		}
 	}
+	if store.GetVaultStatus().Locked {
+		runtime.EventsEmit(ctx, "vault:locked")
+	}
+	a.startBackground()
 }
 
 func (a *App) shutdown(_ context.Context) {
+	// 等待进行中的定时检测和清理结束后再关闭数据库
+	a.stopBackground()
 	store.Close()
 }
 
+// startBackground 启动历史清理和定时检测
+func (a *App) startBackground() {
+	if err := a.restoreDemoProvider(); err != nil {
+		a.logErrorf("启动演示供应商失败: %v", err)
+	}
+	if err := a.reloadCatalog(); err != nil {
+		a.logErrorf("加载模型别名失败: %v", err)
+	}
+	if err := a.reloadCustomCases(); err != nil {
+		a.logErrorf("加载自定义检测用例失败: %v", err)
+	}
+	if err := a.reloadAuthenticity(); err != nil {
+		a.logErrorf("加载模型真实性设置失败: %v", err)
+	}
+	if err := a.reloadSchedules(); err != nil {
+		a.logErrorf("加载定时检测失败: %v", err)
+	}
+	if addr, _ := store.GetMetricsAddr(); addr != "" {
+		if err := a.startMetrics(addr); err != nil {
+			a.logErrorf("启动指标监听失败: %v", err)
+		}
+	}
+	apiAddr := a.apiAddr
+	if apiAddr == "" {
+		apiAddr, _ = store.GetAPIAddr()
+	}
+	if apiAddr != "" {
+		if err := a.startAPI(apiAddr); err != nil {
+			a.logErrorf("启动 REST API 失败: %v", err)
+		}
+	}
+	proxyAddr := a.proxyAddr
+	if proxyAddr == "" {
+		proxyAddr, _ = store.GetProxyAddr()
+	}
+	if proxyAddr != "" {
+		if err := a.startProxy(proxyAddr); err != nil {
+			a.logErrorf("启动代理失败: %v", err)
+		}
+	}
+	a.bgWG.Add(2)
+	go func() {
+		defer a.bgWG.Done()
+		a.pruneLoop()
+	}()
+	go func() {
+		defer a.bgWG.Done()
+		a.scheduler.Run(a.bg)
+	}()
+}
+
+// stopBackground 停止后台任务并等待进行中的检测结束
+func (a *App) stopBackground() {
+	a.cancelBg()
+	a.stopAPI() // 先停止接收新的 API 运行，再等待进行中的运行结束
+	a.bgWG.Wait()
+	a.stopMetrics()
+	a.stopProxy()
+	a.stopDemo()
+}
+
+// emit 向前端发送事件，守护进程模式下忽略
+func (a *App) emit(event string, data ...interface{}) {
+	if a.headless || a.ctx == nil {
+		return
+	}
+	runtime.EventsEmit(a.ctx, event, data...)
+}
+
+func (a *App) logErrorf(format string, args ...interface{}) {
+	if a.headless || a.ctx == nil {
+		log.Printf("ERROR "+format, args...)
+		return
+	}
+	runtime.LogErrorf(a.ctx, format, args...)
+}
+
+func (a *App) logInfof(format string, args ...interface{}) {
+	if a.headless || a.ctx == nil {
+		log.Printf("INFO "+format, args...)
+		return
+	}
+	runtime.LogInfof(a.ctx, format, args...)
+}
+
+// pruneLoop 启动时及之后定期按保留策略清理历史
+func (a *App) pruneLoop() {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		a.autoPrune()
+		select {
+		case <-a.bg.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *App) autoPrune() {
+	policy, err := store.GetRetentionPolicy()
+	if err != nil {
+		// 策略损坏时不清理，避免按默认规则误删历史
+		a.logErrorf("读取历史保留策略失败: %v", err)
+		return
+	}
+	report, err := store.PruneHistory(policy)
+	if err != nil {
+		a.logErrorf("清理历史失败: %v", err)
+		return
+	}
+	if report.Deleted > 0 {
+		a.logInfof("已清理 %d 条历史，释放 %d 字节", report.Deleted, report.FreedBytes)
+		a.emit("history:pruned", report)
+	}
+}
+
 // --- 供应商 ---
 
 // ProviderInfo 返回给前端的供应商信息
@@ -97,9 +278,113 @@ func (a *App) AddProvider(req AddProviderReq) error {
 
 // DeleteProvider 删除自定义供应商
 func (a *App) DeleteProvider(id string) error {
+	if id == demoProviderID {
+		a.stopDemo()
+	}
 	return store.DeleteCustomProvider(id)
 }
 
+// --- 演示供应商 ---
+
+const (
+	demoProviderID = "pingai-demo"
+	demoAPIKey     = "sk-pingai-demo"
+	// demoAddr 优先使用固定端口，被占用时随机分配
+	demoAddr = "127.0.0.1:18790"
+)
+
+var demoModels = []string{mockprovider.DefaultModel, "mock-model-mini"}
+
+// startDemo 启动本地模拟供应商 (OpenAI 协议)，已启动时直接返回 Base URL
+func (a *App) startDemo() (string, error) {
+	a.demoMu.Lock()
+	defer a.demoMu.Unlock()
+	if a.demo == nil {
+		behavior := mockprovider.Behavior{
+			APIKey:     demoAPIKey,
+			Models:     demoModels,
+			TTFT:       200 * time.Millisecond,
+			ChunkDelay: 30 * time.Millisecond,
+		}
+		l, err := mockprovider.Listen(demoAddr, behavior)
+		if err != nil {
+			if l, err = mockprovider.Listen("127.0.0.1:0", behavior); err != nil {
+				return "", err
+			}
+		}
+		a.demo = l
+	}
+	return mockprovider.BaseURL(a.demo.URL(), mockprovider.OpenAI), nil
+}
+
+func (a *App) stopDemo() {
+	a.demoMu.Lock()
+	defer a.demoMu.Unlock()
+	if a.demo != nil {
+		a.demo.Close()
+		a.demo = nil
+	}
+}
+
+// saveDemoProvider 写入演示供应商，Base URL 指向当前的模拟服务
+func (a *App) saveDemoProvider(baseURL string) error {
+	modelsJSON, _ := json.Marshal(demoModels)
+	if err := store.AddCustomProvider(store.ProviderRow{
+		ID:       demoProviderID,
+		Name:     "Demo (Mock)",
+		BaseURL:  baseURL,
+		Protocol: "openai",
+		Models:   string(modelsJSON),
+	}); err != nil {
+		return err
+	}
+	cfg, err := store.GetProviderConfig(demoProviderID)
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		cfg = &store.ProviderConfigRow{ProviderID: demoProviderID, APIKey: demoAPIKey, Model: mockprovider.DefaultModel, Protocol: "openai"}
+	}
+	// 端口可能变化，已保存的 Base URL 同步更新
+	if cfg.BaseURL != "" {
+		cfg.BaseURL = baseURL
+	}
+	return store.SaveProviderConfig(*cfg)
+}
+
+// restoreDemoProvider 已添加过演示供应商时随应用启动模拟服务
+func (a *App) restoreDemoProvider() error {
+	customs, err := store.GetCustomProviders()
+	if err != nil {
+		return err
+	}
+	for _, c := range customs {
+		if c.ID == demoProviderID {
+			baseURL, err := a.startDemo()
+			if err != nil {
+				return err
+			}
+			return a.saveDemoProvider(baseURL)
+		}
+	}
+	return nil
+}
+
+// AddDemoProvider 添加本地演示供应商，无需网络和真实 Key 即可体验检测流程
+func (a *App) AddDemoProvider() (ProviderInfo, error) {
+	baseURL, err := a.startDemo()
+	if err != nil {
+		return ProviderInfo{}, err
+	}
+	if err := a.saveDemoProvider(baseURL); err != nil {
+		return ProviderInfo{}, err
+	}
+	return ProviderInfo{
+		ID: demoProviderID, Name: "Demo (Mock)", BaseURL: baseURL, Protocol: "openai",
+		Models: demoModels,
+	}, nil
+}
+
 // --- 可见性 ---
 
 // SetProviderVisibility 设置供应商是否显示在侧边栏
@@ -121,6 +406,88 @@ func (a *App) ResetAllProviders() error {
 	return store.ResetAll()
 }
 
+// --- 备份 ---
+
+// ExportBackup 导出自定义供应商、配置、可见性及可选的 Key 和历史到备份文件
+func (a *App) ExportBackup(opts backup.Options) (string, error) {
+	snap, err := store.TakeSnapshot(opts.IncludeKeys, opts.IncludeHistory)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Backup",
+		DefaultFilename: "pingai_backup_" + time.Now().Format("20060102_150405") + ".pingai",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "PingAI Backup", Pattern: "*.pingai"},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	if err := backup.Write(f, snap, opts); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// ImportBackup 从备份文件恢复，mode 为 "merge" 或 "replace"
+func (a *App) ImportBackup(mode, passphrase string) (store.RestoreResult, error) {
+	if mode != store.RestoreMerge && mode != store.RestoreReplace {
+		return store.RestoreResult{}, fmt.Errorf("不支持的恢复模式: %s", mode)
+	}
+
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Backup",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "PingAI Backup", Pattern: "*.pingai"},
+		},
+	})
+	if err != nil || path == "" {
+		return store.RestoreResult{}, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return store.RestoreResult{}, err
+	}
+	defer f.Close()
+	snap, err := backup.Read(f, passphrase)
+	if err != nil {
+		return store.RestoreResult{}, err
+	}
+	return store.RestoreSnapshot(snap, mode)
+}
+
+// --- 保险库 ---
+
+// GetVaultStatus 获取 API Key 保险库状态
+func (a *App) GetVaultStatus() store.VaultStatus {
+	return store.GetVaultStatus()
+}
+
+// UnlockVault 使用口令解锁保险库 (密钥文件模式忽略口令)
+func (a *App) UnlockVault(passphrase string) error {
+	return store.UnlockVault(passphrase)
+}
+
+// LockVault 锁定保险库
+func (a *App) LockVault() {
+	store.LockVault()
+}
+
+// SetVaultPassphrase 设置保险库口令，传空字符串切换回本地密钥文件
+func (a *App) SetVaultPassphrase(passphrase string) error {
+	return store.SetVaultPassphrase(passphrase)
+}
+
 // --- 配置 ---
 
 // SaveProviderConfig 保存供应商配置 (API Key 等)
@@ -156,12 +523,128 @@ func (a *App) GetProviderDefaults(providerID string) *ProviderInfo {
 	return nil
 }
 
+// --- 已保存的 Key ---
+
+// ListProviderKeys 获取供应商已保存的 Key
+func (a *App) ListProviderKeys(providerID string) ([]store.ProviderKeyRow, error) {
+	keys, err := store.GetProviderKeys(providerID)
+	if keys == nil {
+		keys = []store.ProviderKeyRow{}
+	}
+	return keys, err
+}
+
+// AddProviderKey 保存一个带标签的 Key，返回新 Key 的 ID
+func (a *App) AddProviderKey(providerID, label, apiKey, notes string) (int64, error) {
+	if providerID == "" || apiKey == "" {
+		return 0, fmt.Errorf("供应商和 Key 不能为空")
+	}
+	return store.AddProviderKey(store.ProviderKeyRow{
+		ProviderID: providerID,
+		Label:      label,
+		APIKey:     apiKey,
+		Notes:      notes,
+	})
+}
+
+// RemoveProviderKey 删除已保存的 Key
+func (a *App) RemoveProviderKey(id int64) error {
+	return store.DeleteProviderKey(id)
+}
+
+// ActivateProviderKey 切换供应商当前使用的 Key
+func (a *App) ActivateProviderKey(id int64) error {
+	return store.ActivateProviderKey(id)
+}
+
+// RunStoredKeyCheck 使用供应商当前配置检测已保存的 Key，keyIDs 为空时检测全部
+func (a *App) RunStoredKeyCheck(providerID string, keyIDs []int64) (BatchKeyCheckResult, error) {
+	target, err := a.resolveTarget(providerID)
+	if err != nil {
+		return BatchKeyCheckResult{}, err
+	}
+
+	saved, err := store.GetProviderKeys(providerID)
+	if err != nil {
+		return BatchKeyCheckResult{}, err
+	}
+	wanted := make(map[int64]bool, len(keyIDs))
+	for _, id := range keyIDs {
+		wanted[id] = true
+	}
+	var selected []store.ProviderKeyRow
+	for _, k := range saved {
+		if len(keyIDs) == 0 || wanted[k.ID] {
+			selected = append(selected, k)
+		}
+	}
+
+	apiKeys := make([]string, len(selected))
+	for i, k := range selected {
+		apiKeys[i] = k.APIKey
+	}
+	items := a.runKeyChecks(target.BaseURL, target.Model, providerID, target.Name, target.Protocol, apiKeys)
+	for i, it := range items {
+		store.UpdateProviderKeyCheck(selected[i].ID, string(it.Verdict))
+		if selected[i].Label != "" {
+			items[i].MaskedKey = selected[i].Label + " (" + it.MaskedKey + ")"
+		}
+	}
+	return BatchKeyCheckResult{Items: items, Summary: checker.SummarizeKeys(items)}, nil
+}
+
+// checkTarget 供应商的检测目标 (已保存配置优先，其次为预设/自定义默认值)
+type checkTarget struct {
+	Name     string
+	BaseURL  string
+	APIKey   string
+	Model    string
+	Protocol string
+}
+
+func (a *App) resolveTarget(providerID string) (checkTarget, error) {
+	var t checkTarget
+	for _, p := range a.GetProviders() {
+		if p.ID == providerID {
+			t = checkTarget{Name: p.Name, BaseURL: p.BaseURL, Protocol: p.Protocol}
+			if len(p.Models) > 0 {
+				t.Model = p.Models[0]
+			}
+			break
+		}
+	}
+
+	cfg, err := store.GetProviderConfig(providerID)
+	if err != nil {
+		return t, err
+	}
+	if cfg != nil {
+		t.APIKey = cfg.APIKey
+		if cfg.BaseURL != "" {
+			t.BaseURL = cfg.BaseURL
+		}
+		if cfg.Model != "" {
+			t.Model = cfg.Model
+		}
+		if cfg.Protocol != "" {
+			t.Protocol = cfg.Protocol
+		}
+	}
+	if t.Name == "" {
+		t.Name = providerID
+	}
+	if t.BaseURL == "" || t.Model == "" {
+		return t, fmt.Errorf("供应商 %s 缺少 Base URL 或模型配置", providerID)
+	}
+	return t, nil
+}
+
 // --- 检测 ---
 
 // RunCheck 执行单个检测
 func (a *App) RunCheck(baseURL, apiKey, model, providerID, providerName, protocol string) checker.FullCheckResult {
-	result := a.checker.RunFullCheck(baseURL, apiKey, model, providerID, providerName, protocol)
-	a.saveHistory(result)
+	result, rec := a.runFullCheck(context.Background(), baseURL, apiKey, model, providerID, providerName, protocol)
+	a.recordResult(result, rec)
 	return result
 }
 
@@ -180,145 +663,1464 @@ func (a *App) RunBatchCheck(items []BatchCheckItem) []checker.FullCheckResult {
 	type indexed struct {
 		idx    int
 		result checker.FullCheckResult
+		rec    *protocol.Recorder
 	}
 
 	results := make([]checker.FullCheckResult, len(items))
+	recs := make([]*protocol.Recorder, len(items))
 	ch := make(chan indexed, len(items))
+	sem := make(chan struct{}, a.GetBatchConcurrency())
 
 	for i, item := range items {
 		go func(idx int, it BatchCheckItem) {
-			r := a.checker.RunFullCheck(it.BaseURL, it.APIKey, it.Model, it.ProviderID, it.ProviderName, it.Protocol)
-			ch <- indexed{idx: idx, result: r}
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			r, rec := a.runFullCheck(context.Background(), it.BaseURL, it.APIKey, it.Model, it.ProviderID, it.ProviderName, it.Protocol)
+			ch <- indexed{idx: idx, result: r, rec: rec}
 		}(i, item)
 	}
 
 	for range items {
 		ir := <-ch
 		results[ir.idx] = ir.result
+		recs[ir.idx] = ir.rec
 	}
 
 	// 保存历史
-	for _, r := range results {
-		a.saveHistory(r)
+	for i, r := range results {
+		a.recordResult(r, recs[i])
 	}
 	return results
 }
 
+// RunMatrixCheck 供应商 × 模型 × Key 矩阵检测；未填写地址或 Key 的供应商使用已保存的配置
+func (a *App) RunMatrixCheck(spec checker.MatrixSpec) (checker.MatrixResult, error) {
+	for i, p := range spec.Providers {
+		if p.BaseURL != "" && (len(p.Keys) > 0 || len(spec.Keys) > 0) {
+			continue
+		}
+		t, err := a.resolveTarget(p.ProviderID)
+		if err != nil {
+			return checker.MatrixResult{}, err
+		}
+		if p.BaseURL == "" {
+			p.BaseURL, p.Protocol = t.BaseURL, t.Protocol
+		}
+		if p.ProviderName == "" {
+			p.ProviderName = t.Name
+		}
+		if len(p.Keys) == 0 && len(spec.Keys) == 0 && t.APIKey != "" {
+			p.Keys = []string{t.APIKey}
+		}
+		spec.Providers[i] = p
+	}
+	targets, err := checker.ExpandMatrix(spec)
+	if err != nil {
+		return checker.MatrixResult{}, err
+	}
+
+	concurrency := spec.Concurrency
+	if concurrency <= 0 {
+		concurrency = a.GetBatchConcurrency()
+	}
+	result := checker.RunMatrix(targets, concurrency, func(t checker.MatrixTarget) checker.FullCheckResult {
+		r, rec := a.runFullCheck(context.Background(), t.BaseURL, t.APIKey, t.Model, t.ProviderID, t.ProviderName, t.Protocol)
+		a.recordResult(r, rec)
+		return r
+	})
+	return result, nil
+}
+
+// ExportMatrixPivot 将矩阵检测结果按 rows × cols (provider / model / key) 透视导出为 CSV
+func (a *App) ExportMatrixPivot(result checker.MatrixResult, rows, cols string) (string, error) {
+	content, err := checker.MatrixPivotCSV(result, rows, cols)
+	if err != nil {
+		return "", err
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Matrix",
+		DefaultFilename: "matrix_" + rows + "_" + cols + "_" + time.Now().Format("20060102_150405") + ".csv",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "CSV Files", Pattern: "*.csv"},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// BatchKeyCheckResult 批量 Key 检测返回
+type BatchKeyCheckResult struct {
+	Items   []checker.KeyResult `json:"items"`
+	Summary checker.KeySummary  `json:"summary"`
+}
+
 // RunBatchKeyCheck 批量 Key 检测：同一供应商配置，多个 API Key
-func (a *App) RunBatchKeyCheck(baseURL, model, providerID, providerName, protocol string, apiKeys []string) []checker.FullCheckResult {
+func (a *App) RunBatchKeyCheck(baseURL, model, providerID, providerName, protocol string, apiKeys []string) BatchKeyCheckResult {
+	items := a.runKeyChecks(baseURL, model, providerID, providerName, protocol, apiKeys)
+	return BatchKeyCheckResult{Items: items, Summary: checker.SummarizeKeys(items)}
+}
+
+func (a *App) runKeyChecks(baseURL, model, providerID, providerName, proto string, apiKeys []string) []checker.KeyResult {
 	type indexed struct {
 		idx    int
 		result checker.FullCheckResult
+		rec    *protocol.Recorder
 	}
 
 	results := make([]checker.FullCheckResult, len(apiKeys))
+	recs := make([]*protocol.Recorder, len(apiKeys))
 	ch := make(chan indexed, len(apiKeys))
+	sem := make(chan struct{}, a.GetBatchConcurrency())
 
 	for i, key := range apiKeys {
 		go func(idx int, k string) {
+			sem <- struct{}{}
+			defer func() { <-sem }()
 			// 供应商名称附加脱敏 Key 标识
 			masked := maskKey(k)
 			name := providerName + " (" + masked + ")"
-			r := a.checker.RunFullCheck(baseURL, k, model, providerID, name, protocol)
-			ch <- indexed{idx: idx, result: r}
+			r, rec := a.runFullCheck(context.Background(), baseURL, k, model, providerID, name, proto)
+			ch <- indexed{idx: idx, result: r, rec: rec}
 		}(i, key)
 	}
 
 	for range apiKeys {
 		ir := <-ch
 		results[ir.idx] = ir.result
+		recs[ir.idx] = ir.rec
 	}
 
-	for _, r := range results {
-		a.saveHistory(r)
+	items := make([]checker.KeyResult, len(results))
+	for i, r := range results {
+		a.saveHistory(r, recs[i])
+		items[i] = checker.NewKeyResult(apiKeys[i], maskKey(apiKeys[i]), r)
 	}
-	return results
+	return items
 }
 
-// maskKey 脱敏 API Key，保留前3后4位
-func maskKey(key string) string {
-	if len(key) <= 8 {
-		return "***"
+// GetBatchConcurrency 获取批量检测 (多配置 / 多 Key / 模型扫描) 的并发上限
+func (a *App) GetBatchConcurrency() int {
+	n, _ := store.GetBatchConcurrency()
+	if n <= 0 {
+		n = checker.DefaultBatchConcurrency
 	}
-	return key[:3] + "..." + key[len(key)-4:]
+	return n
 }
 
-func (a *App) saveHistory(r checker.FullCheckResult) {
-	resultsJSON, _ := json.Marshal(r.Results)
-	modelListJSON, _ := json.Marshal(r.ModelList)
-
-	status := "success"
-	for _, item := range r.Results {
-		if item.Status == "failed" {
-			status = "failed"
-			break
-		}
-		if item.Status == "warning" {
-			status = "warning"
-		}
+// SetBatchConcurrency 设置批量检测的并发上限
+func (a *App) SetBatchConcurrency(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("并发数必须大于 0")
 	}
-
-	store.SaveHistory(store.HistoryRow{
-		ProviderID:   r.ProviderID,
-		ProviderName: r.ProviderName,
-		BaseURL:      r.BaseURL,
-		Model:        r.Model,
-		Protocol:     r.Protocol,
-		ResultsJSON:  string(resultsJSON),
-		ModelList:    string(modelListJSON),
-		TotalLatency: r.TotalLatency,
-		Status:       status,
-	})
+	return store.SetBatchConcurrency(n)
 }
 
-// --- 历史记录 ---
-
-// HistoryItem 前端展示的历史项
-type HistoryItem struct {
-	ID           int64                  `json:"id"`
-	ProviderID   string                 `json:"providerID"`
-	ProviderName string                 `json:"providerName"`
-	BaseURL      string                 `json:"baseURL"`
-	Model        string                 `json:"model"`
-	Protocol     string                 `json:"protocol"`
-	Results      []checker.CheckResult  `json:"results"`
-	ModelList    []string               `json:"modelList"`
-	TotalLatency int64                  `json:"totalLatency"`
-	Status       string                 `json:"status"`
-	CreatedAt    string                 `json:"createdAt"`
+// SweepModels 模型扫描：获取模型列表，对选中的模型逐个做轻量检测，不保存历史
+func (a *App) SweepModels(baseURL, apiKey, providerID, providerName, proto string, opts checker.SweepOptions) (checker.SweepResult, error) {
+	for i, m := range opts.Models {
+		opts.Models[i] = a.resolveModel(m, providerID)
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = a.GetBatchConcurrency()
+	}
+	result, err := a.checker.SweepModels(context.Background(), baseURL, apiKey, providerID, providerName, proto, opts)
+	if err != nil {
+		return result, err
+	}
+	records := make([]store.SpendRecord, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		records = append(records, store.SpendRecord{
+			ProviderID: providerID, MaskedKey: maskKey(apiKey), Model: row.Model,
+			Source: store.SpendSweep, Cost: row.Cost, Currency: row.Currency,
+		})
+	}
+	a.recordSpend(records...)
+	return result, nil
 }
 
-// HistoryListResult 历史列表返回
-type HistoryListResult struct {
-	Items []HistoryItem `json:"items"`
-	Total int           `json:"total"`
+// ExportSweep 将模型扫描矩阵导出为 CSV
+func (a *App) ExportSweep(result checker.SweepResult) (string, error) {
+	content, err := checker.SweepCSV(result)
+	if err != nil {
+		return "", err
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Model Sweep",
+		DefaultFilename: "sweep_" + result.ProviderID + "_" + time.Now().Format("20060102_150405") + ".csv",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "CSV Files", Pattern: "*.csv"},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
 }
 
-// GetHistory 获取历史记录
-func (a *App) GetHistory(limit, offset int) HistoryListResult {
-	rows, _ := store.GetHistory(limit, offset)
-	total, _ := store.GetHistoryCount()
-
-	items := make([]HistoryItem, 0, len(rows))
-	for _, row := range rows {
-		item := HistoryItem{
-			ID:           row.ID,
-			ProviderID:   row.ProviderID,
-			ProviderName: row.ProviderName,
-			BaseURL:      row.BaseURL,
-			Model:        row.Model,
-			Protocol:     row.Protocol,
-			TotalLatency: row.TotalLatency,
-			Status:       row.Status,
-			CreatedAt:    row.CreatedAt,
-		}
-		json.Unmarshal([]byte(row.ResultsJSON), &item.Results)
-		json.Unmarshal([]byte(row.ModelList), &item.ModelList)
-		items = append(items, item)
+// ExportKeys 导出批量检测中的 Key，filter 为 all / valid / failing，format 为 txt / csv
+func (a *App) ExportKeys(items []checker.KeyResult, filter, format string) (string, error) {
+	content, err := checker.ExportKeys(items, filter, format)
+	if err != nil {
+		return "", err
 	}
 
-	return HistoryListResult{Items: items, Total: total}
-}
+	displayName := "Text Files"
+	if format == "csv" {
+		displayName = "CSV Files"
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Keys",
+		DefaultFilename: "keys_" + filter + "_" + time.Now().Format("20060102_150405") + "." + format,
+		Filters: []runtime.FileFilter{
+			{DisplayName: displayName, Pattern: "*." + format},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	// Key 文件仅当前用户可读
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// KeySource Key 导入来源
+type KeySource struct {
+	Type string `json:"type"` // "text" | "file" | "clipboard"
+	Text string `json:"text"` // Type 为 text 时的内容
+}
+
+// maxKeyFileSize 导入文件大小上限
+const maxKeyFileSize = 10 << 20
+
+// ParseKeys 从文本、文件 (.env / CSV / txt) 或剪贴板中提取 API Key，去重并标记已保存的 Key
+func (a *App) ParseKeys(source KeySource) (keys.ParseResult, error) {
+	var text string
+	switch source.Type {
+	case "file":
+		path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Title: "Import Keys",
+			Filters: []runtime.FileFilter{
+				{DisplayName: "Key Files (*.env;*.txt;*.csv)", Pattern: "*.env;*.txt;*.csv;.env*"},
+				{DisplayName: "All Files", Pattern: "*"},
+			},
+		})
+		if err != nil || path == "" {
+			return keys.ParseResult{}, err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return keys.ParseResult{}, err
+		}
+		if info.Size() > maxKeyFileSize {
+			return keys.ParseResult{}, fmt.Errorf("文件过大: %d bytes", info.Size())
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return keys.ParseResult{}, err
+		}
+		text = string(data)
+	case "clipboard":
+		clip, err := runtime.ClipboardGetText(a.ctx)
+		if err != nil {
+			return keys.ParseResult{}, err
+		}
+		text = clip
+	default:
+		text = source.Text
+	}
+
+	result := keys.Extract(text)
+	owners, err := store.GetKeyOwners()
+	if err != nil {
+		return keys.ParseResult{}, fmt.Errorf("读取已保存的 Key 失败: %w", err)
+	}
+	keys.MarkStored(&result, owners)
+	if result.Keys == nil {
+		result.Keys = []keys.Candidate{}
+	}
+	return result, nil
+}
+
+// maskKey 脱敏 API Key，保留前3后4位
+func maskKey(key string) string {
+	return keys.Mask(key)
+}
+
+// spendRecord 按模型单价估算不保存历史的操作的费用，价格未知时 Currency 为空
+func spendRecord(source, providerID, apiKey, model string, items ...checker.CheckResult) store.SpendRecord {
+	rec := store.SpendRecord{ProviderID: providerID, MaskedKey: maskKey(apiKey), Model: model, Source: source}
+	info, ok := lookupModelInfo(providerID, model)
+	if !ok || info.Currency == "" {
+		return rec
+	}
+	rec.Currency = info.Currency
+	for _, r := range items {
+		rec.Cost += checker.ItemCost(r, info)
+	}
+	return rec
+}
+
+// recordSpend 保存扫描、试运行等操作的费用，使其计入费用统计和月度预算
+func (a *App) recordSpend(records ...store.SpendRecord) {
+	if err := store.RecordSpend(records...); err != nil {
+		a.logErrorf("保存费用记录失败: %v", err)
+	}
+}
+
+// resolveModel 将规范名或其他供应商的模型 ID 解析为该供应商的模型 ID，
+// 供应商自己的预设模型或列表接口返回的模型原样保留
+func (a *App) resolveModel(model, providerID string) string {
+	var own []string
+	for _, p := range a.GetProviders() {
+		if p.ID == providerID {
+			own = append(own, p.Models...)
+			break
+		}
+	}
+	rows, _ := store.GetModelInfo(providerID)
+	for _, r := range rows {
+		own = append(own, r.Model)
+	}
+	return a.catalog.ResolveOwn(model, providerID, own)
+}
+
+// runFullCheck 执行全量检测，开启流量录制时一并返回录制器
+func (a *App) runFullCheck(ctx context.Context, baseURL, apiKey, model, providerID, providerName, proto string) (checker.FullCheckResult, *protocol.Recorder) {
+	model = a.resolveModel(model, providerID)
+	if on, _ := store.GetTrafficRecording(); !on {
+		return a.checker.RunFullCheckContext(ctx, baseURL, apiKey, model, providerID, providerName, proto), nil
+	}
+	rec := protocol.NewRecorder(nil, apiKey)
+	result := a.checker.RunFullCheckContext(protocol.WithTransport(ctx, rec), baseURL, apiKey, model, providerID, providerName, proto)
+	return result, rec
+}
+
+// saveHistory 保存检测历史及录制的流量 (rec 可为 nil)，返回整体状态
+func (a *App) saveHistory(r checker.FullCheckResult, rec *protocol.Recorder) string {
+	resultsJSON, _ := json.Marshal(r.Results)
+	modelListJSON, _ := json.Marshal(r.ModelList)
+	status := historyStatus(r)
+
+	id, err := store.SaveHistory(store.HistoryRow{
+		ProviderID:   r.ProviderID,
+		ProviderName: r.ProviderName,
+		BaseURL:      r.BaseURL,
+		Model:        r.Model,
+		Protocol:     r.Protocol,
+		ResultsJSON:  string(resultsJSON),
+		ModelList:    string(modelListJSON),
+		TotalLatency: r.TotalLatency,
+		Status:       status,
+		Cost:         r.Cost,
+		Currency:     r.Currency,
+		MaskedKey:    r.MaskedKey,
+	})
+	if err == nil && rec != nil {
+		var cassette strings.Builder
+		protocol.WriteCassette(&cassette, rec.Interactions())
+		if err := store.SaveTraffic(id, cassette.String()); err != nil {
+			a.logErrorf("保存录制流量失败: %v", err)
+		}
+	}
+	return status
+}
+
+// historyStatus 整体状态：任一项失败为 failed，否则有警告为 warning
+func historyStatus(r checker.FullCheckResult) string {
+	status := "success"
+	for _, item := range r.Results {
+		if item.Status == "failed" {
+			return "failed"
+		}
+		if item.Status == "warning" {
+			status = "warning"
+		}
+	}
+	return status
+}
+
+// --- 定时检测 ---
+
+// ScheduleInfo 定时检测计划及运行状态
+type ScheduleInfo struct {
+	store.ScheduleRow
+	NextRun string `json:"nextRun"`
+	Running bool   `json:"running"`
+}
+
+func toSchedule(row store.ScheduleRow) scheduler.Schedule {
+	return scheduler.Schedule{
+		ID:         row.ID,
+		ProviderID: row.ProviderID,
+		Interval:   time.Duration(row.IntervalSeconds) * time.Second,
+		Cron:       row.Cron,
+		Jitter:     time.Duration(row.JitterSeconds) * time.Second,
+		Enabled:    row.Enabled == 1,
+	}
+}
+
+// reloadSchedules 从数据库重新加载计划和并发设置
+func (a *App) reloadSchedules() error {
+	rows, err := store.GetSchedules()
+	if err != nil {
+		return err
+	}
+	list := make([]scheduler.Schedule, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, toSchedule(row))
+	}
+	a.scheduler.Set(list)
+
+	n, err := store.GetSchedulerConcurrency()
+	a.scheduler.SetConcurrency(n)
+	return err
+}
+
+// runScheduled 执行一次定时检测并保存历史
+func (a *App) runScheduled(ctx context.Context, s scheduler.Schedule) error {
+	if budget, err := store.GetBudgetStatus(time.Now()); err == nil && budget.Exceeded {
+		a.emit("budget:exceeded", budget)
+		if budget.Budget.Action == store.BudgetBlock {
+			store.UpdateScheduleRun(s.ID, "budget")
+			a.logInfof("本月检测费用 %.4f %s 已超出预算，跳过定时检测 %s", budget.Spent, budget.Budget.Currency, s.ProviderID)
+			return nil
+		}
+	}
+	t, err := a.resolveTarget(s.ProviderID)
+	if err != nil {
+		store.UpdateScheduleRun(s.ID, "error")
+		a.logErrorf("定时检测 %s 失败: %v", s.ProviderID, err)
+		return err
+	}
+	result, rec := a.runFullCheck(ctx, t.BaseURL, t.APIKey, t.Model, s.ProviderID, t.Name, t.Protocol)
+	status := a.recordResult(result, rec)
+	store.UpdateScheduleRun(s.ID, status)
+	a.emit("schedule:result", result)
+	return nil
+}
+
+// GetSchedules 获取全部定时检测计划
+func (a *App) GetSchedules() ([]ScheduleInfo, error) {
+	rows, err := store.GetSchedules()
+	if err != nil {
+		return []ScheduleInfo{}, err
+	}
+	next := make(map[int64]scheduler.Status)
+	for _, st := range a.scheduler.Statuses() {
+		next[st.ID] = st
+	}
+
+	list := make([]ScheduleInfo, 0, len(rows))
+	for _, row := range rows {
+		info := ScheduleInfo{ScheduleRow: row}
+		if st, ok := next[row.ID]; ok {
+			info.NextRun = st.NextRun.Format(time.RFC3339)
+			info.Running = st.Running
+		}
+		list = append(list, info)
+	}
+	return list, nil
+}
+
+// SaveSchedule 新建或更新供应商的定时检测计划
+func (a *App) SaveSchedule(row store.ScheduleRow) (int64, error) {
+	if err := toSchedule(row).Validate(); err != nil {
+		return 0, err
+	}
+	id, err := store.SaveSchedule(row)
+	if err != nil {
+		return 0, err
+	}
+	return id, a.reloadSchedules()
+}
+
+// DeleteSchedule 删除定时检测计划
+func (a *App) DeleteSchedule(id int64) error {
+	if err := store.DeleteSchedule(id); err != nil {
+		return err
+	}
+	return a.reloadSchedules()
+}
+
+// GetSchedulerConcurrency 获取定时检测的全局并发上限
+func (a *App) GetSchedulerConcurrency() int {
+	n, _ := store.GetSchedulerConcurrency()
+	if n <= 0 {
+		n = scheduler.DefaultConcurrency
+	}
+	return n
+}
+
+// SetSchedulerConcurrency 设置定时检测的全局并发上限
+func (a *App) SetSchedulerConcurrency(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("并发数必须大于 0")
+	}
+	if err := store.SetSchedulerConcurrency(n); err != nil {
+		return err
+	}
+	a.scheduler.SetConcurrency(n)
+	return nil
+}
+
+// --- 通知 ---
+
+func toWebhook(row store.WebhookRow) notify.Webhook {
+	w := notify.Webhook{
+		Name:          row.Name,
+		Kind:          notify.Kind(row.Kind),
+		URL:           row.URL,
+		Enabled:       row.Enabled == 1,
+		FailThreshold: row.FailThreshold,
+		QuietStart:    row.QuietStart,
+		QuietEnd:      row.QuietEnd,
+	}
+	json.Unmarshal([]byte(row.ProviderIDs), &w.ProviderIDs)
+	if w.Name == "" {
+		w.Name = fmt.Sprintf("webhook-%d", row.ID)
+	}
+	return w
+}
+
+// recordResult 保存历史并在状态变化时发送通知，返回整体状态
+// 通知在后台发送，不阻塞检测；退出时等待发送完成
+func (a *App) recordResult(r checker.FullCheckResult, rec *protocol.Recorder) string {
+	a.historyMu.Lock()
+	status := a.saveHistory(r, rec)
+	send := a.transition(r)
+	a.historyMu.Unlock()
+	if send != nil {
+		a.bgWG.Add(1)
+		go func() {
+			defer a.bgWG.Done()
+			send()
+		}()
+	}
+	return status
+}
+
+// transition 根据历史判断供应商+模型+Key 的状态是否发生变化，返回发送通知的函数，无需通知时返回 nil
+// 每个 Webhook 的连续失败阈值可能不同，分别判断
+func (a *App) transition(r checker.FullCheckResult) func() {
+	rows, err := store.GetWebhooks()
+	if err != nil || len(rows) == 0 {
+		return nil
+	}
+	hooks := make([]notify.Webhook, 0, len(rows))
+	maxThreshold := 1
+	for _, row := range rows {
+		w := toWebhook(row)
+		if !w.Enabled || !w.Matches(r.ProviderID) {
+			continue
+		}
+		hooks = append(hooks, w)
+		maxThreshold = max(maxThreshold, w.Threshold())
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	statuses, err := store.RecentStatuses(r.ProviderID, r.Model, r.MaskedKey, maxThreshold+1)
+	if err != nil {
+		a.logErrorf("读取最近检测状态失败: %v", err)
+		return nil
+	}
+
+	ev := notify.Event{
+		ProviderID:   r.ProviderID,
+		ProviderName: r.ProviderName,
+		Model:        r.Model,
+		MaskedKey:    r.MaskedKey,
+		Status:       historyStatus(r),
+		ErrorKind:    string(checker.PrimaryErrorKind(r)),
+		Time:         time.Now(),
+	}
+	for _, item := range r.Results {
+		if item.Status == checker.StatusFailed {
+			ev.Message = item.Message
+			break
+		}
+	}
+
+	var targets []notify.Webhook
+	var events []notify.Event
+	for _, w := range hooks {
+		typ, ok := notify.Detect(statuses, w.Threshold())
+		if !ok {
+			continue
+		}
+		e := ev
+		e.Type = typ
+		if typ == notify.EventDown {
+			e.FailCount = w.Threshold()
+		}
+		targets = append(targets, w)
+		events = append(events, e)
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	return func() {
+		for i, w := range targets {
+			for name, err := range a.notifier.Dispatch(context.Background(), []notify.Webhook{w}, events[i]) {
+				a.logErrorf("发送通知 %s 失败: %v", name, err)
+			}
+		}
+	}
+}
+
+func validateWebhook(row store.WebhookRow) error {
+	if !strings.HasPrefix(row.URL, "http://") && !strings.HasPrefix(row.URL, "https://") {
+		return fmt.Errorf("Webhook 地址必须以 http:// 或 https:// 开头")
+	}
+	for _, k := range notify.Kinds {
+		if notify.Kind(row.Kind) == k {
+			return nil
+		}
+	}
+	return fmt.Errorf("不支持的 Webhook 类型: %s", row.Kind)
+}
+
+// GetWebhooks 获取全部通知 Webhook
+func (a *App) GetWebhooks() ([]store.WebhookRow, error) {
+	rows, err := store.GetWebhooks()
+	if rows == nil {
+		rows = []store.WebhookRow{}
+	}
+	return rows, err
+}
+
+// SaveWebhook 新建或更新通知 Webhook
+func (a *App) SaveWebhook(row store.WebhookRow) (int64, error) {
+	if err := validateWebhook(row); err != nil {
+		return 0, err
+	}
+	return store.SaveWebhook(row)
+}
+
+// DeleteWebhook 删除通知 Webhook
+func (a *App) DeleteWebhook(id int64) error {
+	return store.DeleteWebhook(id)
+}
+
+// TestWebhook 发送一条测试通知，忽略启用状态和免打扰
+func (a *App) TestWebhook(row store.WebhookRow) error {
+	if err := validateWebhook(row); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	return a.notifier.Send(ctx, toWebhook(row), notify.Event{
+		Type:         notify.EventTest,
+		ProviderName: row.Name,
+		Message:      "这是一条来自 PingAI 的测试消息",
+		Time:         time.Now(),
+	})
+}
+
+// --- 自定义检测用例 ---
+
+// toCustomCase 解析数据库中的用例
+func toCustomCase(row store.CustomCaseRow) (checker.CustomCase, error) {
+	cc := checker.CustomCase{ID: row.ID, Name: row.Name, System: row.SystemPrompt}
+	fields := []struct {
+		name string
+		raw  string
+		dst  any
+	}{
+		{"消息", row.Messages, &cc.Messages},
+		{"参数", row.Params, &cc.Params},
+		{"断言", row.Assertions, &cc.Assertions},
+	}
+	for _, f := range fields {
+		if f.raw == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(f.raw), f.dst); err != nil {
+			return cc, fmt.Errorf("用例 %s 的%s格式错误: %w", row.Name, f.name, err)
+		}
+	}
+	return cc, nil
+}
+
+// reloadCustomCases 将启用的用例交给检测引擎
+func (a *App) reloadCustomCases() error {
+	rows, err := store.GetCustomCases()
+	if err != nil {
+		return err
+	}
+	var cases []checker.CustomCase
+	for _, row := range rows {
+		if row.Enabled != 1 {
+			continue
+		}
+		cc, err := toCustomCase(row)
+		if err == nil {
+			err = cc.Validate()
+		}
+		if err != nil {
+			a.logErrorf("跳过检测用例: %v", err)
+			continue
+		}
+		cases = append(cases, cc)
+	}
+	a.checker.SetCustomCases(cases)
+	return nil
+}
+
+// GetCustomCases 获取全部自定义检测用例
+func (a *App) GetCustomCases() ([]store.CustomCaseRow, error) {
+	rows, err := store.GetCustomCases()
+	if rows == nil {
+		rows = []store.CustomCaseRow{}
+	}
+	return rows, err
+}
+
+// SaveCustomCase 新建或更新自定义检测用例，启用的用例在之后的每次检测中执行
+func (a *App) SaveCustomCase(row store.CustomCaseRow) (int64, error) {
+	cc, err := toCustomCase(row)
+	if err != nil {
+		return 0, err
+	}
+	if err := cc.Validate(); err != nil {
+		return 0, err
+	}
+	id, err := store.SaveCustomCase(row)
+	if err != nil {
+		return 0, err
+	}
+	return id, a.reloadCustomCases()
+}
+
+// DeleteCustomCase 删除自定义检测用例
+func (a *App) DeleteCustomCase(id int64) error {
+	if err := store.DeleteCustomCase(id); err != nil {
+		return err
+	}
+	return a.reloadCustomCases()
+}
+
+// TestCustomCase 用某个供应商的当前配置试运行用例，不保存历史
+func (a *App) TestCustomCase(row store.CustomCaseRow, providerID string) (checker.CheckResult, error) {
+	cc, err := toCustomCase(row)
+	if err != nil {
+		return checker.CheckResult{}, err
+	}
+	if err := cc.Validate(); err != nil {
+		return checker.CheckResult{}, err
+	}
+	t, err := a.resolveTarget(providerID)
+	if err != nil {
+		return checker.CheckResult{}, err
+	}
+	model := a.resolveModel(t.Model, providerID)
+	r := a.checker.RunCustomCase(context.Background(), t.BaseURL, t.APIKey, model, t.Protocol, cc)
+	rec := spendRecord(store.SpendCustomCase, providerID, t.APIKey, model, r)
+	r.Cost = rec.Cost
+	a.recordSpend(rec)
+	return r, nil
+}
+
+// --- 模型别名 ---
+
+// reloadCatalog 合并内置别名和用户添加的别名
+func (a *App) reloadCatalog() error {
+	rows, err := store.GetModelAliases()
+	if err != nil {
+		return err
+	}
+	user := make([]provider.ModelAlias, 0, len(rows))
+	for _, row := range rows {
+		user = append(user, provider.ModelAlias{Canonical: row.Canonical, IDs: map[string]string{row.ProviderID: row.ModelID}})
+	}
+	a.catalog.Set(provider.DefaultAliases(), user)
+	return nil
+}
+
+// GetModelCatalog 获取合并后的模型别名目录
+func (a *App) GetModelCatalog() []provider.ModelAlias {
+	return a.catalog.Aliases()
+}
+
+// GetModelAliases 获取用户添加的模型别名
+func (a *App) GetModelAliases() ([]store.ModelAliasRow, error) {
+	rows, err := store.GetModelAliases()
+	if rows == nil {
+		rows = []store.ModelAliasRow{}
+	}
+	return rows, err
+}
+
+// SaveModelAlias 添加或覆盖模型别名，优先于内置别名
+func (a *App) SaveModelAlias(row store.ModelAliasRow) error {
+	row.Canonical = strings.TrimSpace(row.Canonical)
+	row.ModelID = strings.TrimSpace(row.ModelID)
+	if row.Canonical == "" || row.ProviderID == "" || row.ModelID == "" {
+		return fmt.Errorf("规范名、供应商和模型 ID 均不能为空")
+	}
+	if err := store.SaveModelAlias(row); err != nil {
+		return err
+	}
+	return a.reloadCatalog()
+}
+
+// DeleteModelAlias 删除用户添加的模型别名
+func (a *App) DeleteModelAlias(canonical, providerID string) error {
+	if err := store.DeleteModelAlias(canonical, providerID); err != nil {
+		return err
+	}
+	return a.reloadCatalog()
+}
+
+// ResolveModel 将规范名解析为供应商的模型 ID，没有映射或是供应商自己的模型时原样返回
+func (a *App) ResolveModel(model, providerID string) string {
+	return a.resolveModel(model, providerID)
+}
+
+// --- 模型元数据 ---
+
+// lookupModelInfo 模型元数据：从列表接口获取的优先，缺失字段用预设补全
+func lookupModelInfo(providerID, model string) (protocol.ModelInfo, bool) {
+	preset, hasPreset := provider.LookupPresetModelInfo(providerID, model)
+	row, err := store.GetModelInfoFor(providerID, model)
+	if err != nil {
+		return preset, hasPreset
+	}
+	var info protocol.ModelInfo
+	if json.Unmarshal([]byte(row.Info), &info) != nil {
+		return preset, hasPreset
+	}
+	return info.Merge(preset), true
+}
+
+// GetModelInfo 获取供应商各模型的元数据 (预设与已获取的合并)
+func (a *App) GetModelInfo(providerID string) ([]protocol.ModelInfo, error) {
+	rows, err := store.GetModelInfo(providerID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	result := []protocol.ModelInfo{}
+	for _, row := range rows {
+		if info, ok := lookupModelInfo(providerID, row.Model); ok {
+			result = append(result, info)
+			seen[row.Model] = true
+		}
+	}
+	for _, info := range provider.PresetModelInfo(providerID) {
+		if !seen[info.ID] {
+			result = append(result, info)
+		}
+	}
+	return result, nil
+}
+
+// RefreshModelInfo 从供应商的模型列表接口重新获取元数据 (OpenRouter / Gemini / Ollama 提供的最完整)
+func (a *App) RefreshModelInfo(providerID string) ([]protocol.ModelInfo, error) {
+	t, err := a.resolveTarget(providerID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	infos, err := protocol.ListModelInfo(ctx, protocol.Protocol(t.Protocol), t.BaseURL, t.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	// Ollama 的 OpenAI 兼容接口不含元数据，逐个查询原生接口
+	if providerID == "ollama" {
+		for i, info := range infos {
+			if shown, err := protocol.OllamaShow(ctx, t.BaseURL, info.ID); err == nil {
+				infos[i] = shown.Merge(info)
+			}
+		}
+	}
+
+	rows := make([]store.ModelInfoRow, 0, len(infos))
+	for _, info := range infos {
+		data, err := json.Marshal(info)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, store.ModelInfoRow{Model: info.ID, Info: string(data)})
+	}
+	if err := store.ReplaceModelInfo(providerID, rows); err != nil {
+		return nil, err
+	}
+	return a.GetModelInfo(providerID)
+}
+
+// --- 模型真实性 ---
+
+// profileKey 参考档案的存储键: 目录中的模型用规范名，否则去掉路由前缀和版本后缀，
+// 使 gpt-4o、openai/gpt-4o 和 gpt-4o-2024-08-06 共用同一份档案
+func (a *App) profileKey(model string) string {
+	if canonical := a.catalog.Canonical(model); canonical != "" {
+		return strings.ToLower(canonical)
+	}
+	return fingerprint.BaseModel(model)
+}
+
+// lookupProfile 读取模型参考档案，没有或无法解析时返回 nil
+func (a *App) lookupProfile(model string) *fingerprint.Profile {
+	row, err := store.GetModelProfile(a.profileKey(model))
+	if errors.Is(err, store.ErrModelProfileNotFound) {
+		// 兼容按原始模型名保存的旧档案
+		row, err = store.GetModelProfile(model)
+	}
+	if err != nil {
+		return nil
+	}
+	var p fingerprint.Profile
+	if json.Unmarshal([]byte(row.Profile), &p) != nil {
+		return nil
+	}
+	return &p
+}
+
+// reloadAuthenticity 按设置开关检测引擎中的真实性检测
+func (a *App) reloadAuthenticity() error {
+	on, err := store.GetAuthenticityEnabled()
+	if err != nil {
+		return err
+	}
+	if on {
+		a.checker.SetAuthenticity(a.lookupProfile)
+	} else {
+		a.checker.SetAuthenticity(nil)
+	}
+	return nil
+}
+
+// GetAuthenticityEnabled 完整检测时是否执行模型真实性检测
+func (a *App) GetAuthenticityEnabled() (bool, error) {
+	return store.GetAuthenticityEnabled()
+}
+
+// SetAuthenticityEnabled 开关模型真实性检测，开启后每次检测额外发送一组探针请求
+func (a *App) SetAuthenticityEnabled(on bool) error {
+	if err := store.SetAuthenticityEnabled(on); err != nil {
+		return err
+	}
+	return a.reloadAuthenticity()
+}
+
+// RunAuthenticityCheck 对供应商当前模型运行真实性检测，返回可信度和全部证据
+func (a *App) RunAuthenticityCheck(providerID string) (fingerprint.Result, error) {
+	t, err := a.resolveTarget(providerID)
+	if err != nil {
+		return fingerprint.Result{}, err
+	}
+	model := a.resolveModel(t.Model, providerID)
+	item, result := a.checker.RunAuthenticity(context.Background(), t.BaseURL, t.APIKey, model, t.Protocol, a.lookupProfile(model))
+	a.recordSpend(spendRecord(store.SpendAuthenticity, providerID, t.APIKey, model, item))
+	return result, nil
+}
+
+// CaptureModelProfile 以供应商当前的回答作为该模型的参考档案，应选择可信的官方渠道采集
+func (a *App) CaptureModelProfile(providerID string) (store.ModelProfileRow, error) {
+	t, err := a.resolveTarget(providerID)
+	if err != nil {
+		return store.ModelProfileRow{}, err
+	}
+	if t.Model == "" {
+		return store.ModelProfileRow{}, fmt.Errorf("供应商 %s 未配置模型", t.Name)
+	}
+	t.Model = a.resolveModel(t.Model, providerID)
+	adapter := protocol.GetAdapter(protocol.Protocol(t.Protocol))
+	obs := fingerprint.Run(context.Background(), adapter, protocol.ChatRequest{BaseURL: t.BaseURL, APIKey: t.APIKey, Model: t.Model}, fingerprint.DefaultProbes)
+	var usage checker.CheckResult
+	for _, o := range obs {
+		usage.TokenIn += o.PromptTokens
+		usage.TokenOut += o.CompTokens
+	}
+	a.recordSpend(spendRecord(store.SpendProfile, providerID, t.APIKey, t.Model, usage))
+	for _, o := range obs {
+		if o.Error != "" {
+			return store.ModelProfileRow{}, fmt.Errorf("探针 %s 失败: %s", o.ProbeID, o.Error)
+		}
+	}
+	data, err := json.Marshal(fingerprint.Capture(t.Model, providerID, obs))
+	if err != nil {
+		return store.ModelProfileRow{}, err
+	}
+	row := store.ModelProfileRow{Model: a.profileKey(t.Model), Profile: string(data), Source: providerID}
+	if err := store.SaveModelProfile(row); err != nil {
+		return store.ModelProfileRow{}, err
+	}
+	saved, err := store.GetModelProfile(row.Model)
+	if err != nil {
+		return store.ModelProfileRow{}, err
+	}
+	return *saved, nil
+}
+
+// GetModelProfiles 获取全部模型参考档案
+func (a *App) GetModelProfiles() ([]store.ModelProfileRow, error) {
+	rows, err := store.GetModelProfiles()
+	if rows == nil {
+		rows = []store.ModelProfileRow{}
+	}
+	return rows, err
+}
+
+// DeleteModelProfile 删除模型参考档案
+func (a *App) DeleteModelProfile(model string) error {
+	return store.DeleteModelProfile(model)
+}
+
+// --- 指标 ---
+
+// startMetrics 在 addr 上启动 /metrics，替换已有的监听
+func (a *App) startMetrics(addr string) error {
+	a.metricsMu.Lock()
+	defer a.metricsMu.Unlock()
+	if a.metricsSrv != nil {
+		a.metricsSrv.Close()
+		a.metricsSrv = nil
+	}
+	srv, err := metrics.Listen(addr, a.metrics)
+	if err != nil {
+		return err
+	}
+	a.metricsSrv = srv
+	a.logInfof("Prometheus 指标监听 http://%s/metrics", srv.Addr())
+	return nil
+}
+
+func (a *App) stopMetrics() {
+	a.metricsMu.Lock()
+	defer a.metricsMu.Unlock()
+	if a.metricsSrv != nil {
+		a.metricsSrv.Close()
+		a.metricsSrv = nil
+	}
+}
+
+// GetMetricsAddr 获取 Prometheus 指标监听地址，为空表示未启用
+func (a *App) GetMetricsAddr() string {
+	addr, _ := store.GetMetricsAddr()
+	return addr
+}
+
+// SetMetricsAddr 设置指标监听地址 (如 "127.0.0.1:9464")，为空时关闭监听
+func (a *App) SetMetricsAddr(addr string) error {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		a.stopMetrics()
+	} else if err := a.startMetrics(addr); err != nil {
+		return err
+	}
+	return store.SetMetricsAddr(addr)
+}
+
+// --- REST API ---
+
+// APIServerConfig REST API 配置
+type APIServerConfig struct {
+	Addr    string `json:"addr"`    // 为空表示未启用
+	Token   string `json:"token"`   // Bearer 令牌
+	Running bool   `json:"running"` // 当前是否在监听
+}
+
+// apiToken 读取访问令牌，不存在时生成并保存
+func apiToken() (string, error) {
+	token, err := store.GetAPIToken()
+	if err != nil || token != "" {
+		return token, err
+	}
+	if token, err = api.NewToken(); err != nil {
+		return "", err
+	}
+	return token, store.SetAPIToken(token)
+}
+
+// startAPI 在 addr 上启动 REST API，替换已有的监听
+func (a *App) startAPI(addr string) error {
+	token, err := apiToken()
+	if err != nil {
+		return err
+	}
+	a.apiMu.Lock()
+	defer a.apiMu.Unlock()
+	if a.apiSrv != nil {
+		a.apiSrv.Close()
+		a.apiSrv = nil
+	}
+	srv := api.New(apiService{a: a}, token)
+	srv.SetBackground(a.bg, &a.bgWG)
+	if err := srv.Listen(addr); err != nil {
+		return err
+	}
+	a.apiSrv = srv
+	a.logInfof("REST API 监听 http://%s/api", srv.Addr())
+	return nil
+}
+
+func (a *App) stopAPI() {
+	a.apiMu.Lock()
+	defer a.apiMu.Unlock()
+	if a.apiSrv != nil {
+		a.apiSrv.Close()
+		a.apiSrv = nil
+	}
+}
+
+// GetAPIServerConfig 获取 REST API 监听地址和访问令牌
+func (a *App) GetAPIServerConfig() (APIServerConfig, error) {
+	addr, err := store.GetAPIAddr()
+	if err != nil {
+		return APIServerConfig{}, err
+	}
+	token, err := apiToken()
+	if err != nil {
+		return APIServerConfig{}, err
+	}
+	a.apiMu.Lock()
+	running := a.apiSrv != nil
+	a.apiMu.Unlock()
+	return APIServerConfig{Addr: addr, Token: token, Running: running}, nil
+}
+
+// SetAPIServerAddr 设置 REST API 监听地址 (如 "127.0.0.1:8787")，为空时关闭
+func (a *App) SetAPIServerAddr(addr string) error {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		a.stopAPI()
+	} else if err := a.startAPI(addr); err != nil {
+		return err
+	}
+	return store.SetAPIAddr(addr)
+}
+
+// RegenerateAPIToken 生成新的访问令牌，旧令牌立即失效
+func (a *App) RegenerateAPIToken() (string, error) {
+	token, err := api.NewToken()
+	if err != nil {
+		return "", err
+	}
+	if err := store.SetAPIToken(token); err != nil {
+		return "", err
+	}
+	a.apiMu.Lock()
+	if a.apiSrv != nil {
+		a.apiSrv.SetToken(token)
+	}
+	a.apiMu.Unlock()
+	a.proxyMu.Lock()
+	if a.proxySrv != nil {
+		a.proxySrv.SetToken(token)
+	}
+	a.proxyMu.Unlock()
+	return token, nil
+}
+
+// --- OpenAI 兼容代理 ---
+
+// proxyTargets 由已保存的供应商配置和检测通过的 Key 组成上游池，健康度取自最近一次检测
+func (a *App) proxyTargets() ([]proxy.Target, error) {
+	configs, err := store.GetAllProviderConfigs()
+	if err != nil && !errors.Is(err, store.ErrVaultLocked) {
+		return nil, err
+	}
+	latest, err := store.LatestHistory()
+	if err != nil {
+		return nil, err
+	}
+	byKey, err := store.LatestHistoryByKey()
+	if err != nil {
+		return nil, err
+	}
+	health := make(map[string]store.HistoryRow, len(latest))
+	for _, h := range latest {
+		health[h.ProviderID] = h
+	}
+	keyHealth := make(map[[2]string]store.HistoryRow, len(byKey))
+	for _, h := range byKey {
+		keyHealth[[2]string{h.ProviderID, h.MaskedKey}] = h
+	}
+	savedKeys, _ := store.GetAllProviderKeys()
+
+	var targets []proxy.Target
+	for _, cfg := range configs {
+		t, err := a.resolveTarget(cfg.ProviderID)
+		if err != nil {
+			continue
+		}
+		base := proxy.Target{
+			ProviderID: cfg.ProviderID,
+			Name:       t.Name,
+			BaseURL:    t.BaseURL,
+			Protocol:   t.Protocol,
+			Models:     []string{t.Model},
+		}
+		if p := a.GetProviderDefaults(cfg.ProviderID); p != nil {
+			base.Models = append(base.Models, p.Models...)
+		}
+		if h, ok := health[cfg.ProviderID]; ok {
+			var listed []string
+			json.Unmarshal([]byte(h.ModelList), &listed)
+			base.Models = append(base.Models, listed...)
+		}
+		verdicts := make(map[string]checker.KeyVerdict)
+		for _, k := range savedKeys {
+			if k.ProviderID == cfg.ProviderID && k.LastVerdict != "" {
+				verdicts[k.APIKey] = checker.KeyVerdict(k.LastVerdict)
+			}
+		}
+
+		// 每个 Key 按自己最近一次检测排序，最近结论为无效或已吊销的 Key 不参与转发
+		seen := make(map[string]bool)
+		addKey := func(key string) {
+			if key == "" || seen[key] {
+				return
+			}
+			seen[key] = true
+			tt := base
+			tt.APIKey = key
+			verdict, known := verdicts[key]
+			if h, ok := keyHealth[[2]string{cfg.ProviderID, maskKey(key)}]; ok {
+				tt.Status = h.Status
+				tt.Latency = h.TotalLatency
+				if !known {
+					r := checker.FullCheckResult{}
+					json.Unmarshal([]byte(h.ResultsJSON), &r.Results)
+					verdict = checker.ClassifyKey(r)
+				}
+			}
+			if verdict == checker.VerdictInvalid || verdict == checker.VerdictRevoked {
+				return
+			}
+			targets = append(targets, tt)
+		}
+		addKey(t.APIKey)
+		for _, k := range savedKeys {
+			if k.ProviderID == cfg.ProviderID && checker.KeyVerdict(k.LastVerdict).IsUsable() {
+				addKey(k.APIKey)
+			}
+		}
+	}
+	return targets, nil
+}
+
+// startProxy 在 addr 上启动代理，替换已有的监听，访问令牌与 REST API 共用
+func (a *App) startProxy(addr string) error {
+	token, err := apiToken()
+	if err != nil {
+		return err
+	}
+	a.proxyMu.Lock()
+	defer a.proxyMu.Unlock()
+	if a.proxySrv != nil {
+		a.proxySrv.Close()
+		a.proxySrv = nil
+	}
+	srv := proxy.New(a.proxyTargets, token)
+	if err := srv.Listen(addr); err != nil {
+		return err
+	}
+	a.proxySrv = srv
+	a.logInfof("OpenAI 兼容代理监听 http://%s/v1", srv.Addr())
+	return nil
+}
+
+func (a *App) stopProxy() {
+	a.proxyMu.Lock()
+	defer a.proxyMu.Unlock()
+	if a.proxySrv != nil {
+		a.proxySrv.Close()
+		a.proxySrv = nil
+	}
+}
+
+// GetProxyAddr 获取代理监听地址，为空表示未启用
+func (a *App) GetProxyAddr() string {
+	addr, _ := store.GetProxyAddr()
+	return addr
+}
+
+// SetProxyAddr 设置代理监听地址 (如 "127.0.0.1:8788")，为空时关闭
+func (a *App) SetProxyAddr(addr string) error {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		a.stopProxy()
+	} else if err := a.startProxy(addr); err != nil {
+		return err
+	}
+	return store.SetProxyAddr(addr)
+}
+
+// GetProxyRoutes 按当前路由顺序列出可服务 model 的上游 (不含 Key)
+func (a *App) GetProxyRoutes(model string) ([]proxy.Target, error) {
+	a.proxyMu.Lock()
+	srv := a.proxySrv
+	a.proxyMu.Unlock()
+	if srv == nil {
+		srv = proxy.New(a.proxyTargets, "")
+	}
+	routes, err := srv.Candidates(model)
+	if routes == nil {
+		routes = []proxy.Target{}
+	}
+	return routes, err
+}
+
+// --- 历史记录 ---
+
+// HistoryItem 前端展示的历史项
+type HistoryItem struct {
+	ID           int64                  `json:"id"`
+	ProviderID   string                 `json:"providerID"`
+	ProviderName string                 `json:"providerName"`
+	BaseURL      string                 `json:"baseURL"`
+	Model        string                 `json:"model"`
+	Protocol     string                 `json:"protocol"`
+	Results      []checker.CheckResult  `json:"results"`
+	ModelList    []string               `json:"modelList"`
+	TotalLatency int64                  `json:"totalLatency"`
+	Status       string                 `json:"status"`
+	CreatedAt    string                 `json:"createdAt"`
+	HasTraffic   bool                   `json:"hasTraffic"` // 是否录制了原始流量
+	Cost         float64                `json:"cost"`
+	Currency     string                 `json:"currency"`
+	MaskedKey    string                 `json:"maskedKey"`
+}
+
+// HistoryListResult 历史列表返回
+type HistoryListResult struct {
+	Items []HistoryItem `json:"items"`
+	Total int           `json:"total"`
+}
+
+// GetHistory 按条件查询历史记录，Total 为过滤后的总数
+func (a *App) GetHistory(query store.HistoryQuery) (HistoryListResult, error) {
+	rows, total, err := store.QueryHistory(query)
+	if err != nil {
+		return HistoryListResult{Items: []HistoryItem{}}, err
+	}
+
+	recorded := make(map[int64]bool)
+	if ids, err := store.HistoryIDsWithTraffic(); err == nil {
+		for _, id := range ids {
+			recorded[id] = true
+		}
+	}
+	items := make([]HistoryItem, 0, len(rows))
+	for _, row := range rows {
+		item := toHistoryItem(row)
+		item.HasTraffic = recorded[row.ID]
+		items = append(items, item)
+	}
+	return HistoryListResult{Items: items, Total: total}, nil
+}
+
+func toHistoryItem(row store.HistoryRow) HistoryItem {
+	item := HistoryItem{
+		ID:           row.ID,
+		ProviderID:   row.ProviderID,
+		ProviderName: row.ProviderName,
+		BaseURL:      row.BaseURL,
+		Model:        row.Model,
+		Protocol:     row.Protocol,
+		TotalLatency: row.TotalLatency,
+		Status:       row.Status,
+		CreatedAt:    row.CreatedAt,
+		Cost:         row.Cost,
+		Currency:     row.Currency,
+		MaskedKey:    row.MaskedKey,
+	}
+	json.Unmarshal([]byte(row.ResultsJSON), &item.Results)
+	json.Unmarshal([]byte(row.ModelList), &item.ModelList)
+	return item
+}
+
+// GetProviderStats 获取供应商/模型在时间窗口内的可用率与延迟统计
+func (a *App) GetProviderStats(query stats.Query) (stats.ProviderStats, error) {
+	if query.ProviderID != "" && query.Model != "" {
+		query.Model = a.resolveModel(query.Model, query.ProviderID)
+	}
+	return stats.GetProviderStats(query)
+}
+
+// CompareProviders 按成功率和中位延迟对全部供应商排名
+func (a *App) CompareProviders(from, to string) ([]stats.ProviderRank, error) {
+	ranks, err := stats.CompareProviders(from, to)
+	if ranks == nil {
+		ranks = []stats.ProviderRank{}
+	}
+	return ranks, err
+}
+
+// CompareModel 对同一模型 (规范名或任一供应商的模型 ID) 在各供应商的表现排名
+func (a *App) CompareModel(model, from, to string) ([]stats.ProviderRank, error) {
+	ranks, err := stats.CompareModel(from, to, func(providerID string) string {
+		return a.resolveModel(model, providerID)
+	})
+	if ranks == nil {
+		ranks = []stats.ProviderRank{}
+	}
+	return ranks, err
+}
+
+// GetRetentionPolicy 获取历史保留策略
+func (a *App) GetRetentionPolicy() (store.RetentionPolicy, error) {
+	return store.GetRetentionPolicy()
+}
+
+// SetRetentionPolicy 保存历史保留策略
+func (a *App) SetRetentionPolicy(policy store.RetentionPolicy) error {
+	return store.SetRetentionPolicy(policy)
+}
+
+// PruneHistory 立即按当前策略清理历史，返回释放的空间
+func (a *App) PruneHistory() (store.PruneReport, error) {
+	policy, err := store.GetRetentionPolicy()
+	if err != nil {
+		return store.PruneReport{}, err
+	}
+	return store.PruneHistory(policy)
+}
+
+// GetSpend 按供应商、Key、日期汇总 [from, to] 内的检测费用 (YYYY-MM-DD，空值不限制)
+func (a *App) GetSpend(from, to string) ([]store.SpendRow, error) {
+	return store.QuerySpend(from, to)
+}
+
+// GetBudget 获取月度预算
+func (a *App) GetBudget() (store.Budget, error) {
+	return store.GetBudget()
+}
+
+// SetBudget 保存月度预算
+func (a *App) SetBudget(b store.Budget) error {
+	if b.Amount < 0 {
+		return fmt.Errorf("预算不能为负数")
+	}
+	if b.Amount > 0 && b.Currency == "" {
+		return fmt.Errorf("请选择预算币种")
+	}
+	if b.Action != store.BudgetBlock {
+		b.Action = store.BudgetWarn
+	}
+	return store.SetBudget(b)
+}
+
+// GetBudgetStatus 获取本月预算使用情况
+func (a *App) GetBudgetStatus() (store.BudgetStatus, error) {
+	return store.GetBudgetStatus(time.Now())
+}
 
 // DeleteHistory 删除单条历史
 func (a *App) DeleteHistory(id int64) error {
@@ -335,6 +2137,83 @@ func (a *App) DeleteAllHistory() error {
 	return store.DeleteAllHistory()
 }
 
+// --- 流量录制 ---
+
+// GetTrafficRecording 是否录制检测流量
+func (a *App) GetTrafficRecording() (bool, error) {
+	return store.GetTrafficRecording()
+}
+
+// SetTrafficRecording 开关流量录制，开启后每次检测的原始请求和响应 (已脱敏) 随历史保存
+func (a *App) SetTrafficRecording(on bool) error {
+	return store.SetTrafficRecording(on)
+}
+
+// historyTraffic 读取并解析某条历史的录制内容
+func historyTraffic(id int64) ([]protocol.Interaction, error) {
+	cassette, err := store.GetTraffic(id)
+	if err != nil {
+		return nil, err
+	}
+	return protocol.ReadCassette(strings.NewReader(cassette))
+}
+
+// GetHistoryTraffic 查看某条历史的原始流量
+func (a *App) GetHistoryTraffic(id int64) ([]protocol.Interaction, error) {
+	return historyTraffic(id)
+}
+
+// ExportHistoryTraffic 导出某条历史的原始流量，format 为 har / jsonl
+func (a *App) ExportHistoryTraffic(id int64, format string) (string, error) {
+	interactions, err := historyTraffic(id)
+	if err != nil {
+		return "", err
+	}
+	displayName := "HAR Files"
+	if format != "har" {
+		format, displayName = "jsonl", "JSONL Cassettes"
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Traffic",
+		DefaultFilename: fmt.Sprintf("traffic_%d.%s", id, format),
+		Filters: []runtime.FileFilter{
+			{DisplayName: displayName, Pattern: "*." + format},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if format == "har" {
+		err = protocol.WriteHAR(f, interactions)
+	} else {
+		err = protocol.WriteCassette(f, interactions)
+	}
+	if err != nil {
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// ReplayHistory 用录制的流量重新执行一次检测，不访问网络、不保存历史
+func (a *App) ReplayHistory(id int64) (checker.FullCheckResult, error) {
+	row, err := store.GetHistoryByID(id)
+	if err != nil {
+		return checker.FullCheckResult{}, err
+	}
+	interactions, err := historyTraffic(id)
+	if err != nil {
+		return checker.FullCheckResult{}, err
+	}
+	ctx := protocol.WithTransport(context.Background(), protocol.NewReplayer(interactions))
+	return a.checker.RunFullCheckContext(ctx, row.BaseURL, "", row.Model, row.ProviderID, row.ProviderName, row.Protocol), nil
+}
+
 // --- 导出 ---
 
 // ExportReport 导出报告到文件
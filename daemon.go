@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"pingai/internal/store"
+	"strings"
+	"syscall"
+)
+
+// vaultPassphraseEnv 守护进程模式下解锁口令保险库的环境变量
+const vaultPassphraseEnv = "PINGAI_VAULT_PASSPHRASE"
+
+// isDaemonMode 命令行包含 --daemon、--api=ADDR 或 --proxy=ADDR 时以无界面模式运行
+func isDaemonMode(args []string) bool {
+	if flagValue(args, "api") != "" || flagValue(args, "proxy") != "" {
+		return true
+	}
+	for _, arg := range args {
+		if arg == "--daemon" || arg == "-daemon" {
+			return true
+		}
+	}
+	return false
+}
+
+// flagValue 读取 --name=VALUE 形式的参数，如 --api=127.0.0.1:8787
+func flagValue(args []string, name string) string {
+	for _, arg := range args {
+		for _, prefix := range []string{"--" + name + "=", "-" + name + "="} {
+			if value, ok := strings.CutPrefix(arg, prefix); ok {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// runDaemon 无界面运行定时检测、历史清理、REST API 及代理，直到收到 SIGINT/SIGTERM
+func runDaemon(args []string) error {
+	if err := store.Init(); err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if store.GetVaultStatus().Locked {
+		passphrase := os.Getenv(vaultPassphraseEnv)
+		if passphrase == "" {
+			return errors.New("保险库已锁定，请通过环境变量 " + vaultPassphraseEnv + " 提供口令")
+		}
+		if err := store.UnlockVault(passphrase); err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	app := NewApp()
+	app.headless = true
+	app.apiAddr = flagValue(args, "api")
+	app.proxyAddr = flagValue(args, "proxy")
+	app.startBackground()
+	log.Printf("PingAI 守护进程已启动，定时检测计划 %d 个", len(app.scheduler.Statuses()))
+
+	<-ctx.Done()
+	log.Printf("正在停止，等待进行中的检测结束")
+	app.stopBackground()
+	return nil
+}
@@ -32,11 +32,16 @@ const messages: Record<Locale, Record<string, string>> = {
     'config.model': '模型',
     'config.reset': '重置',
     'config.batch': '批量',
+    'config.cases': '用例',
+    'config.authenticity': '真实性',
+    'config.sweep': '扫描',
     'config.check': '检测',
 
     // Sidebar
     'sidebar.providers': '供应商',
     'sidebar.history': '历史记录',
+    'sidebar.matrix': '矩阵对比',
+    'sidebar.spend': '费用',
 
     // CheckCard
     'card.totalLatency': '总耗时',
@@ -49,6 +54,7 @@ const messages: Record<Locale, Record<string, string>> = {
     'item.stream': '流式输出',
     'item.models': '模型列表',
     'item.multi_turn': '多轮对话',
+    'item.authenticity': '模型真实性',
 
     // History
     'history.total': '共 {n} 条记录',
@@ -60,6 +66,11 @@ const messages: Record<Locale, Record<string, string>> = {
     'history.confirmDelete': '确定删除 {n} 条记录?',
     'history.confirmClear': '确定清空全部历史记录?',
     'history.empty': '暂无历史记录',
+    'history.viewTraffic': '查看原始流量',
+    'history.hideTraffic': '收起原始流量',
+    'history.exportHAR': '导出 HAR',
+    'history.exportJSONL': '导出 JSONL',
+    'history.trafficError': '网络错误',
 
     // BatchKeyDialog
     'batchKey.title': '批量 Key 检测',
@@ -75,6 +86,8 @@ const messages: Record<Locale, Record<string, string>> = {
     'addProvider.models': '模型列表（每行一个）',
     'addProvider.cancel': '取消',
     'addProvider.add': '添加',
+    'addProvider.demo': '添加演示供应商',
+    'addProvider.demoHint': '本地模拟的 OpenAI 接口，无需网络和 API Key',
 
     // Settings
     'settings.title': '设置',
@@ -87,6 +100,115 @@ const messages: Record<Locale, Record<string, string>> = {
     'settings.resetDefault': '重置为默认',
     'settings.close': '关闭',
     'settings.language': '语言',
+    'settings.traffic': '流量录制',
+    'settings.trafficHint': '保存每次检测的原始请求和响应 (Key 已脱敏)，可在历史记录中查看和导出',
+    'settings.authenticity': '模型真实性检测',
+    'settings.authenticityHint': '额外发送一组探针请求，比对回显的模型名、分词数和知识截止时间，判断模型是否被替换',
+    'settings.budget': '月度预算',
+    'settings.budgetHint': '按模型单价估算每次检测、扫描和试运行的费用，本月累计超出预算后提示或暂停定时检测，0 表示不限制。只统计与预算同币种的费用，不做汇率换算',
+    'settings.budgetWarn': '仅提示',
+    'settings.budgetBlock': '暂停定时检测',
+    'budget.exceeded': '本月检测费用 {n} 已超出预算',
+    'budget.uncounted': '本月其他币种的费用 {n} 未计入预算',
+
+    // CustomCaseDialog
+    'customCase.title': '自定义检测用例',
+    'customCase.list': '用例列表',
+    'customCase.new': '新建',
+    'customCase.empty': '暂无用例，启用的用例会在每次检测中作为额外检测项执行',
+    'customCase.edit': '编辑',
+    'customCase.delete': '删除',
+    'customCase.confirmDelete': '确定删除用例 {n}?',
+    'customCase.enabled': '启用',
+    'customCase.enabledHint': '在每次检测中执行',
+    'customCase.name': '名称',
+    'customCase.system': '系统提示词',
+    'customCase.messages': '消息（最后一条必须是 user）',
+    'customCase.params': '请求参数（留空使用默认值）',
+    'customCase.stream': '流式',
+    'customCase.temperature': 'Temperature',
+    'customCase.maxTokens': '最大输出 Token',
+    'customCase.timeout': '超时（秒）',
+    'customCase.assertions': '断言',
+    'customCase.test': '试运行',
+    'customCase.testHint': '使用 {n} 的当前配置运行，不保存历史',
+    'customCase.save': '保存',
+
+    // AuthenticityDialog
+    'authenticity.title': '模型真实性',
+    'authenticity.run': '运行检测',
+    'authenticity.runHint': '对 {n} 的当前模型发送探针，与参考档案比对',
+    'authenticity.capture': '采集档案',
+    'authenticity.captureHint': '以 {n} 当前的回答作为该模型的参考档案，请选择可信的官方渠道',
+    'authenticity.confidence': '可信度 {n}%',
+    'authenticity.noProfile': '该模型没有参考档案，仅比对回显的模型名和自述身份',
+    'authenticity.verdict.genuine': '一致',
+    'authenticity.verdict.suspicious': '可疑',
+    'authenticity.verdict.substituted': '疑似替换',
+    'authenticity.verdict.unknown': '无法判定',
+    'authenticity.expected': '期望',
+    'authenticity.observed': '实际',
+    'authenticity.profiles': '参考档案',
+    'authenticity.profilesEmpty': '暂无参考档案',
+    'authenticity.source': '来源',
+    'authenticity.delete': '删除',
+    'authenticity.confirmDelete': '确定删除 {n} 的参考档案?',
+
+    // SweepDialog
+    'sweep.title': '模型扫描',
+    'sweep.pattern': '模型筛选（通配符 gpt-4* 或 /正则/，留空扫描全部）',
+    'sweep.stream': '同时检测流式',
+    'sweep.concurrency': '并发（0 使用默认）',
+    'sweep.start': '开始扫描',
+    'sweep.export': '导出 CSV',
+    'sweep.summary': '共 {n} 个模型',
+    'sweep.model': '模型',
+    'sweep.status': '状态',
+    'sweep.latency': '耗时',
+    'sweep.ttft': '首字',
+    'sweep.error': '错误',
+    'sweep.cost': '费用 {n}',
+
+    // MatrixPanel
+    'matrix.providers': '供应商（使用已保存的地址和 Key）',
+    'matrix.models': '模型（每行一个，可用规范名）',
+    'matrix.keys': 'API Keys（每行一个，留空使用已保存的 Key）',
+    'matrix.concurrency': '并发（0 使用默认）',
+    'matrix.start': '开始检测',
+    'matrix.count': '{n} 个组合',
+    'matrix.rows': '行',
+    'matrix.cols': '列',
+    'matrix.export': '导出透视 CSV',
+    'matrix.axis.provider': '供应商',
+    'matrix.axis.model': '模型',
+    'matrix.axis.key': 'Key',
+    'matrix.summary': '分组汇总',
+    'matrix.success': '成功',
+    'matrix.avgLatency': '平均延迟',
+
+    // 模型元数据
+    'modelInfo.context': '上下文',
+    'modelInfo.maxOutput': '最大输出',
+    'modelInfo.modalities': '模态',
+    'modelInfo.tools': '工具调用',
+    'modelInfo.price': '{c} {i} / {o} 每百万 Token',
+    'modelInfo.source': '来源',
+    'modelInfo.unknown': '暂无该模型的元数据',
+    'modelInfo.refresh': '刷新元数据',
+    'modelInfo.refreshHint': '从供应商的模型列表接口重新获取上下文、模态和价格',
+
+    // SpendPanel
+    'spend.from': '从',
+    'spend.to': '到',
+    'spend.total': '合计 {n}',
+    'spend.empty': '该时间段内没有可计价的费用',
+    'spend.day': '日期',
+    'spend.provider': '供应商',
+    'spend.key': 'Key',
+    'spend.runs': '次数',
+    'spend.cost': '费用',
+    'spend.budget': '本月 {n} / 预算 {b}',
+    'spend.hint': '包含检测、扫描、用例试运行和真实性探针；价格未知的模型不计入，不同币种分别统计；清理或删除历史不影响费用',
   },
   en: {
     'app.export': 'Export',
@@ -100,10 +222,15 @@ const messages: Record<Locale, Record<string, string>> = {
     'config.model': 'Model',
     'config.reset': 'Reset',
     'config.batch': 'Batch',
+    'config.cases': 'Cases',
+    'config.authenticity': 'Authenticity',
+    'config.sweep': 'Sweep',
     'config.check': 'Check',
 
     'sidebar.providers': 'Providers',
     'sidebar.history': 'History',
+    'sidebar.matrix': 'Matrix',
+    'sidebar.spend': 'Spend',
 
     'card.totalLatency': 'Total',
     'card.availableModels': 'Available Models',
@@ -114,6 +241,7 @@ const messages: Record<Locale, Record<string, string>> = {
     'item.stream': 'Streaming',
     'item.models': 'Model List',
     'item.multi_turn': 'Multi-turn',
+    'item.authenticity': 'Model Authenticity',
 
     'history.total': '{n} records',
     'history.selectAll': 'Select All',
@@ -124,6 +252,11 @@ const messages: Record<Locale, Record<string, string>> = {
     'history.confirmDelete': 'Delete {n} records?',
     'history.confirmClear': 'Clear all history?',
     'history.empty': 'No history yet',
+    'history.viewTraffic': 'View Raw Traffic',
+    'history.hideTraffic': 'Hide Raw Traffic',
+    'history.exportHAR': 'Export HAR',
+    'history.exportJSONL': 'Export JSONL',
+    'history.trafficError': 'Network error',
 
     'batchKey.title': 'Batch Key Check',
     'batchKey.label': 'API Keys (one per line)',
@@ -137,6 +270,8 @@ const messages: Record<Locale, Record<string, string>> = {
     'addProvider.models': 'Models (one per line)',
     'addProvider.cancel': 'Cancel',
     'addProvider.add': 'Add',
+    'addProvider.demo': 'Add Demo Provider',
+    'addProvider.demoHint': 'A local mock OpenAI endpoint, no network or API key needed',
 
     'settings.title': 'Settings',
     'settings.providerManage': 'Provider Management',
@@ -148,6 +283,109 @@ const messages: Record<Locale, Record<string, string>> = {
     'settings.resetDefault': 'Reset to Default',
     'settings.close': 'Close',
     'settings.language': 'Language',
+    'settings.traffic': 'Traffic Recording',
+    'settings.trafficHint': 'Save raw requests and responses of each check (keys redacted); view and export them from history',
+    'settings.authenticity': 'Model Authenticity Check',
+    'settings.authenticityHint': 'Send extra probe prompts and compare the echoed model name, token counts and knowledge cutoff to detect model substitution',
+    'settings.budget': 'Monthly Budget',
+    'settings.budgetHint': 'Estimate the cost of each check, sweep and test run from per-model pricing; once spend this month exceeds the budget, warn or pause scheduled checks. 0 means unlimited. Only spend in the budget currency counts; other currencies are not converted',
+    'settings.budgetWarn': 'Warn only',
+    'settings.budgetBlock': 'Pause scheduled checks',
+    'budget.exceeded': 'Check spend this month ({n}) exceeds the budget',
+    'budget.uncounted': 'Spend in other currencies this month ({n}) is not counted toward the budget',
+
+    'customCase.title': 'Custom Check Cases',
+    'customCase.list': 'Cases',
+    'customCase.new': 'New',
+    'customCase.empty': 'No cases yet. Enabled cases run as extra items in every check',
+    'customCase.edit': 'Edit',
+    'customCase.delete': 'Delete',
+    'customCase.confirmDelete': 'Delete case {n}?',
+    'customCase.enabled': 'Enabled',
+    'customCase.enabledHint': 'Run in every check',
+    'customCase.name': 'Name',
+    'customCase.system': 'System Prompt',
+    'customCase.messages': 'Messages (the last one must be user)',
+    'customCase.params': 'Request Parameters (blank for defaults)',
+    'customCase.stream': 'Stream',
+    'customCase.temperature': 'Temperature',
+    'customCase.maxTokens': 'Max Output Tokens',
+    'customCase.timeout': 'Timeout (s)',
+    'customCase.assertions': 'Assertions',
+    'customCase.test': 'Test Run',
+    'customCase.testHint': "Run with {n}'s current config, not saved to history",
+    'customCase.save': 'Save',
+
+    'authenticity.title': 'Model Authenticity',
+    'authenticity.run': 'Run Check',
+    'authenticity.runHint': "Send probes to {n}'s current model and compare with the reference profile",
+    'authenticity.capture': 'Capture Profile',
+    'authenticity.captureHint': "Use {n}'s current answers as the model's reference profile; pick a trusted official endpoint",
+    'authenticity.confidence': 'Confidence {n}%',
+    'authenticity.noProfile': 'No reference profile for this model; only the echoed model name and self-reported identity are compared',
+    'authenticity.verdict.genuine': 'Genuine',
+    'authenticity.verdict.suspicious': 'Suspicious',
+    'authenticity.verdict.substituted': 'Likely substituted',
+    'authenticity.verdict.unknown': 'Unknown',
+    'authenticity.expected': 'Expected',
+    'authenticity.observed': 'Observed',
+    'authenticity.profiles': 'Reference Profiles',
+    'authenticity.profilesEmpty': 'No reference profiles yet',
+    'authenticity.source': 'Source',
+    'authenticity.delete': 'Delete',
+    'authenticity.confirmDelete': 'Delete the reference profile of {n}?',
+
+    'sweep.title': 'Model Sweep',
+    'sweep.pattern': 'Model filter (glob gpt-4* or /regex/, blank for all)',
+    'sweep.stream': 'Also check streaming',
+    'sweep.concurrency': 'Concurrency (0 for default)',
+    'sweep.start': 'Start Sweep',
+    'sweep.export': 'Export CSV',
+    'sweep.summary': '{n} models',
+    'sweep.model': 'Model',
+    'sweep.status': 'Status',
+    'sweep.latency': 'Latency',
+    'sweep.ttft': 'TTFT',
+    'sweep.error': 'Error',
+    'sweep.cost': 'Cost {n}',
+
+    'matrix.providers': 'Providers (saved URL and key)',
+    'matrix.models': 'Models (one per line, canonical names allowed)',
+    'matrix.keys': 'API Keys (one per line, blank for saved keys)',
+    'matrix.concurrency': 'Concurrency (0 for default)',
+    'matrix.start': 'Start',
+    'matrix.count': '{n} combinations',
+    'matrix.rows': 'Rows',
+    'matrix.cols': 'Columns',
+    'matrix.export': 'Export Pivot CSV',
+    'matrix.axis.provider': 'Provider',
+    'matrix.axis.model': 'Model',
+    'matrix.axis.key': 'Key',
+    'matrix.summary': 'Group Summary',
+    'matrix.success': 'Success',
+    'matrix.avgLatency': 'Avg Latency',
+
+    'modelInfo.context': 'Context',
+    'modelInfo.maxOutput': 'Max output',
+    'modelInfo.modalities': 'Modalities',
+    'modelInfo.tools': 'Tools',
+    'modelInfo.price': '{c} {i} / {o} per 1M tokens',
+    'modelInfo.source': 'Source',
+    'modelInfo.unknown': 'No metadata for this model',
+    'modelInfo.refresh': 'Refresh Metadata',
+    'modelInfo.refreshHint': "Fetch context window, modalities and pricing again from the provider's model list endpoint",
+
+    'spend.from': 'From',
+    'spend.to': 'To',
+    'spend.total': 'Total {n}',
+    'spend.empty': 'No priced spend in this range',
+    'spend.day': 'Day',
+    'spend.provider': 'Provider',
+    'spend.key': 'Key',
+    'spend.runs': 'Runs',
+    'spend.cost': 'Cost',
+    'spend.budget': 'This month {n} / budget {b}',
+    'spend.hint': 'Includes checks, sweeps, case test runs and authenticity probes; models without pricing are excluded and currencies are totaled separately; pruning or deleting history does not reduce spend',
   },
 }
 
@@ -164,5 +402,6 @@ export function t(key: string, params?: Record<string, string | number>): string
 
 // 检测项名称（响应式）
 export function checkItemName(item: string): string {
+  if (item.startsWith('custom:')) return item.slice('custom:'.length)
   return t(`item.${item}`) || item
 }
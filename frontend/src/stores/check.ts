@@ -1,5 +1,5 @@
 import { computed, reactive, ref } from 'vue'
-import type { ProviderInfo, CheckConfig, FullCheckResult, ProtocolType, HistoryItem } from '../types'
+import type { ProviderInfo, CheckConfig, FullCheckResult, ProtocolType, HistoryItem, HistoryQuery, BatchKeyCheckResult, KeySummary, TrafficInteraction, SweepOptions, SweepResult, MatrixSpec, MatrixResult, MatrixAxis, ModelInfo, SpendRow, Budget, BudgetStatus, CustomCase, CheckResult, ModelProfile, AuthenticityResult } from '../types'
 
 // 全局状态
 export const providers = ref<ProviderInfo[]>([])
@@ -10,7 +10,7 @@ export const isRunning = ref(false)
 export const allResults = ref<FullCheckResult[]>([])
 
 // 视图切换: 'check' | 'history'
-export const activeView = ref<'check' | 'history'>('check')
+export const activeView = ref<'check' | 'history' | 'matrix' | 'spend'>('check')
 
 // 历史记录
 export const historyItems = ref<HistoryItem[]>([])
@@ -168,6 +168,12 @@ export async function addProvider(data: { id: string; name: string; baseURL: str
   await initProviders()
 }
 
+export async function addDemoProvider() {
+  const p: ProviderInfo = await wails().AddDemoProvider()
+  await initProviders()
+  selectedProviderID.value = p.id
+}
+
 export async function deleteProvider(id: string) {
   await wails().DeleteProvider(id)
   providers.value = providers.value.filter(p => p.id !== id)
@@ -227,9 +233,9 @@ export async function resetAllProviders() {
 
 // --- 历史记录 ---
 
-export async function loadHistory(limit = 50, offset = 0) {
+export async function loadHistory(limit = 50, offset = 0, query: HistoryQuery = {}) {
   try {
-    const result = await wails().GetHistory(limit, offset)
+    const result = await wails().GetHistory({ ...query, limit, offset })
     historyItems.value = result.items || []
     historyTotal.value = result.total
   } catch (e) {
@@ -253,10 +259,49 @@ export async function clearAllHistory() {
   historyTotal.value = 0
 }
 
+// --- 流量录制 ---
+
+export const trafficRecording = ref(false)
+
+export async function loadTrafficRecording() {
+  trafficRecording.value = await wails().GetTrafficRecording()
+}
+
+export async function setTrafficRecording(on: boolean) {
+  await wails().SetTrafficRecording(on)
+  trafficRecording.value = on
+}
+
+// --- 模型真实性 ---
+
+export const authenticityEnabled = ref(false)
+
+export async function loadAuthenticityEnabled() {
+  authenticityEnabled.value = await wails().GetAuthenticityEnabled()
+}
+
+export async function setAuthenticityEnabled(on: boolean) {
+  await wails().SetAuthenticityEnabled(on)
+  authenticityEnabled.value = on
+}
+
+export async function getHistoryTraffic(id: number): Promise<TrafficInteraction[]> {
+  return (await wails().GetHistoryTraffic(id)) || []
+}
+
+export async function exportHistoryTraffic(id: number, format: 'har' | 'jsonl') {
+  try {
+    await wails().ExportHistoryTraffic(id, format)
+  } catch (e) {
+    console.error('Export traffic failed:', e)
+  }
+}
+
 // --- 批量 Key 检测 ---
 
 export const isBatchRunning = ref(false)
 export const batchKeyResults = ref<FullCheckResult[]>([])
+export const batchKeySummary = ref<KeySummary | null>(null)
 
 export async function runBatchKeyCheck(apiKeys: string[]): Promise<FullCheckResult[]> {
   const cfg = checkConfigs.get(selectedProviderID.value)
@@ -265,10 +310,12 @@ export async function runBatchKeyCheck(apiKeys: string[]): Promise<FullCheckResu
   isBatchRunning.value = true
   batchKeyResults.value = []
   try {
-    const results: FullCheckResult[] = await wails().RunBatchKeyCheck(
+    const res: BatchKeyCheckResult = await wails().RunBatchKeyCheck(
       cfg.baseURL, cfg.model, cfg.providerID, cfg.providerName, cfg.protocol, apiKeys
     )
+    const results = (res.items || []).map(it => it.result)
     batchKeyResults.value = results
+    batchKeySummary.value = res.summary
     return results
   } catch (e) {
     console.error('Batch key check failed:', e)
@@ -278,6 +325,167 @@ export async function runBatchKeyCheck(apiKeys: string[]): Promise<FullCheckResu
   }
 }
 
+// --- 自定义检测用例 ---
+
+export const customCases = ref<CustomCase[]>([])
+
+export async function loadCustomCases() {
+  try {
+    customCases.value = (await wails().GetCustomCases()) || []
+  } catch (e) {
+    console.error('Load custom cases failed:', e)
+  }
+}
+
+// 保存失败时抛出后端的校验错误，由编辑器展示
+export async function saveCustomCase(c: CustomCase): Promise<number> {
+  const id: number = await wails().SaveCustomCase(c)
+  await loadCustomCases()
+  return id
+}
+
+export async function deleteCustomCase(id: number) {
+  await wails().DeleteCustomCase(id)
+  await loadCustomCases()
+}
+
+// 用当前选中供应商的配置试运行用例，不保存历史
+export async function testCustomCase(c: CustomCase): Promise<CheckResult> {
+  return await wails().TestCustomCase(c, selectedProviderID.value)
+}
+
+// --- 模型真实性 ---
+
+export const modelProfiles = ref<ModelProfile[]>([])
+
+export async function loadModelProfiles() {
+  try {
+    modelProfiles.value = (await wails().GetModelProfiles()) || []
+  } catch (e) {
+    console.error('Load model profiles failed:', e)
+  }
+}
+
+// 以当前选中供应商的回答作为参考档案，失败时抛出
+export async function captureModelProfile(): Promise<ModelProfile> {
+  const row: ModelProfile = await wails().CaptureModelProfile(selectedProviderID.value)
+  await loadModelProfiles()
+  return row
+}
+
+export async function deleteModelProfile(model: string) {
+  await wails().DeleteModelProfile(model)
+  await loadModelProfiles()
+}
+
+export async function runAuthenticityCheck(): Promise<AuthenticityResult> {
+  return await wails().RunAuthenticityCheck(selectedProviderID.value)
+}
+
+// --- 模型元数据 ---
+
+export const modelInfo = reactive(new Map<string, ModelInfo[]>())
+
+export async function loadModelInfo(providerID: string) {
+  modelInfo.set(providerID, (await wails().GetModelInfo(providerID)) || [])
+}
+
+export async function refreshModelInfo(providerID: string) {
+  try {
+    modelInfo.set(providerID, (await wails().RefreshModelInfo(providerID)) || [])
+  } catch (e) {
+    console.error('Refresh model info failed:', e)
+  }
+}
+
+// --- 模型扫描 ---
+
+export const isSweepRunning = ref(false)
+export const sweepResult = ref<SweepResult | null>(null)
+
+export async function sweepModels(opts: SweepOptions): Promise<SweepResult | null> {
+  const cfg = checkConfigs.get(selectedProviderID.value)
+  if (!cfg || !cfg.baseURL) return null
+
+  isSweepRunning.value = true
+  sweepResult.value = null
+  try {
+    const res: SweepResult = await wails().SweepModels(
+      cfg.baseURL, cfg.apiKey, cfg.providerID, cfg.providerName, cfg.protocol, opts
+    )
+    sweepResult.value = res
+    return res
+  } catch (e) {
+    console.error('Model sweep failed:', e)
+    return null
+  } finally {
+    isSweepRunning.value = false
+  }
+}
+
+export async function exportSweep(result: SweepResult) {
+  try {
+    await wails().ExportSweep(result)
+  } catch (e) {
+    console.error('Export sweep failed:', e)
+  }
+}
+
+// --- 矩阵检测 ---
+
+export const isMatrixRunning = ref(false)
+export const matrixResult = ref<MatrixResult | null>(null)
+
+export async function runMatrixCheck(spec: MatrixSpec): Promise<MatrixResult | null> {
+  isMatrixRunning.value = true
+  matrixResult.value = null
+  try {
+    const res: MatrixResult = await wails().RunMatrixCheck(spec)
+    matrixResult.value = res
+    await loadHistory()
+    return res
+  } catch (e) {
+    console.error('Matrix check failed:', e)
+    return null
+  } finally {
+    isMatrixRunning.value = false
+  }
+}
+
+export async function exportMatrixPivot(result: MatrixResult, rows: MatrixAxis, cols: MatrixAxis) {
+  try {
+    await wails().ExportMatrixPivot(result, rows, cols)
+  } catch (e) {
+    console.error('Export matrix failed:', e)
+  }
+}
+
+// --- 费用与预算 ---
+
+export const spendRows = ref<SpendRow[]>([])
+export const budgetStatus = ref<BudgetStatus | null>(null)
+
+export async function loadSpend(from = '', to = '') {
+  try {
+    spendRows.value = (await wails().GetSpend(from, to)) || []
+  } catch (e) {
+    console.error('Load spend failed:', e)
+  }
+}
+
+export async function loadBudgetStatus() {
+  try {
+    budgetStatus.value = await wails().GetBudgetStatus()
+  } catch (e) {
+    console.error('Load budget failed:', e)
+  }
+}
+
+export async function setBudget(b: Budget) {
+  await wails().SetBudget(b)
+  await loadBudgetStatus()
+}
+
 function updateAllResults() {
   allResults.value = Array.from(checkResults.values())
 }
@@ -12,8 +12,22 @@ export interface ProviderInfo {
 }
 
 // 检测状态
-export type CheckStatus = 'pending' | 'running' | 'success' | 'failed' | 'warning'
-export type CheckItem = 'connectivity' | 'chat' | 'stream' | 'models' | 'multi_turn'
+export type CheckStatus = 'pending' | 'running' | 'success' | 'failed' | 'warning' | 'skipped'
+// 自定义用例为 custom:<用例名称>
+export type CheckItem = 'connectivity' | 'chat' | 'stream' | 'models' | 'multi_turn' | 'authenticity' | `custom:${string}`
+
+export type AssertionKind = 'contains' | 'regex' | 'exact' | 'json_path' | 'max_length' | 'max_latency' | 'language'
+
+export interface Assertion {
+  kind: AssertionKind
+  path?: string
+  value: string
+}
+
+export interface AssertionResult extends Assertion {
+  passed: boolean
+  actual?: string
+}
 
 // 检测结果
 export interface CheckResult {
@@ -25,6 +39,9 @@ export interface CheckResult {
   detail: string
   tokenIn: number
   tokenOut: number
+  errorKind?: string
+  cost?: number
+  assertions?: AssertionResult[]
 }
 
 export interface FullCheckResult {
@@ -38,6 +55,113 @@ export interface FullCheckResult {
   startTime: string
   endTime: string
   totalLatency: number
+  maskedKey?: string
+  cost?: number
+  currency?: string
+}
+
+// 批量 Key 检测
+export type KeyVerdict = 'valid' | 'valid_limited' | 'no_quota' | 'invalid' | 'revoked' | 'model_not_allowed' | 'network_error'
+
+export interface KeyResult {
+  apiKey: string
+  maskedKey: string
+  verdict: KeyVerdict
+  errorKind?: string
+  result: FullCheckResult
+}
+
+export interface KeySummary {
+  total: number
+  usable: number
+  verdicts: Record<string, number>
+}
+
+export interface BatchKeyCheckResult {
+  items: KeyResult[]
+  summary: KeySummary
+}
+
+// 模型扫描
+export interface SweepOptions {
+  models: string[]
+  pattern: string // 通配符 (gpt-4*) 或 /正则/
+  stream: boolean
+  concurrency: number // 0 使用批量并发设置
+}
+
+export interface SweepRow {
+  model: string
+  status: CheckStatus
+  latency: number
+  ttft: number
+  errorKind?: string
+  chat: CheckResult
+  stream?: CheckResult
+  cost?: number
+  currency?: string // 为空表示价格未知
+}
+
+export interface SweepResult {
+  providerID: string
+  providerName: string
+  baseURL: string
+  protocol: string
+  listed: string[]
+  rows: SweepRow[]
+  summary: { total: number; success: number; warning: number; failed: number; skipped: number }
+  startTime: string
+  endTime: string
+}
+
+// 供应商 × 模型 × Key 矩阵检测
+export interface MatrixProvider {
+  providerID: string
+  providerName?: string
+  baseURL?: string // 为空时使用已保存的配置
+  protocol?: ProtocolType
+  models?: string[] // 为空时使用 MatrixSpec.models
+  keys?: string[] // 为空时使用 MatrixSpec.keys，再为空时使用已保存的 Key
+}
+
+export interface MatrixSpec {
+  providers: MatrixProvider[]
+  models: string[]
+  keys: string[]
+  concurrency: number
+}
+
+export type MatrixAxis = 'provider' | 'model' | 'key'
+
+export interface MatrixCell {
+  providerID: string
+  providerName: string
+  baseURL: string
+  protocol: string
+  model: string
+  maskedKey: string
+  keyID: string // 同一次检测中 Key 的序号，Key 维度按此分组
+  keyLabel: string // Key 维度的展示名，脱敏结果相同时附加序号
+  status: CheckStatus
+  verdict: string
+  latency: number
+  result: FullCheckResult
+}
+
+export interface MatrixGroup {
+  value: string // 供应商维度为供应商 ID，Key 维度为 keyID
+  label: string // 展示名，供应商维度为供应商名称，Key 维度为脱敏 Key
+  total: number
+  success: number
+  avgLatency: number
+  cells: number[] // MatrixResult.cells 下标
+}
+
+export interface MatrixResult {
+  cells: MatrixCell[]
+  byProvider: MatrixGroup[]
+  byModel: MatrixGroup[]
+  byKey: MatrixGroup[]
 }
 
 // 配置
@@ -63,6 +187,42 @@ export interface HistoryItem {
   totalLatency: number
   status: string
   createdAt: string
+  hasTraffic: boolean
+  cost: number
+  currency: string
+  maskedKey: string
+}
+
+// 录制的原始流量 (认证信息已脱敏)
+export interface TrafficChunk {
+  offset: number
+  data: string
+}
+
+export interface TrafficInteraction {
+  startedAt: string
+  headerAt: number
+  duration: number
+  request: { method: string; url: string; header: Record<string, string>; body: string }
+  response: { status: number; header: Record<string, string>; body: string; chunks?: TrafficChunk[] }
+  error?: string
+}
+
+export interface HistoryQuery {
+  providerIDs?: string[]
+  model?: string
+  protocol?: string
+  statuses?: string[]
+  from?: string
+  to?: string
+  minLatency?: number
+  maxLatency?: number
+  failedItem?: string
+  text?: string
+  sortBy?: 'date' | 'latency'
+  sortAsc?: boolean
+  limit?: number
+  offset?: number
 }
 
 export interface HistoryListResult {
@@ -70,6 +230,252 @@ export interface HistoryListResult {
   total: number
 }
 
+export interface StatsQuery {
+  providerID?: string
+  model?: string
+  from?: string
+  to?: string
+  bucket?: '' | 'hour' | 'day'
+}
+
+export interface Percentiles {
+  count: number
+  min: number
+  max: number
+  avg: number
+  p50: number
+  p90: number
+  p95: number
+  p99: number
+}
+
+export interface ItemStats {
+  item: string
+  total: number
+  success: number
+  warning: number
+  failed: number
+  availability: number
+  latency: Percentiles
+}
+
+export interface StatsBucket {
+  start: string
+  total: number
+  failed: number
+  availability: number
+  p50Latency: number
+}
+
+export interface ProviderStats {
+  providerID: string
+  model: string
+  runs: number
+  availability: number
+  items: ItemStats[]
+  latency: Percentiles
+  ttft: Percentiles
+  failureCauses: Record<string, number>
+  bucketSize: 'hour' | 'day'
+  buckets: StatsBucket[]
+}
+
+export interface ProviderRank {
+  rank: number
+  providerID: string
+  providerName: string
+  runs: number
+  successRate: number
+  p50Latency: number
+  p95Latency: number
+}
+
+export interface ProviderRetention {
+  maxAgeDays: number
+  maxRows: number
+}
+
+export interface RetentionPolicy {
+  maxAgeDays: number
+  maxRows: number
+  providers: Record<string, ProviderRetention> | null
+  compactMode: '' | 'failures' | 'changes'
+  compactAfterDays: number
+}
+
+// 检测费用
+export interface SpendRow {
+  providerID: string
+  maskedKey: string
+  day: string
+  currency: string
+  cost: number
+  runs: number
+}
+
+export interface Budget {
+  amount: number
+  currency: string
+  action: 'warn' | 'block'
+}
+
+export interface BudgetStatus {
+  budget: Budget
+  spent: number
+  exceeded: boolean
+  uncounted?: Record<string, number> // 其他币种的费用，不换算也不计入预算
+}
+
+export interface PruneReport {
+  deleted: number
+  bytesBefore: number
+  bytesAfter: number
+  freedBytes: number
+}
+
+export interface BackupOptions {
+  includeKeys: boolean
+  passphrase: string
+  includeHistory: boolean
+}
+
+export interface RestoreConflict {
+  providerID: string
+  kind: 'provider' | 'config' | 'visibility'
+  resolution: 'skipped' | 'replaced'
+}
+
+export interface RestoreResult {
+  providers: number
+  configs: number
+  keys: number
+  visibility: number
+  history: number
+  conflicts: RestoreConflict[]
+}
+
+export interface ScheduleInfo {
+  id: number
+  providerID: string
+  intervalSeconds: number
+  cron: string
+  jitterSeconds: number
+  enabled: number
+  lastRunAt: string
+  lastStatus: string
+  createdAt: string
+  nextRun: string
+  running: boolean
+}
+
+export type WebhookKind = 'slack' | 'feishu' | 'dingtalk' | 'wecom' | 'discord' | 'generic'
+
+export interface Webhook {
+  id: number
+  name: string
+  kind: WebhookKind
+  url: string
+  enabled: number
+  providerIDs: string // JSON 数组
+  failThreshold: number
+  quietStart: string
+  quietEnd: string
+  createdAt?: string
+}
+
+// 自定义检测用例，JSON 字段以字符串保存
+export interface CustomCase {
+  id: number
+  name: string
+  systemPrompt: string
+  messages: string // JSON 数组 [{role, content}]
+  params: string // JSON 对象 {stream, temperature, maxTokens, timeoutSeconds}
+  assertions: string // JSON 数组 Assertion[]
+  enabled: number
+  createdAt?: string
+}
+
+// 模型元数据，数值为 0 / 字段缺失表示未知
+export interface ModelInfo {
+  id: string
+  contextWindow?: number
+  maxOutput?: number
+  inputModalities?: string[]
+  outputModalities?: string[]
+  tools?: boolean
+  streaming?: boolean
+  inputPrice?: number // 每百万 token
+  outputPrice?: number
+  currency?: 'USD' | 'CNY' | string // 为空表示价格未知
+  source?: 'preset' | 'openrouter' | 'gemini' | 'ollama' | string
+}
+
+// 模型别名：规范模型名 -> 各供应商的模型 ID
+export interface ModelAlias {
+  canonical: string
+  ids: Record<string, string> // 供应商 ID -> 模型 ID
+}
+
+export interface ModelAliasRow {
+  canonical: string
+  providerID: string
+  modelID: string
+}
+
+// 模型真实性检测
+export type AuthenticityVerdict = 'genuine' | 'suspicious' | 'substituted' | 'unknown'
+
+export interface FingerprintEvidence {
+  signal: string // echo / identity / tokenizer / cutoff / answer:<探针>
+  expected: string
+  observed: string
+  match: boolean
+  weight: number
+}
+
+export interface FingerprintObservation {
+  probeID: string
+  kind: 'identity' | 'tokenizer' | 'cutoff' | 'answer'
+  answer: string
+  echoedModel: string
+  promptTokens: number
+  compTokens: number
+  latency: number
+  error?: string
+}
+
+export interface AuthenticityResult {
+  model: string
+  confidence: number // 0~1
+  verdict: AuthenticityVerdict
+  hasProfile: boolean
+  evidence: FingerprintEvidence[]
+  observations: FingerprintObservation[]
+}
+
+export interface ModelProfile {
+  model: string
+  profile: string // JSON {model, cutoff, promptTokens, answers, source, capturedAt}
+  source: string
+  updatedAt: string
+}
+
+export interface APIServerConfig {
+  addr: string
+  token: string
+  running: boolean
+}
+
+export interface ProxyTarget {
+  providerID: string
+  name: string
+  baseURL: string
+  protocol: ProtocolType
+  models: string[]
+  status: string
+  latency: number
+}
+
 export const PROTOCOL_NAMES: Record<ProtocolType, string> = {
   openai: 'OpenAI',
   anthropic: 'Anthropic',
@@ -0,0 +1,358 @@
+// Package api 提供本地 REST API，让其他工具无需界面即可驱动检测
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"pingai/internal/checker"
+	"pingai/internal/stats"
+	"pingai/internal/store"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider 供应商信息
+type Provider struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	BaseURL   string   `json:"baseURL"`
+	Protocol  string   `json:"protocol"`
+	Models    []string `json:"models"`
+	IsBuiltin bool     `json:"isBuiltin"`
+}
+
+// CheckRequest 检测请求，只填 ProviderID 时使用已保存的配置，其余字段用于覆盖
+type CheckRequest struct {
+	ProviderID   string `json:"providerID"`
+	ProviderName string `json:"providerName,omitempty"`
+	BaseURL      string `json:"baseURL,omitempty"`
+	APIKey       string `json:"apiKey,omitempty"`
+	Model        string `json:"model,omitempty"`
+	Protocol     string `json:"protocol,omitempty"`
+}
+
+// HistoryItem 历史记录，字段与 checker.FullCheckResult 一致，另含 ID、整体状态和时间
+type HistoryItem struct {
+	ID        int64  `json:"id"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"createdAt"`
+	checker.FullCheckResult
+}
+
+// HistoryPage 历史分页
+type HistoryPage struct {
+	Items []HistoryItem `json:"items"`
+	Total int           `json:"total"`
+}
+
+// Service API 背后的业务实现，与 App 的绑定方法一一对应
+type Service interface {
+	Providers() []Provider
+	Check(ctx context.Context, req CheckRequest) (checker.FullCheckResult, error) // ctx 取消时中止检测
+	History(q store.HistoryQuery) (HistoryPage, error)
+	Stats(q stats.Query) (stats.ProviderStats, error)
+	Compare(from, to string) ([]stats.ProviderRank, error)
+	BatchConcurrency() int // 批量检测的并发上限
+}
+
+// ErrorResponse 错误响应
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server REST API 服务
+type Server struct {
+	svc  Service
+	runs *runStore
+
+	// 异步运行的生命周期，由 SetBackground 绑定到宿主的后台任务
+	bg context.Context
+	wg *sync.WaitGroup
+
+	mu    sync.RWMutex
+	token string
+
+	srv *http.Server
+	ln  net.Listener
+}
+
+// NewToken 生成随机访问令牌
+func NewToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// New 创建 API 服务，token 为空时拒绝所有需要认证的请求
+func New(svc Service, token string) *Server {
+	return &Server{svc: svc, token: token, runs: newRunStore(), bg: context.Background(), wg: &sync.WaitGroup{}}
+}
+
+// SetBackground 设置异步运行使用的上下文和等待组：ctx 取消时中止进行中的运行，
+// 每个运行中的检测计入 wg，宿主退出时可等待其结束。须在 Listen 之前调用
+func (s *Server) SetBackground(ctx context.Context, wg *sync.WaitGroup) {
+	s.bg, s.wg = ctx, wg
+}
+
+// SetToken 更换访问令牌，旧令牌立即失效
+func (s *Server) SetToken(token string) {
+	s.mu.Lock()
+	s.token = token
+	s.mu.Unlock()
+}
+
+// Handler 返回路由，便于测试或嵌入其他服务
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.Handle("GET /api/providers", s.auth(s.handleProviders))
+	mux.Handle("POST /api/checks", s.auth(s.handleCheck))
+	mux.Handle("POST /api/batches", s.auth(s.handleBatch))
+	mux.Handle("GET /api/runs/{id}", s.auth(s.handleRun))
+	mux.Handle("GET /api/history", s.auth(s.handleHistory))
+	mux.Handle("GET /api/stats", s.auth(s.handleStats))
+	mux.Handle("GET /api/stats/compare", s.auth(s.handleCompare))
+	mux.Handle("POST /api/reports", s.auth(s.handleReport))
+	return mux
+}
+
+// Listen 在 addr 上启动服务
+func (s *Server) Listen(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.ln = ln
+	s.srv = &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go s.srv.Serve(ln)
+	return nil
+}
+
+// Addr 实际监听地址
+func (s *Server) Addr() string {
+	if s.ln == nil {
+		return ""
+	}
+	return s.ln.Addr().String()
+}
+
+// Close 停止服务
+func (s *Server) Close() error {
+	if s.srv == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.srv.Shutdown(ctx)
+}
+
+// auth 校验 Authorization: Bearer <token>
+func (s *Server) auth(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		token := s.token
+		s.mu.RUnlock()
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="pingai"`)
+			writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		next(w, r)
+	})
+}
+
+func (s *Server) handleProviders(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.svc.Providers())
+}
+
+// handleCheck 同步执行单个检测，?async=true 时返回运行 ID
+func (s *Server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	var req CheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.ProviderID == "" {
+		writeError(w, http.StatusBadRequest, errors.New("providerID is required"))
+		return
+	}
+	if isTrue(r.URL.Query().Get("async")) {
+		writeJSON(w, http.StatusAccepted, s.startRun([]CheckRequest{req}))
+		return
+	}
+	result, err := s.svc.Check(r.Context(), req)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleBatch 默认异步执行批量检测，?wait=true 时等待全部完成
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Items []CheckRequest `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(body.Items) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("items is empty"))
+		return
+	}
+	for _, it := range body.Items {
+		if it.ProviderID == "" {
+			writeError(w, http.StatusBadRequest, errors.New("providerID is required"))
+			return
+		}
+	}
+
+	run := s.startRun(body.Items)
+	if !isTrue(r.URL.Query().Get("wait")) {
+		writeJSON(w, http.StatusAccepted, run)
+		return
+	}
+	select {
+	case <-s.runs.done(run.ID):
+	case <-r.Context().Done():
+		return
+	}
+	got, _ := s.runs.get(run.ID)
+	writeJSON(w, http.StatusOK, got)
+}
+
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	run, ok := s.runs.get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("run not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	hq := store.HistoryQuery{
+		ProviderIDs: splitList(q["provider"]),
+		Model:       q.Get("model"),
+		Protocol:    q.Get("protocol"),
+		Statuses:    splitList(q["status"]),
+		From:        q.Get("from"),
+		To:          q.Get("to"),
+		FailedItem:  q.Get("failedItem"),
+		Text:        q.Get("q"),
+		SortBy:      q.Get("sort"),
+		SortAsc:     q.Get("order") == "asc",
+	}
+	hq.MinLatency, _ = strconv.ParseInt(q.Get("minLatency"), 10, 64)
+	hq.MaxLatency, _ = strconv.ParseInt(q.Get("maxLatency"), 10, 64)
+	hq.Limit, _ = strconv.Atoi(q.Get("limit"))
+	hq.Offset, _ = strconv.Atoi(q.Get("offset"))
+	if hq.Limit < 0 || hq.Limit > 1000 {
+		hq.Limit = 1000
+	}
+
+	page, err := s.svc.History(hq)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	result, err := s.svc.Stats(stats.Query{
+		ProviderID: q.Get("provider"),
+		Model:      q.Get("model"),
+		From:       q.Get("from"),
+		To:         q.Get("to"),
+		Bucket:     q.Get("bucket"),
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	ranks, err := s.svc.Compare(q.Get("from"), q.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ranks)
+}
+
+// handleReport 根据给定结果或运行 ID 生成报告，?format=text 时返回文本摘要
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		RunID   string                    `json:"runID"`
+		Results []checker.FullCheckResult `json:"results"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	results := body.Results
+	if body.RunID != "" {
+		run, ok := s.runs.get(body.RunID)
+		if !ok {
+			writeError(w, http.StatusNotFound, errors.New("run not found"))
+			return
+		}
+		results = run.Results
+	}
+
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(checker.GenerateTextSummary(results)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(checker.GenerateReport(results)))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+// splitList 支持 ?status=a&status=b 和 ?status=a,b 两种写法
+func splitList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+func isTrue(s string) bool {
+	b, _ := strconv.ParseBool(s)
+	return b
+}
@@ -0,0 +1,282 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"pingai/internal/checker"
+	"pingai/internal/stats"
+	"pingai/internal/store"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeService struct {
+	release   chan struct{} // 非 nil 时 Check 阻塞到关闭
+	lastQuery store.HistoryQuery
+	limit     int
+
+	mu             sync.Mutex
+	inflight, peak int
+}
+
+func (f *fakeService) Providers() []Provider {
+	return []Provider{{ID: "openai", Name: "OpenAI", Protocol: "openai", Models: []string{"gpt-4o"}, IsBuiltin: true}}
+}
+
+func (f *fakeService) Check(ctx context.Context, req CheckRequest) (checker.FullCheckResult, error) {
+	f.mu.Lock()
+	f.inflight++
+	f.peak = max(f.peak, f.inflight)
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inflight--
+		f.mu.Unlock()
+	}()
+	if f.release != nil {
+		select {
+		case <-f.release:
+		case <-ctx.Done():
+			return checker.FullCheckResult{}, ctx.Err()
+		}
+	}
+	if req.ProviderID == "missing" {
+		return checker.FullCheckResult{}, errors.New("供应商 missing 缺少 Base URL 或模型配置")
+	}
+	return checker.FullCheckResult{
+		ProviderID: req.ProviderID,
+		Model:      "gpt-4o",
+		Results:    []checker.CheckResult{{Item: checker.CheckChat, Status: checker.StatusSuccess}},
+	}, nil
+}
+
+func (f *fakeService) History(q store.HistoryQuery) (HistoryPage, error) {
+	f.lastQuery = q
+	return HistoryPage{Items: []HistoryItem{{ID: 1, Status: "success", FullCheckResult: checker.FullCheckResult{ProviderID: "openai"}}}, Total: 1}, nil
+}
+
+func (f *fakeService) Stats(q stats.Query) (stats.ProviderStats, error) {
+	return stats.ProviderStats{ProviderID: q.ProviderID, Runs: 3}, nil
+}
+
+func (f *fakeService) Compare(from, to string) ([]stats.ProviderRank, error) {
+	return []stats.ProviderRank{{Rank: 1, ProviderID: "openai"}}, nil
+}
+
+func (f *fakeService) BatchConcurrency() int {
+	return f.limit
+}
+
+const testToken = "secret-token"
+
+func do(t *testing.T, h http.Handler, method, path, body string, auth bool) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if auth {
+		req.Header.Set("Authorization", "Bearer "+testToken)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatalf("解析响应失败: %v (%s)", err, rec.Body.String())
+	}
+	return v
+}
+
+func TestAuth(t *testing.T) {
+	h := New(&fakeService{}, testToken).Handler()
+
+	if rec := do(t, h, "GET", "/api/health", "", false); rec.Code != http.StatusOK {
+		t.Errorf("health 无需认证, 得到 %d", rec.Code)
+	}
+	if rec := do(t, h, "GET", "/api/providers", "", false); rec.Code != http.StatusUnauthorized {
+		t.Errorf("缺少令牌应返回 401, 得到 %d", rec.Code)
+	}
+	req := httptest.NewRequest("GET", "/api/providers", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("错误令牌应返回 401, 得到 %d", rec.Code)
+	}
+
+	// 未配置令牌时拒绝全部请求
+	empty := New(&fakeService{}, "").Handler()
+	req = httptest.NewRequest("GET", "/api/providers", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec = httptest.NewRecorder()
+	empty.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("空令牌应返回 401, 得到 %d", rec.Code)
+	}
+
+	// 更换令牌后旧令牌失效
+	s := New(&fakeService{}, "old")
+	s.SetToken(testToken)
+	if rec := do(t, s.Handler(), "GET", "/api/providers", "", true); rec.Code != http.StatusOK {
+		t.Errorf("新令牌应通过认证, 得到 %d", rec.Code)
+	}
+
+	providers := decode[[]Provider](t, do(t, h, "GET", "/api/providers", "", true))
+	if len(providers) != 1 || providers[0].ID != "openai" {
+		t.Errorf("providers = %+v", providers)
+	}
+}
+
+func TestCheckAndReport(t *testing.T) {
+	h := New(&fakeService{}, testToken).Handler()
+
+	rec := do(t, h, "POST", "/api/checks", `{"providerID":"openai"}`, true)
+	result := decode[checker.FullCheckResult](t, rec)
+	if rec.Code != http.StatusOK || result.ProviderID != "openai" || result.Results[0].Item != checker.CheckChat {
+		t.Errorf("check = %d %+v", rec.Code, result)
+	}
+	if rec := do(t, h, "POST", "/api/checks", `{"providerID":"missing"}`, true); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("无法检测应返回 422, 得到 %d", rec.Code)
+	}
+	if rec := do(t, h, "POST", "/api/checks", `{}`, true); rec.Code != http.StatusBadRequest {
+		t.Errorf("缺少 providerID 应返回 400, 得到 %d", rec.Code)
+	}
+
+	body, _ := json.Marshal(map[string]any{"results": []checker.FullCheckResult{result}})
+	rec = do(t, h, "POST", "/api/reports", string(body), true)
+	report := decode[checker.Report](t, rec)
+	if report.Summary.Total != 1 || report.Summary.Success != 1 {
+		t.Errorf("report = %+v", report.Summary)
+	}
+	rec = do(t, h, "POST", "/api/reports?format=text", string(body), true)
+	if !strings.Contains(rec.Body.String(), "AI API Check Report") {
+		t.Errorf("文本报告 = %s", rec.Body.String())
+	}
+}
+
+func TestAsyncBatch(t *testing.T) {
+	svc := &fakeService{release: make(chan struct{})}
+	h := New(svc, testToken).Handler()
+
+	rec := do(t, h, "POST", "/api/batches", `{"items":[{"providerID":"openai"},{"providerID":"missing"}]}`, true)
+	run := decode[Run](t, rec)
+	if rec.Code != http.StatusAccepted || run.Status != RunRunning || run.Total != 2 || run.ID == "" {
+		t.Fatalf("batch = %d %+v", rec.Code, run)
+	}
+
+	close(svc.release)
+	deadline := time.Now().Add(time.Second)
+	for run.Status != RunDone && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		run = decode[Run](t, do(t, h, "GET", "/api/runs/"+run.ID, "", true))
+	}
+	if run.Status != RunDone || run.Completed != 2 || run.FinishedAt == "" {
+		t.Fatalf("run = %+v", run)
+	}
+	if run.Results[0].ProviderID != "openai" || run.Errors[1] == "" {
+		t.Errorf("run 结果 = %+v", run)
+	}
+
+	// 基于运行 ID 生成报告
+	rec = do(t, h, "POST", "/api/reports", `{"runID":"`+run.ID+`"}`, true)
+	if report := decode[checker.Report](t, rec); report.Summary.Total != 2 {
+		t.Errorf("运行报告 = %+v", report.Summary)
+	}
+
+	// wait=true 同步返回
+	svc.release = nil
+	rec = do(t, h, "POST", "/api/batches?wait=true", `{"items":[{"providerID":"openai"}]}`, true)
+	if run := decode[Run](t, rec); rec.Code != http.StatusOK || run.Status != RunDone {
+		t.Errorf("wait 批量 = %d %+v", rec.Code, run)
+	}
+	if rec := do(t, h, "GET", "/api/runs/nope", "", true); rec.Code != http.StatusNotFound {
+		t.Errorf("未知运行应返回 404, 得到 %d", rec.Code)
+	}
+
+	// 并发受批量检测上限约束
+	svc.limit = 2
+	rec = do(t, h, "POST", "/api/batches?wait=true", `{"items":[{"providerID":"a"},{"providerID":"b"},{"providerID":"c"},{"providerID":"d"},{"providerID":"e"}]}`, true)
+	if run := decode[Run](t, rec); run.Completed != 5 || svc.peak > 2 {
+		t.Errorf("并发上限 2, 峰值 %d, run = %+v", svc.peak, run)
+	}
+}
+
+func TestRunsStopWithBackground(t *testing.T) {
+	svc := &fakeService{release: make(chan struct{}), limit: 1}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	srv := New(svc, testToken)
+	srv.SetBackground(ctx, &wg)
+	h := srv.Handler()
+
+	// 并发为 1：一个检测进行中，另一个等待名额
+	rec := do(t, h, "POST", "/api/batches", `{"items":[{"providerID":"a"},{"providerID":"b"}]}`, true)
+	run := decode[Run](t, rec)
+
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("后台上下文取消后运行应结束")
+	}
+	run = decode[Run](t, do(t, h, "GET", "/api/runs/"+run.ID, "", true))
+	if run.Status != RunDone || len(run.Errors) != 2 {
+		t.Errorf("取消后的运行 = %+v", run)
+	}
+}
+
+func TestHistoryAndStats(t *testing.T) {
+	svc := &fakeService{}
+	h := New(svc, testToken).Handler()
+
+	rec := do(t, h, "GET", "/api/history?provider=openai,groq&status=failed&status=warning&from=2026-03-01&limit=5000&sort=latency&order=asc", "", true)
+	page := decode[HistoryPage](t, rec)
+	if page.Total != 1 || page.Items[0].ProviderID != "openai" {
+		t.Errorf("history = %+v", page)
+	}
+	q := svc.lastQuery
+	if len(q.ProviderIDs) != 2 || len(q.Statuses) != 2 || q.From != "2026-03-01" || q.Limit != 1000 || q.SortBy != "latency" || !q.SortAsc {
+		t.Errorf("解析的查询 = %+v", q)
+	}
+	// 历史项字段与 FullCheckResult 平铺
+	if !strings.Contains(rec.Body.String(), `"providerID":"openai"`) {
+		t.Errorf("历史 JSON = %s", rec.Body.String())
+	}
+
+	if s := decode[stats.ProviderStats](t, do(t, h, "GET", "/api/stats?provider=openai", "", true)); s.ProviderID != "openai" || s.Runs != 3 {
+		t.Errorf("stats = %+v", s)
+	}
+	if ranks := decode[[]stats.ProviderRank](t, do(t, h, "GET", "/api/stats/compare", "", true)); len(ranks) != 1 {
+		t.Errorf("compare = %+v", ranks)
+	}
+}
+
+func TestListen(t *testing.T) {
+	s := New(&fakeService{}, testToken)
+	if err := s.Listen("127.0.0.1:0"); err != nil {
+		t.Fatalf("Listen 失败: %v", err)
+	}
+	defer s.Close()
+	resp, err := http.Get("http://" + s.Addr() + "/api/health")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "ok") {
+		t.Errorf("health = %s", body)
+	}
+}
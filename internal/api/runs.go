@@ -0,0 +1,157 @@
+package api
+
+import (
+	"pingai/internal/checker"
+	"sync"
+	"time"
+)
+
+// 运行状态
+const (
+	RunRunning = "running"
+	RunDone    = "done"
+)
+
+// maxRuns 内存中保留的运行记录数，超出后淘汰最早完成的
+const maxRuns = 100
+
+// Run 异步检测任务，Results 与请求顺序一致，未完成的位置为零值
+type Run struct {
+	ID         string                    `json:"id"`
+	Status     string                    `json:"status"`
+	Total      int                       `json:"total"`
+	Completed  int                       `json:"completed"`
+	Results    []checker.FullCheckResult `json:"results"`
+	Errors     map[int]string            `json:"errors,omitempty"` // 请求下标 -> 错误信息
+	CreatedAt  string                    `json:"createdAt"`
+	FinishedAt string                    `json:"finishedAt,omitempty"`
+}
+
+type runEntry struct {
+	run  Run
+	done chan struct{}
+}
+
+type runStore struct {
+	mu    sync.Mutex
+	runs  map[string]*runEntry
+	order []string
+}
+
+func newRunStore() *runStore {
+	return &runStore{runs: make(map[string]*runEntry)}
+}
+
+// get 返回运行记录的副本
+func (rs *runStore) get(id string) (Run, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	e, ok := rs.runs[id]
+	if !ok {
+		return Run{}, false
+	}
+	run := e.run
+	run.Results = append([]checker.FullCheckResult(nil), e.run.Results...)
+	if e.run.Errors != nil {
+		run.Errors = make(map[int]string, len(e.run.Errors))
+		for k, v := range e.run.Errors {
+			run.Errors[k] = v
+		}
+	}
+	return run, true
+}
+
+// done 返回运行结束时关闭的通道
+func (rs *runStore) done(id string) <-chan struct{} {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if e, ok := rs.runs[id]; ok {
+		return e.done
+	}
+	closed := make(chan struct{})
+	close(closed)
+	return closed
+}
+
+func (rs *runStore) add(run Run) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.runs[run.ID] = &runEntry{run: run, done: make(chan struct{})}
+	rs.order = append(rs.order, run.ID)
+
+	// 淘汰最早的已完成记录
+	for len(rs.order) > maxRuns {
+		evicted := false
+		for i, id := range rs.order {
+			if rs.runs[id].run.Status == RunDone {
+				delete(rs.runs, id)
+				rs.order = append(rs.order[:i], rs.order[i+1:]...)
+				evicted = true
+				break
+			}
+		}
+		if !evicted {
+			break
+		}
+	}
+}
+
+func (rs *runStore) complete(id string, idx int, result checker.FullCheckResult, err error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	e, ok := rs.runs[id]
+	if !ok {
+		return
+	}
+	e.run.Results[idx] = result
+	if err != nil {
+		if e.run.Errors == nil {
+			e.run.Errors = make(map[int]string)
+		}
+		e.run.Errors[idx] = err.Error()
+	}
+	e.run.Completed++
+	if e.run.Completed == e.run.Total {
+		e.run.Status = RunDone
+		e.run.FinishedAt = time.Now().Format(time.RFC3339)
+		close(e.done)
+	}
+}
+
+// startRun 在后台并行执行检测，立即返回运行记录；后台上下文取消时未开始的检测直接记为失败
+func (s *Server) startRun(items []CheckRequest) Run {
+	id, _ := NewToken()
+	id = id[:16]
+	run := Run{
+		ID:        id,
+		Status:    RunRunning,
+		Total:     len(items),
+		Results:   make([]checker.FullCheckResult, len(items)),
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	s.runs.add(run)
+	snapshot, _ := s.runs.get(id)
+
+	limit := s.svc.BatchConcurrency()
+	if limit <= 0 {
+		limit = checker.DefaultBatchConcurrency
+	}
+	sem := make(chan struct{}, limit)
+	ctx := s.bg
+	s.wg.Add(len(items))
+	for i, item := range items {
+		go func(idx int, req CheckRequest) {
+			defer s.wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				s.runs.complete(id, idx, checker.FullCheckResult{}, ctx.Err())
+				return
+			}
+			defer func() { <-sem }()
+			result, err := s.svc.Check(ctx, req)
+			s.runs.complete(id, idx, result, err)
+		}(i, item)
+	}
+	return snapshot
+}
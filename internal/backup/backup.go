@@ -0,0 +1,209 @@
+// Package backup 读写 PingAI 备份文件 (gzip 压缩的 JSON)
+package backup
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"pingai/internal/secretbox"
+	"pingai/internal/store"
+	"time"
+)
+
+// Format 备份文件标识
+const Format = "pingai-backup"
+
+// Version 当前写出的备份格式版本
+const Version = 1
+
+// Key 保护方式
+const (
+	KeysNone       = "none"       // 不含 Key
+	KeysPassphrase = "passphrase" // Key 使用口令加密
+)
+
+// 用于校验口令的明文
+const checkPlaintext = "pingai-backup"
+
+var (
+	ErrNotBackup          = errors.New("不是 PingAI 备份文件")
+	ErrPassphraseRequired = errors.New("备份中的 Key 已加密，请输入口令")
+	ErrWrongPassphrase    = errors.New("备份口令错误")
+	ErrTooLarge           = errors.New("备份解压后超过大小上限")
+)
+
+// maxSize 解压后的大小上限，防止恶意构造的压缩包耗尽内存
+var maxSize int64 = 1 << 30
+
+// ErrUnsupportedVersion 备份由更新版本的程序创建
+type ErrUnsupportedVersion struct {
+	Version int
+}
+
+func (e *ErrUnsupportedVersion) Error() string {
+	return fmt.Sprintf("备份格式版本 %d 高于当前程序支持的版本 %d，请升级 PingAI", e.Version, Version)
+}
+
+// Options 导出选项
+type Options struct {
+	IncludeKeys    bool   `json:"includeKeys"`
+	Passphrase     string `json:"passphrase"` // 包含 Key 时必填，用于加密 Key
+	IncludeHistory bool   `json:"includeHistory"`
+}
+
+// archive 备份文件结构
+type archive struct {
+	Format    string         `json:"format"`
+	Version   int            `json:"version"`
+	CreatedAt string         `json:"createdAt"`
+	Keys      string         `json:"keys"`            // KeysNone / KeysPassphrase
+	Salt      string         `json:"salt,omitempty"`  // base64
+	Check     string         `json:"check,omitempty"` // 加密后的 checkPlaintext
+	Data      store.Snapshot `json:"data"`
+}
+
+// upgrades 将旧版本备份升级到下一版本，键为原版本号
+// 新增版本时在此追加，保证旧备份始终可读
+var upgrades = map[int]func(doc map[string]any) error{}
+
+// Write 将快照写入备份，快照中的 Key 为明文
+func Write(w io.Writer, snap store.Snapshot, opts Options) error {
+	a := archive{
+		Format:    Format,
+		Version:   Version,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Keys:      KeysNone,
+		Data:      snap,
+	}
+
+	if opts.IncludeKeys {
+		if opts.Passphrase == "" {
+			return ErrPassphraseRequired
+		}
+		salt, err := secretbox.NewSalt()
+		if err != nil {
+			return err
+		}
+		key, err := secretbox.DeriveKey(opts.Passphrase, salt)
+		if err != nil {
+			return err
+		}
+		if a.Check, err = secretbox.Seal(key, checkPlaintext); err != nil {
+			return err
+		}
+		a.Keys = KeysPassphrase
+		a.Salt = base64.StdEncoding.EncodeToString(salt)
+		if err := transformKeys(&a.Data, func(s string) (string, error) { return secretbox.Seal(key, s) }); err != nil {
+			return err
+		}
+	} else {
+		stripKeys(&a.Data)
+	}
+	if !opts.IncludeHistory {
+		a.Data.History = nil
+	}
+
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(a); err != nil {
+		gz.Close()
+		return err
+	}
+	return gz.Close()
+}
+
+// Read 读取备份并返回明文快照，旧版本备份会先升级到当前格式
+func Read(r io.Reader, passphrase string) (store.Snapshot, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return store.Snapshot{}, ErrNotBackup
+	}
+	defer gz.Close()
+
+	limited := &io.LimitedReader{R: gz, N: maxSize + 1}
+	var doc map[string]any
+	if err := json.NewDecoder(limited).Decode(&doc); err != nil {
+		if limited.N <= 0 {
+			return store.Snapshot{}, ErrTooLarge
+		}
+		return store.Snapshot{}, ErrNotBackup
+	}
+	if doc["format"] != Format {
+		return store.Snapshot{}, ErrNotBackup
+	}
+	version, _ := doc["version"].(float64)
+	if int(version) > Version {
+		return store.Snapshot{}, &ErrUnsupportedVersion{Version: int(version)}
+	}
+	for v := int(version); v < Version; v++ {
+		up, ok := upgrades[v]
+		if !ok {
+			return store.Snapshot{}, fmt.Errorf("缺少备份版本 %d 的升级规则", v)
+		}
+		if err := up(doc); err != nil {
+			return store.Snapshot{}, fmt.Errorf("升级备份版本 %d 失败: %w", v, err)
+		}
+		doc["version"] = float64(v + 1)
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return store.Snapshot{}, err
+	}
+	var a archive
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return store.Snapshot{}, err
+	}
+
+	if a.Keys == KeysPassphrase {
+		if passphrase == "" {
+			return store.Snapshot{}, ErrPassphraseRequired
+		}
+		salt, err := base64.StdEncoding.DecodeString(a.Salt)
+		if err != nil {
+			return store.Snapshot{}, err
+		}
+		key, err := secretbox.DeriveKey(passphrase, salt)
+		if err != nil {
+			return store.Snapshot{}, err
+		}
+		if check, err := secretbox.Open(key, a.Check); err != nil || check != checkPlaintext {
+			return store.Snapshot{}, ErrWrongPassphrase
+		}
+		if err := transformKeys(&a.Data, func(s string) (string, error) { return secretbox.Open(key, s) }); err != nil {
+			return store.Snapshot{}, err
+		}
+	} else {
+		stripKeys(&a.Data)
+	}
+	return a.Data, nil
+}
+
+// transformKeys 对快照中全部非空 Key 执行 fn
+func transformKeys(s *store.Snapshot, fn func(string) (string, error)) error {
+	var err error
+	for i := range s.Configs {
+		if s.Configs[i].APIKey == "" {
+			continue
+		}
+		if s.Configs[i].APIKey, err = fn(s.Configs[i].APIKey); err != nil {
+			return err
+		}
+	}
+	for i := range s.Keys {
+		if s.Keys[i].APIKey, err = fn(s.Keys[i].APIKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stripKeys 移除快照中的全部 Key
+func stripKeys(s *store.Snapshot) {
+	for i := range s.Configs {
+		s.Configs[i].APIKey = ""
+	}
+	s.Keys = nil
+}
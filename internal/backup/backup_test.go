@@ -0,0 +1,115 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"pingai/internal/store"
+	"strings"
+	"testing"
+)
+
+func sampleSnapshot() store.Snapshot {
+	return store.Snapshot{
+		Providers:  []store.ProviderRow{{ID: "relay", Name: "Relay", BaseURL: "https://relay.example.com/v1", Protocol: "openai", Models: "[]"}},
+		Configs:    []store.ProviderConfigRow{{ProviderID: "relay", APIKey: "sk-relay", Model: "gpt-4o", Protocol: "openai"}},
+		Keys:       []store.ProviderKeyRow{{ProviderID: "relay", Label: "team", APIKey: "sk-relay", IsActive: 1}},
+		Visibility: []store.VisibilityRow{{ProviderID: "ollama", Visible: 0}},
+		History:    []store.HistoryRow{{ProviderID: "relay", Model: "gpt-4o", ResultsJSON: "[]", Status: "success"}},
+	}
+}
+
+func TestRoundTripEncryptedKeys(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, sampleSnapshot(), Options{IncludeKeys: true, Passphrase: "secret", IncludeHistory: true}); err != nil {
+		t.Fatalf("Write 失败: %v", err)
+	}
+
+	// 文件中不应出现明文 Key
+	gz, _ := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+	var plain bytes.Buffer
+	plain.ReadFrom(gz)
+	if strings.Contains(plain.String(), "sk-relay") {
+		t.Error("备份中出现明文 Key")
+	}
+
+	if _, err := Read(bytes.NewReader(buf.Bytes()), ""); err != ErrPassphraseRequired {
+		t.Errorf("缺少口令应返回 ErrPassphraseRequired, 得到 %v", err)
+	}
+	if _, err := Read(bytes.NewReader(buf.Bytes()), "wrong"); err != ErrWrongPassphrase {
+		t.Errorf("错误口令应返回 ErrWrongPassphrase, 得到 %v", err)
+	}
+	snap, err := Read(bytes.NewReader(buf.Bytes()), "secret")
+	if err != nil {
+		t.Fatalf("Read 失败: %v", err)
+	}
+	if snap.Configs[0].APIKey != "sk-relay" || snap.Keys[0].APIKey != "sk-relay" {
+		t.Errorf("解密后 Key = %q / %q", snap.Configs[0].APIKey, snap.Keys[0].APIKey)
+	}
+	if len(snap.History) != 1 || len(snap.Providers) != 1 || len(snap.Visibility) != 1 {
+		t.Errorf("快照内容 = %+v", snap)
+	}
+}
+
+func TestWriteWithoutKeysOrHistory(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, sampleSnapshot(), Options{}); err != nil {
+		t.Fatalf("Write 失败: %v", err)
+	}
+	snap, err := Read(&buf, "")
+	if err != nil {
+		t.Fatalf("Read 失败: %v", err)
+	}
+	if snap.Configs[0].APIKey != "" || snap.Keys != nil || snap.History != nil {
+		t.Errorf("不应包含 Key 和历史: %+v", snap)
+	}
+	if err := Write(&buf, sampleSnapshot(), Options{IncludeKeys: true}); err != ErrPassphraseRequired {
+		t.Errorf("包含 Key 但无口令应返回 ErrPassphraseRequired, 得到 %v", err)
+	}
+}
+
+func TestReadVersions(t *testing.T) {
+	gzipped := func(s string) *bytes.Buffer {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write([]byte(s))
+		gz.Close()
+		return &buf
+	}
+
+	if _, err := Read(strings.NewReader("plain text"), ""); err != ErrNotBackup {
+		t.Errorf("非备份文件应返回 ErrNotBackup, 得到 %v", err)
+	}
+	if _, err := Read(gzipped(`{"format":"other"}`), ""); err != ErrNotBackup {
+		t.Errorf("其他格式应返回 ErrNotBackup, 得到 %v", err)
+	}
+	savedMax := maxSize
+	maxSize = 64
+	_, err := Read(gzipped(`{"format":"pingai-backup","version":1,"data":{"history":[`+strings.Repeat(`{},`, 100)+`{}]}}`), "")
+	maxSize = savedMax
+	if err != ErrTooLarge {
+		t.Errorf("超过大小上限应返回 ErrTooLarge, 得到 %v", err)
+	}
+
+	var tooNew *ErrUnsupportedVersion
+	if _, err := Read(gzipped(`{"format":"pingai-backup","version":99}`), ""); !errors.As(err, &tooNew) || tooNew.Version != 99 {
+		t.Errorf("新版本备份应返回 ErrUnsupportedVersion, 得到 %v", err)
+	}
+
+	// 旧版本经升级规则转换后读取
+	saved := upgrades
+	defer func() { upgrades = saved }()
+	upgrades = map[int]func(map[string]any) error{
+		0: func(doc map[string]any) error {
+			doc["data"] = map[string]any{"providers": doc["customProviders"]}
+			return nil
+		},
+	}
+	snap, err := Read(gzipped(`{"format":"pingai-backup","version":0,"customProviders":[{"id":"old"}]}`), "")
+	if err != nil {
+		t.Fatalf("读取旧版本失败: %v", err)
+	}
+	if len(snap.Providers) != 1 || snap.Providers[0].ID != "old" {
+		t.Errorf("升级后供应商 = %+v", snap.Providers)
+	}
+}
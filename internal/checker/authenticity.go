@@ -0,0 +1,78 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"pingai/internal/fingerprint"
+	"pingai/internal/protocol"
+	"time"
+)
+
+// ProfileLookup 按模型名查找参考档案，没有时返回 nil
+type ProfileLookup func(model string) *fingerprint.Profile
+
+// SetAuthenticity 开启模型真实性检测，lookup 为 nil 时关闭
+func (c *Checker) SetAuthenticity(lookup ProfileLookup) {
+	c.mu.Lock()
+	c.profiles = lookup
+	c.mu.Unlock()
+}
+
+func (c *Checker) authenticity() ProfileLookup {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.profiles
+}
+
+// RunAuthenticity 运行探针组并与参考档案 (可为 nil) 比较，返回检测项和完整结论
+func (c *Checker) RunAuthenticity(ctx context.Context, baseURL, apiKey, model, proto string, profile *fingerprint.Profile) (CheckResult, fingerprint.Result) {
+	adapter := protocol.GetAdapter(protocol.Protocol(proto))
+	return c.checkAuthenticity(ctx, adapter, baseURL, apiKey, model, profile)
+}
+
+// checkAuthenticity 模型真实性检测
+func (c *Checker) checkAuthenticity(parent context.Context, adapter protocol.Adapter, baseURL, apiKey, model string, profile *fingerprint.Profile) (CheckResult, fingerprint.Result) {
+	start := time.Now()
+	r := CheckResult{Item: CheckAuthenticity}
+
+	ctx, cancel := context.WithTimeout(parent, 60*time.Second)
+	defer cancel()
+
+	obs := fingerprint.Run(ctx, adapter, protocol.ChatRequest{BaseURL: baseURL, APIKey: apiKey, Model: model}, fingerprint.DefaultProbes)
+	r.Latency = time.Since(start).Milliseconds()
+	failed := 0
+	for _, o := range obs {
+		r.TokenIn += o.PromptTokens
+		r.TokenOut += o.CompTokens
+		if o.Error != "" {
+			failed++
+		}
+	}
+	result := fingerprint.Evaluate(model, obs, profile)
+
+	if failed == len(obs) {
+		r.Status = StatusFailed
+		r.Message = "探针请求全部失败"
+		r.Detail = obs[0].Error
+		r.ErrorKind = KindUnknown
+		return r, result
+	}
+
+	percent := int(result.Confidence*100 + 0.5)
+	switch result.Verdict {
+	case fingerprint.VerdictGenuine:
+		r.Status = StatusSuccess
+		r.Message = fmt.Sprintf("模型可信 (%d%%)", percent)
+	case fingerprint.VerdictSuspicious:
+		r.Status = StatusWarning
+		r.Message = fmt.Sprintf("模型存疑 (%d%%)", percent)
+	case fingerprint.VerdictSubstituted:
+		r.Status = StatusFailed
+		r.Message = fmt.Sprintf("模型疑似被替换 (%d%%)", percent)
+	default:
+		r.Status = StatusWarning
+		r.Message = "证据不足, 无法判断"
+	}
+	r.Detail = result.Summary()
+	return r, result
+}
@@ -0,0 +1,45 @@
+package checker
+
+import (
+	"pingai/internal/fingerprint"
+	"pingai/internal/mockprovider"
+	"testing"
+)
+
+func TestCheckAuthenticity(t *testing.T) {
+	srv := mockprovider.NewServer(mockprovider.Behavior{})
+	defer srv.Close()
+	c := NewChecker()
+	run := func() FullCheckResult {
+		return c.RunFullCheck(srv.BaseURL(mockprovider.OpenAI), "k", "gpt-4o", "mock", "Mock", "openai")
+	}
+
+	if _, ok := itemsOf(run())[CheckAuthenticity]; ok {
+		t.Fatal("未开启时不应执行真实性检测")
+	}
+
+	var profile *fingerprint.Profile
+	c.SetAuthenticity(func(model string) *fingerprint.Profile { return profile })
+	item, result := c.RunAuthenticity(t.Context(), srv.BaseURL(mockprovider.OpenAI), "k", "gpt-4o", "openai", nil)
+	if item.Status != StatusSuccess || result.Verdict != fingerprint.VerdictGenuine {
+		t.Fatalf("真实渠道 = %+v", item)
+	}
+	captured := fingerprint.Capture("gpt-4o", "mock", result.Observations)
+	profile = &captured
+
+	r := run()
+	if got := itemsOf(r)[CheckAuthenticity]; got.Status != StatusSuccess || got.TokenIn == 0 {
+		t.Errorf("与档案一致时 = %+v", got)
+	}
+
+	// 回显其他模型：真实性失败，但不影响 Key 判定
+	srv.Set(mockprovider.Behavior{EchoModel: "gpt-3.5-turbo", PromptTokens: 3})
+	r = run()
+	got := itemsOf(r)[CheckAuthenticity]
+	if got.Status == StatusSuccess || got.Detail == "" {
+		t.Errorf("模型被替换时 = %+v", got)
+	}
+	if v := ClassifyKey(r); v != VerdictValid {
+		t.Errorf("真实性结果不应影响 Key 判定, 得到 %s", v)
+	}
+}
@@ -0,0 +1,47 @@
+package checker
+
+import "pingai/internal/protocol"
+
+// ModelInfoLookup 查找模型元数据，未知时 ok 为 false
+type ModelInfoLookup func(providerID, model string) (info protocol.ModelInfo, ok bool)
+
+// SetModelInfo 设置模型元数据来源，检测时跳过模型不支持的能力；nil 时不跳过
+func (c *Checker) SetModelInfo(lookup ModelInfoLookup) {
+	c.mu.Lock()
+	c.modelInfo = lookup
+	c.mu.Unlock()
+}
+
+// unsupported 返回因模型不支持而应跳过的检测项及原因，自定义用例使用 CheckChat 的结论
+func (c *Checker) unsupported(providerID, model string) map[CheckItem]string {
+	c.mu.RLock()
+	lookup := c.modelInfo
+	c.mu.RUnlock()
+	if lookup == nil {
+		return nil
+	}
+	info, ok := lookup(providerID, model)
+	if !ok {
+		return nil
+	}
+
+	skip := make(map[CheckItem]string)
+	if !info.OutputsText() {
+		reason := "模型不支持文本输出"
+		for _, item := range []CheckItem{CheckChat, CheckStream, CheckMultiTurn, CheckAuthenticity} {
+			skip[item] = reason
+		}
+		for _, cc := range c.customCases() {
+			skip[CustomItem(cc.Name)] = reason
+		}
+	}
+	if info.Streaming != nil && !*info.Streaming {
+		skip[CheckStream] = "模型不支持流式输出"
+	}
+	return skip
+}
+
+// skipped 跳过的检测项
+func skipped(item CheckItem, reason string) CheckResult {
+	return CheckResult{Item: item, Status: StatusSkipped, Message: "已跳过: " + reason}
+}
@@ -0,0 +1,54 @@
+package checker
+
+import (
+	"context"
+	"pingai/internal/mockprovider"
+	"pingai/internal/protocol"
+	"testing"
+)
+
+func TestSkipUnsupported(t *testing.T) {
+	srv := mockprovider.NewServer(mockprovider.Behavior{})
+	defer srv.Close()
+	c := NewChecker()
+	c.SetCustomCases([]CustomCase{{Name: "hello", Messages: []protocol.Message{{Role: "user", Content: "hi"}}}})
+	no := false
+	c.SetModelInfo(func(providerID, model string) (protocol.ModelInfo, bool) {
+		switch model {
+		case "embed":
+			return protocol.ModelInfo{ID: model, OutputModalities: []string{"embedding"}}, true
+		case "no-stream":
+			return protocol.ModelInfo{ID: model, Streaming: &no}, true
+		}
+		return protocol.ModelInfo{}, false
+	})
+	run := func(model string) map[CheckItem]CheckResult {
+		return itemsOf(c.RunFullCheck(srv.BaseURL(mockprovider.OpenAI), "k", model, "mock", "Mock", "openai"))
+	}
+
+	items := run("embed")
+	for _, item := range []CheckItem{CheckChat, CheckStream, CheckMultiTurn, CustomItem("hello")} {
+		if items[item].Status != StatusSkipped {
+			t.Errorf("embedding 模型 %s = %+v, 期望跳过", item, items[item])
+		}
+	}
+	if items[CheckModels].Status != StatusSuccess {
+		t.Errorf("模型列表不应跳过: %+v", items[CheckModels])
+	}
+	if v := ClassifyKey(c.RunFullCheck(srv.BaseURL(mockprovider.OpenAI), "k", "embed", "mock", "Mock", "openai")); v != VerdictValid {
+		t.Errorf("跳过的检测项不应影响 Key 判定, 得到 %s", v)
+	}
+
+	items = run("no-stream")
+	if items[CheckStream].Status != StatusSkipped || items[CheckChat].Status != StatusSuccess {
+		t.Errorf("不支持流式 = stream %+v, chat %+v", items[CheckStream], items[CheckChat])
+	}
+	if items := run("unknown"); items[CheckStream].Status != StatusSuccess {
+		t.Errorf("元数据未知时不应跳过: %+v", items[CheckStream])
+	}
+
+	r, err := c.SweepModels(context.Background(), srv.BaseURL(mockprovider.OpenAI), "k", "mock", "Mock", "openai", SweepOptions{Models: []string{"embed", "no-stream"}, Stream: true})
+	if err != nil || r.Rows[0].Status != StatusSkipped || r.Rows[1].Status != StatusSuccess || r.Rows[1].Stream.Status != StatusSkipped || r.Summary.Skipped != 1 {
+		t.Errorf("扫描 = %+v, %v", r, err)
+	}
+}
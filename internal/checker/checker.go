@@ -1,9 +1,10 @@
 package checker
 
 import (
-	"pingai/internal/protocol"
 	"context"
 	"fmt"
+	"pingai/internal/keys"
+	"pingai/internal/protocol"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +19,7 @@ const (
 	CheckStream       CheckItem = "stream"
 	CheckModels       CheckItem = "models"
 	CheckMultiTurn    CheckItem = "multi_turn"
+	CheckAuthenticity CheckItem = "authenticity" // 模型真实性，开启后执行
 )
 
 // CheckStatus 检测状态
@@ -29,18 +31,23 @@ const (
 	StatusSuccess CheckStatus = "success"
 	StatusFailed  CheckStatus = "failed"
 	StatusWarning CheckStatus = "warning"
+	StatusSkipped CheckStatus = "skipped" // 模型元数据表明不支持该能力
 )
 
 // CheckResult 单项检测结果
 type CheckResult struct {
-	Item     CheckItem   `json:"item"`
-	Status   CheckStatus `json:"status"`
-	Latency  int64       `json:"latency"`
-	TTFT     int64       `json:"ttft"`
-	Message  string      `json:"message"`
-	Detail   string      `json:"detail"`
-	TokenIn  int         `json:"tokenIn"`
-	TokenOut int         `json:"tokenOut"`
+	Item      CheckItem   `json:"item"`
+	Status    CheckStatus `json:"status"`
+	Latency   int64       `json:"latency"`
+	TTFT      int64       `json:"ttft"`
+	Message   string      `json:"message"`
+	Detail    string      `json:"detail"`
+	TokenIn   int         `json:"tokenIn"`
+	TokenOut  int         `json:"tokenOut"`
+	ErrorKind ErrorKind   `json:"errorKind,omitempty"`
+	Cost      float64     `json:"cost,omitempty"` // 按模型单价估算的费用，币种见 FullCheckResult.Currency
+
+	Assertions []AssertionResult `json:"assertions,omitempty"` // 自定义用例的断言结果
 }
 
 // FullCheckResult 完整检测结果
@@ -55,20 +62,55 @@ type FullCheckResult struct {
 	StartTime    string        `json:"startTime"`
 	EndTime      string        `json:"endTime"`
 	TotalLatency int64         `json:"totalLatency"`
+	MaskedKey    string        `json:"maskedKey,omitempty"`
+	Cost         float64       `json:"cost,omitempty"`     // 各检测项费用之和
+	Currency     string        `json:"currency,omitempty"` // 为空表示模型价格未知
 }
 
+// Observer 检测项完成时的回调，可能被并发调用
+type Observer func(providerID, model string, r CheckResult)
+
 // Checker 检测引擎
-type Checker struct{}
+type Checker struct {
+	mu        sync.RWMutex
+	observers []Observer
+	cases     []CustomCase
+	profiles  ProfileLookup // 非 nil 时执行模型真实性检测
+	modelInfo ModelInfoLookup
+}
 
 // NewChecker 创建检测引擎
 func NewChecker() *Checker {
 	return &Checker{}
 }
 
+// AddObserver 注册检测项结果回调
+func (c *Checker) AddObserver(o Observer) {
+	c.mu.Lock()
+	c.observers = append(c.observers, o)
+	c.mu.Unlock()
+}
+
+// observe 通知所有回调，返回原结果便于链式调用
+func (c *Checker) observe(providerID, model string, r CheckResult) CheckResult {
+	c.mu.RLock()
+	observers := c.observers
+	c.mu.RUnlock()
+	for _, o := range observers {
+		o(providerID, model, r)
+	}
+	return r
+}
+
 const timeFmt = "2006-01-02 15:04:05"
 
 // RunFullCheck 执行全量检测
 func (c *Checker) RunFullCheck(baseURL, apiKey, model, providerID, providerName, proto string) FullCheckResult {
+	return c.RunFullCheckContext(context.Background(), baseURL, apiKey, model, providerID, providerName, proto)
+}
+
+// RunFullCheckContext 同 RunFullCheck，各项检测的超时从 ctx 派生 (可通过 protocol.WithTransport 录制或回放)
+func (c *Checker) RunFullCheckContext(ctx context.Context, baseURL, apiKey, model, providerID, providerName, proto string) FullCheckResult {
 	startTime := time.Now()
 	adapter := protocol.GetAdapter(protocol.Protocol(proto))
 
@@ -79,10 +121,11 @@ func (c *Checker) RunFullCheck(baseURL, apiKey, model, providerID, providerName,
 		Model:        model,
 		Protocol:     proto,
 		StartTime:    startTime.Format(timeFmt),
+		MaskedKey:    keys.Mask(apiKey),
 	}
 
 	// 连通性检测
-	connResult := c.checkConnectivity(adapter, baseURL, apiKey)
+	connResult := c.observe(providerID, model, c.checkConnectivity(ctx, adapter, baseURL, apiKey))
 	result.Results = append(result.Results, connResult)
 	if connResult.Status == StatusFailed {
 		result.EndTime = time.Now().Format(timeFmt)
@@ -90,19 +133,64 @@ func (c *Checker) RunFullCheck(baseURL, apiKey, model, providerID, providerName,
 		return result
 	}
 
-	// 并行执行其余检测
+	// 并行执行其余检测，模型元数据表明不支持的能力直接跳过
 	var wg sync.WaitGroup
 	var chatResult, streamResult, modelResult, multiTurnResult CheckResult
 	var modelList []string
+	skip := c.unsupported(providerID, model)
+	run := func(item CheckItem, dst *CheckResult, fn func() CheckResult) {
+		if reason, ok := skip[item]; ok {
+			*dst = skipped(item, reason)
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			*dst = c.observe(providerID, model, fn())
+		}()
+	}
+
+	run(CheckChat, &chatResult, func() CheckResult {
+		return c.checkChat(ctx, adapter, baseURL, apiKey, model)
+	})
+	run(CheckStream, &streamResult, func() CheckResult {
+		return c.checkStream(ctx, adapter, baseURL, apiKey, model)
+	})
+	run(CheckModels, &modelResult, func() CheckResult {
+		var r CheckResult
+		r, modelList = c.checkModels(ctx, adapter, baseURL, apiKey)
+		return r
+	})
+	run(CheckMultiTurn, &multiTurnResult, func() CheckResult {
+		return c.checkMultiTurn(ctx, adapter, baseURL, apiKey, model)
+	})
+	var authResult *CheckResult
+	if lookup := c.authenticity(); lookup != nil {
+		authResult = &CheckResult{}
+		run(CheckAuthenticity, authResult, func() CheckResult {
+			r, _ := c.checkAuthenticity(ctx, adapter, baseURL, apiKey, model, lookup(model))
+			return r
+		})
+	}
 
-	wg.Add(4)
-	go func() { defer wg.Done(); chatResult = c.checkChat(adapter, baseURL, apiKey, model) }()
-	go func() { defer wg.Done(); streamResult = c.checkStream(adapter, baseURL, apiKey, model) }()
-	go func() { defer wg.Done(); modelResult, modelList = c.checkModels(adapter, baseURL, apiKey) }()
-	go func() { defer wg.Done(); multiTurnResult = c.checkMultiTurn(adapter, baseURL, apiKey, model) }()
+	// 自定义用例与内置检测并行执行
+	cases := c.customCases()
+	caseResults := make([]CheckResult, len(cases))
+	for i, cc := range cases {
+		run(CustomItem(cc.Name), &caseResults[i], func() CheckResult {
+			return c.checkCustom(ctx, adapter, baseURL, apiKey, model, cc)
+		})
+	}
 	wg.Wait()
 
 	result.Results = append(result.Results, chatResult, streamResult, modelResult, multiTurnResult)
+	if authResult != nil {
+		result.Results = append(result.Results, *authResult)
+	}
+	result.Results = append(result.Results, caseResults...)
+	if info, ok := c.pricing(providerID, model); ok {
+		ApplyCost(&result, info)
+	}
 	result.ModelList = modelList
 	result.EndTime = time.Now().Format(timeFmt)
 	result.TotalLatency = time.Since(startTime).Milliseconds()
@@ -110,11 +198,11 @@ func (c *Checker) RunFullCheck(baseURL, apiKey, model, providerID, providerName,
 }
 
 // checkConnectivity 连通性检测
-func (c *Checker) checkConnectivity(adapter protocol.Adapter, baseURL, apiKey string) CheckResult {
+func (c *Checker) checkConnectivity(parent context.Context, adapter protocol.Adapter, baseURL, apiKey string) CheckResult {
 	start := time.Now()
 	r := CheckResult{Item: CheckConnectivity}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(parent, 15*time.Second)
 	defer cancel()
 
 	code, err := adapter.CheckConnectivity(ctx, baseURL, apiKey)
@@ -124,12 +212,14 @@ func (c *Checker) checkConnectivity(adapter protocol.Adapter, baseURL, apiKey st
 		r.Status = StatusFailed
 		r.Message = "网络不可达"
 		r.Detail = err.Error()
+		r.ErrorKind = ClassifyError(err)
 		return r
 	}
 	if code == 401 || code == 403 {
 		r.Status = StatusWarning
 		r.Message = fmt.Sprintf("网络可达, 认证失败 (HTTP %d)", code)
 		r.Detail = "请检查 API Key"
+		r.ErrorKind = ClassifyResponse(code, "")
 		return r
 	}
 	if code >= 200 && code < 500 {
@@ -139,15 +229,16 @@ func (c *Checker) checkConnectivity(adapter protocol.Adapter, baseURL, apiKey st
 	}
 	r.Status = StatusFailed
 	r.Message = fmt.Sprintf("服务异常 (HTTP %d)", code)
+	r.ErrorKind = ClassifyResponse(code, "")
 	return r
 }
 
 // checkChat 对话测试
-func (c *Checker) checkChat(adapter protocol.Adapter, baseURL, apiKey, model string) CheckResult {
+func (c *Checker) checkChat(parent context.Context, adapter protocol.Adapter, baseURL, apiKey, model string) CheckResult {
 	start := time.Now()
 	r := CheckResult{Item: CheckChat}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(parent, 30*time.Second)
 	defer cancel()
 
 	resp, err := adapter.Chat(ctx, protocol.ChatRequest{
@@ -162,12 +253,14 @@ func (c *Checker) checkChat(adapter protocol.Adapter, baseURL, apiKey, model str
 		r.Status = StatusFailed
 		r.Message = "请求失败"
 		r.Detail = err.Error()
+		r.ErrorKind = ClassifyError(err)
 		return r
 	}
 	if resp.Error != "" {
 		r.Status = StatusFailed
 		r.Message = resp.Error
 		r.Detail = resp.RawBody
+		r.ErrorKind = classifyChatResponse(resp)
 		return r
 	}
 
@@ -180,11 +273,11 @@ func (c *Checker) checkChat(adapter protocol.Adapter, baseURL, apiKey, model str
 }
 
 // checkStream 流式输出测试
-func (c *Checker) checkStream(adapter protocol.Adapter, baseURL, apiKey, model string) CheckResult {
+func (c *Checker) checkStream(parent context.Context, adapter protocol.Adapter, baseURL, apiKey, model string) CheckResult {
 	start := time.Now()
 	r := CheckResult{Item: CheckStream}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(parent, 30*time.Second)
 	defer cancel()
 
 	chunkCount := 0
@@ -205,18 +298,24 @@ func (c *Checker) checkStream(adapter protocol.Adapter, baseURL, apiKey, model s
 	if err != nil {
 		r.Status = StatusFailed
 		r.Message = "请求失败"
+		if chunkCount > 0 {
+			r.Message = fmt.Sprintf("流式中断, 已收到 %d chunks", chunkCount)
+		}
 		r.Detail = err.Error()
+		r.ErrorKind = ClassifyError(err)
 		return r
 	}
 	if resp.Error != "" {
 		r.Status = StatusFailed
 		r.Message = resp.Error
 		r.Detail = resp.RawBody
+		r.ErrorKind = classifyChatResponse(resp)
 		return r
 	}
 	if chunkCount == 0 {
 		r.Status = StatusFailed
 		r.Message = "未收到流式数据"
+		r.ErrorKind = KindParseError
 		return r
 	}
 
@@ -227,11 +326,11 @@ func (c *Checker) checkStream(adapter protocol.Adapter, baseURL, apiKey, model s
 }
 
 // checkModels 模型列表获取
-func (c *Checker) checkModels(adapter protocol.Adapter, baseURL, apiKey string) (CheckResult, []string) {
+func (c *Checker) checkModels(parent context.Context, adapter protocol.Adapter, baseURL, apiKey string) (CheckResult, []string) {
 	start := time.Now()
 	r := CheckResult{Item: CheckModels}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(parent, 15*time.Second)
 	defer cancel()
 
 	models, err := adapter.ListModels(ctx, baseURL, apiKey)
@@ -241,6 +340,7 @@ func (c *Checker) checkModels(adapter protocol.Adapter, baseURL, apiKey string)
 		r.Status = StatusWarning
 		r.Message = "模型列表获取失败"
 		r.Detail = err.Error()
+		r.ErrorKind = ClassifyError(err)
 		return r, nil
 	}
 
@@ -255,11 +355,11 @@ func (c *Checker) checkModels(adapter protocol.Adapter, baseURL, apiKey string)
 }
 
 // checkMultiTurn 多轮对话测试
-func (c *Checker) checkMultiTurn(adapter protocol.Adapter, baseURL, apiKey, model string) CheckResult {
+func (c *Checker) checkMultiTurn(parent context.Context, adapter protocol.Adapter, baseURL, apiKey, model string) CheckResult {
 	start := time.Now()
 	r := CheckResult{Item: CheckMultiTurn}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(parent, 60*time.Second)
 	defer cancel()
 
 	// 第一轮
@@ -274,8 +374,10 @@ func (c *Checker) checkMultiTurn(adapter protocol.Adapter, baseURL, apiKey, mode
 		r.Message = "第一轮对话失败"
 		if err != nil {
 			r.Detail = err.Error()
+			r.ErrorKind = ClassifyError(err)
 		} else {
 			r.Detail = resp1.Error
+			r.ErrorKind = classifyChatResponse(resp1)
 		}
 		r.Latency = time.Since(start).Milliseconds()
 		return r
@@ -299,8 +401,10 @@ func (c *Checker) checkMultiTurn(adapter protocol.Adapter, baseURL, apiKey, mode
 		r.Message = "第二轮对话失败"
 		if err != nil {
 			r.Detail = err.Error()
+			r.ErrorKind = ClassifyError(err)
 		} else {
 			r.Detail = resp2.Error
+			r.ErrorKind = classifyChatResponse(resp2)
 		}
 		return r
 	}
@@ -0,0 +1,116 @@
+package checker
+
+import (
+	"net/http"
+	"pingai/internal/mockprovider"
+	"testing"
+	"time"
+)
+
+// itemsOf 按检测项索引结果
+func itemsOf(r FullCheckResult) map[CheckItem]CheckResult {
+	m := make(map[CheckItem]CheckResult, len(r.Results))
+	for _, item := range r.Results {
+		m[item.Item] = item
+	}
+	return m
+}
+
+func TestRunFullCheckHealthy(t *testing.T) {
+	srv := mockprovider.NewServer(mockprovider.Behavior{APIKey: "sk-mock", Models: []string{"m-1", "m-2"}})
+	defer srv.Close()
+
+	c := NewChecker()
+	c.AddObserver(func(providerID, model string, r CheckResult) {
+		if providerID != "mock" || model != "m-1" {
+			t.Errorf("observer 参数 = %s / %s", providerID, model)
+		}
+	})
+
+	for _, proto := range []string{mockprovider.OpenAI, mockprovider.Anthropic, mockprovider.Gemini} {
+		r := c.RunFullCheck(srv.BaseURL(proto), "sk-mock", "m-1", "mock", "Mock", proto)
+		items := itemsOf(r)
+		if len(r.Results) != 5 {
+			t.Fatalf("%s: 检测项数量 = %d", proto, len(r.Results))
+		}
+		for _, item := range r.Results {
+			if item.Status != StatusSuccess {
+				t.Errorf("%s: %s = %s (%s %s)", proto, item.Item, item.Status, item.Message, item.Detail)
+			}
+		}
+		if len(r.ModelList) != 2 {
+			t.Errorf("%s: ModelList = %v", proto, r.ModelList)
+		}
+		if items[CheckChat].TokenIn == 0 || items[CheckMultiTurn].TokenOut == 0 {
+			t.Errorf("%s: 未记录 token 用量", proto)
+		}
+		if ClassifyKey(r) != VerdictValid {
+			t.Errorf("%s: ClassifyKey = %s", proto, ClassifyKey(r))
+		}
+	}
+}
+
+func TestRunFullCheckFailures(t *testing.T) {
+	srv := mockprovider.NewServer(mockprovider.Behavior{})
+	defer srv.Close()
+	c := NewChecker()
+	run := func(b mockprovider.Behavior) map[CheckItem]CheckResult {
+		srv.Set(b)
+		r := c.RunFullCheck(srv.BaseURL(mockprovider.OpenAI), "sk-mock", "m-1", "mock", "Mock", "openai")
+		return itemsOf(r)
+	}
+
+	// 401: 连通性告警后继续检测，对话失败
+	items := run(mockprovider.Behavior{APIKey: "other"})
+	if items[CheckConnectivity].Status != StatusWarning || items[CheckChat].ErrorKind != KindAuthInvalid {
+		t.Errorf("401: %+v / %+v", items[CheckConnectivity], items[CheckChat])
+	}
+
+	// 500: 连通性失败后不再检测
+	items = run(mockprovider.Behavior{Status: http.StatusInternalServerError})
+	if len(items) != 1 || items[CheckConnectivity].ErrorKind != KindUpstream5xx {
+		t.Errorf("500: %+v", items)
+	}
+
+	// 仅对话接口限流
+	items = run(mockprovider.Behavior{StatusFor: map[mockprovider.Endpoint]int{mockprovider.EndpointChat: http.StatusTooManyRequests}})
+	if items[CheckChat].ErrorKind != KindRateLimited || items[CheckStream].Status != StatusSuccess {
+		t.Errorf("429: %+v / %+v", items[CheckChat], items[CheckStream])
+	}
+
+	// 损坏 JSON
+	items = run(mockprovider.Behavior{Malformed: true})
+	if items[CheckChat].ErrorKind != KindParseError || items[CheckModels].Status != StatusWarning ||
+		items[CheckStream].ErrorKind != KindParseError {
+		t.Errorf("损坏 JSON: %+v / %+v / %+v", items[CheckChat], items[CheckModels], items[CheckStream])
+	}
+
+	// 流式中途断开
+	items = run(mockprovider.Behavior{DisconnectAfter: 1})
+	if items[CheckStream].Status != StatusFailed || items[CheckChat].Status != StatusSuccess {
+		t.Errorf("中途断开: %+v", items[CheckStream])
+	}
+
+	// 模型列表不含请求的模型，且服务端拒绝未知模型
+	items = run(mockprovider.Behavior{Models: []string{"other"}, StrictModels: true})
+	if items[CheckModels].Status != StatusSuccess || items[CheckChat].ErrorKind != KindModelNotFound {
+		t.Errorf("错误模型列表: %+v / %+v", items[CheckModels], items[CheckChat])
+	}
+
+	// 固定回复导致上下文丢失
+	items = run(mockprovider.Behavior{Reply: "OK"})
+	if items[CheckMultiTurn].Status != StatusWarning {
+		t.Errorf("上下文丢失: %+v", items[CheckMultiTurn])
+	}
+}
+
+func TestRunFullCheckSlowTTFT(t *testing.T) {
+	srv := mockprovider.NewServer(mockprovider.Behavior{TTFT: 150 * time.Millisecond, ChunkDelay: 10 * time.Millisecond})
+	defer srv.Close()
+
+	r := NewChecker().RunFullCheck(srv.BaseURL(mockprovider.Anthropic), "k", "m", "mock", "Mock", "anthropic")
+	stream := itemsOf(r)[CheckStream]
+	if stream.Status != StatusSuccess || stream.TTFT < 150 || stream.Latency <= stream.TTFT {
+		t.Errorf("stream = %+v", stream)
+	}
+}
@@ -0,0 +1,33 @@
+package checker
+
+import "pingai/internal/protocol"
+
+// pricing 返回模型单价，价格未知时 ok 为 false
+func (c *Checker) pricing(providerID, model string) (protocol.ModelInfo, bool) {
+	c.mu.RLock()
+	lookup := c.modelInfo
+	c.mu.RUnlock()
+	if lookup == nil {
+		return protocol.ModelInfo{}, false
+	}
+	info, ok := lookup(providerID, model)
+	if !ok || info.Currency == "" {
+		return protocol.ModelInfo{}, false
+	}
+	return info, true
+}
+
+// ItemCost 按每百万 token 单价估算单项费用
+func ItemCost(r CheckResult, price protocol.ModelInfo) float64 {
+	return (float64(r.TokenIn)*price.InputPrice + float64(r.TokenOut)*price.OutputPrice) / 1e6
+}
+
+// ApplyCost 计算各检测项及整次检测的费用
+func ApplyCost(r *FullCheckResult, price protocol.ModelInfo) {
+	r.Cost = 0
+	r.Currency = price.Currency
+	for i := range r.Results {
+		r.Results[i].Cost = ItemCost(r.Results[i], price)
+		r.Cost += r.Results[i].Cost
+	}
+}
@@ -0,0 +1,59 @@
+package checker
+
+import (
+	"context"
+	"pingai/internal/mockprovider"
+	"pingai/internal/protocol"
+	"testing"
+)
+
+func TestCost(t *testing.T) {
+	srv := mockprovider.NewServer(mockprovider.Behavior{})
+	defer srv.Close()
+	c := NewChecker()
+	c.SetModelInfo(func(providerID, model string) (protocol.ModelInfo, bool) {
+		if model == "priced" {
+			return protocol.ModelInfo{ID: model, InputPrice: 2, OutputPrice: 8, Currency: "USD"}, true
+		}
+		return protocol.ModelInfo{ID: model}, true
+	})
+
+	r := c.RunFullCheck(srv.BaseURL(mockprovider.OpenAI), "sk-mock-1234567890", "priced", "mock", "Mock", "openai")
+	chat := itemsOf(r)[CheckChat]
+	if chat.TokenIn == 0 || chat.Cost != ItemCost(chat, protocol.ModelInfo{InputPrice: 2, OutputPrice: 8}) || chat.Cost == 0 {
+		t.Errorf("chat 费用 = %+v", chat)
+	}
+	var sum float64
+	for _, item := range r.Results {
+		sum += item.Cost
+	}
+	if r.Currency != "USD" || r.Cost != sum || r.Cost == 0 {
+		t.Errorf("整体费用 = %v %s, 各项之和 %v", r.Cost, r.Currency, sum)
+	}
+	if r.MaskedKey == "" || r.MaskedKey == "sk-mock-1234567890" {
+		t.Errorf("MaskedKey = %q", r.MaskedKey)
+	}
+
+	// 价格未知时不估算费用
+	r = c.RunFullCheck(srv.BaseURL(mockprovider.OpenAI), "k", "free", "mock", "Mock", "openai")
+	if r.Cost != 0 || r.Currency != "" {
+		t.Errorf("价格未知时 = %v %q", r.Cost, r.Currency)
+	}
+
+	// 模型扫描同样按模型估算费用
+	sweep, err := c.SweepModels(context.Background(), srv.BaseURL(mockprovider.OpenAI), "k", "mock", "Mock", "openai",
+		SweepOptions{Models: []string{"priced", "free"}, Stream: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range sweep.Rows {
+		if priced := row.Model == "priced"; priced != (row.Cost > 0 && row.Currency == "USD") {
+			t.Errorf("扫描费用 %s = %v %q", row.Model, row.Cost, row.Currency)
+		}
+	}
+
+	got := ItemCost(CheckResult{TokenIn: 1000, TokenOut: 500}, protocol.ModelInfo{InputPrice: 3, OutputPrice: 15})
+	if got < 0.0104999 || got > 0.0105001 {
+		t.Errorf("ItemCost = %v, 期望 0.0105", got)
+	}
+}
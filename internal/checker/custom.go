@@ -0,0 +1,394 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"pingai/internal/protocol"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// customItemPrefix 自定义用例检测项的前缀，后接用例名称
+const customItemPrefix = "custom:"
+
+// CustomItem 自定义用例对应的检测项
+func CustomItem(name string) CheckItem {
+	return CheckItem(customItemPrefix + name)
+}
+
+// IsCustom 是否为自定义用例检测项
+func (i CheckItem) IsCustom() bool {
+	return strings.HasPrefix(string(i), customItemPrefix)
+}
+
+// AssertionKind 断言类型
+type AssertionKind string
+
+const (
+	AssertContains   AssertionKind = "contains"    // 回复包含 Value
+	AssertRegex      AssertionKind = "regex"       // 回复匹配正则 Value
+	AssertExact      AssertionKind = "exact"       // 回复 (去除首尾空白) 等于 Value
+	AssertJSONPath   AssertionKind = "json_path"   // 回复中的 JSON 在 Path 处的值等于 Value
+	AssertMaxLength  AssertionKind = "max_length"  // 回复字符数不超过 Value
+	AssertMaxLatency AssertionKind = "max_latency" // 耗时 (ms) 不超过 Value
+	AssertLanguage   AssertionKind = "language"    // 回复语言为 Value (zh / ja / ko / ru / ar / en)
+)
+
+// AssertionKinds 支持的断言类型
+var AssertionKinds = []AssertionKind{
+	AssertContains, AssertRegex, AssertExact, AssertJSONPath, AssertMaxLength, AssertMaxLatency, AssertLanguage,
+}
+
+// Assertion 对回复的一条断言
+type Assertion struct {
+	Kind  AssertionKind `json:"kind"`
+	Path  string        `json:"path,omitempty"` // json_path 使用，如 $.data[0].name
+	Value string        `json:"value"`
+}
+
+// AssertionResult 单条断言的结果
+type AssertionResult struct {
+	Assertion
+	Passed bool   `json:"passed"`
+	Actual string `json:"actual,omitempty"` // 未通过时的实际值
+}
+
+// CaseParams 自定义用例的请求参数
+type CaseParams struct {
+	Stream         bool     `json:"stream,omitempty"`
+	Temperature    *float64 `json:"temperature,omitempty"`
+	MaxTokens      int      `json:"maxTokens,omitempty"`
+	TimeoutSeconds int      `json:"timeoutSeconds,omitempty"` // 为 0 时 60 秒
+}
+
+// CustomCase 用户自定义的检测用例，作为额外的检测项执行
+type CustomCase struct {
+	ID         int64              `json:"id"`
+	Name       string             `json:"name"`
+	System     string             `json:"system,omitempty"`
+	Messages   []protocol.Message `json:"messages"`
+	Params     CaseParams         `json:"params"`
+	Assertions []Assertion        `json:"assertions"`
+}
+
+// Validate 检查用例是否可执行
+func (cc CustomCase) Validate() error {
+	if strings.TrimSpace(cc.Name) == "" {
+		return fmt.Errorf("用例名称不能为空")
+	}
+	if len(cc.Messages) == 0 || cc.Messages[len(cc.Messages)-1].Role != "user" {
+		return fmt.Errorf("用例 %s: 消息不能为空且最后一条必须是 user", cc.Name)
+	}
+	for _, m := range cc.Messages {
+		if m.Role != "user" && m.Role != "assistant" {
+			return fmt.Errorf("用例 %s: 不支持的消息角色 %q", cc.Name, m.Role)
+		}
+	}
+	for _, a := range cc.Assertions {
+		if err := a.validate(); err != nil {
+			return fmt.Errorf("用例 %s: %w", cc.Name, err)
+		}
+	}
+	return nil
+}
+
+func (a Assertion) validate() error {
+	switch a.Kind {
+	case AssertContains, AssertExact, AssertLanguage:
+	case AssertRegex:
+		if _, err := regexp.Compile(a.Value); err != nil {
+			return fmt.Errorf("正则 %q 无效: %w", a.Value, err)
+		}
+	case AssertJSONPath:
+		if strings.TrimSpace(a.Path) == "" {
+			return fmt.Errorf("json_path 断言缺少路径")
+		}
+	case AssertMaxLength, AssertMaxLatency:
+		if n, err := strconv.Atoi(a.Value); err != nil || n < 0 {
+			return fmt.Errorf("%s 断言的值必须是非负整数: %q", a.Kind, a.Value)
+		}
+	default:
+		return fmt.Errorf("不支持的断言类型 %q", a.Kind)
+	}
+	return nil
+}
+
+// SetCustomCases 替换自定义用例，之后的检测生效
+func (c *Checker) SetCustomCases(cases []CustomCase) {
+	c.mu.Lock()
+	c.cases = append([]CustomCase(nil), cases...)
+	c.mu.Unlock()
+}
+
+func (c *Checker) customCases() []CustomCase {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cases
+}
+
+// checkCustom 执行自定义用例并逐条校验断言
+func (c *Checker) checkCustom(parent context.Context, adapter protocol.Adapter, baseURL, apiKey, model string, cc CustomCase) CheckResult {
+	start := time.Now()
+	r := CheckResult{Item: CustomItem(cc.Name)}
+
+	timeout := time.Duration(cc.Params.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	// 超时由用例决定，不受默认客户端 30 秒总超时的限制
+	ctx, cancel := context.WithTimeout(protocol.WithClient(parent, protocol.NoTimeoutClient), timeout)
+	defer cancel()
+
+	req := protocol.ChatRequest{
+		BaseURL:     baseURL,
+		APIKey:      apiKey,
+		Model:       model,
+		Messages:    cc.Messages,
+		Stream:      cc.Params.Stream,
+		System:      cc.System,
+		Temperature: cc.Params.Temperature,
+		MaxTokens:   cc.Params.MaxTokens,
+	}
+	var resp *protocol.ChatResponse
+	var err error
+	if cc.Params.Stream {
+		resp, err = adapter.ChatStream(ctx, req, func(chunk string, isFirst bool) {
+			if isFirst {
+				r.TTFT = time.Since(start).Milliseconds()
+			}
+		})
+	} else {
+		resp, err = adapter.Chat(ctx, req)
+	}
+	r.Latency = time.Since(start).Milliseconds()
+
+	if err != nil {
+		r.Status = StatusFailed
+		r.Message = "请求失败"
+		r.Detail = err.Error()
+		r.ErrorKind = ClassifyError(err)
+		return r
+	}
+	if resp.Error != "" {
+		r.Status = StatusFailed
+		r.Message = resp.Error
+		r.Detail = resp.RawBody
+		r.ErrorKind = classifyChatResponse(resp)
+		return r
+	}
+	r.TokenIn = resp.PromptTokens
+	r.TokenOut = resp.CompTokens
+
+	// 断言未通过说明回复不符合预期，但接口本身可用，记为 warning
+	r.Assertions = EvaluateAssertions(cc.Assertions, resp.Content, r.Latency)
+	passed := 0
+	var lines []string
+	for _, ar := range r.Assertions {
+		if ar.Passed {
+			passed++
+		}
+		lines = append(lines, ar.String())
+	}
+	lines = append(lines, "回复: "+truncate(resp.Content, 200))
+	r.Detail = strings.Join(lines, "\n")
+
+	switch {
+	case len(r.Assertions) == 0:
+		r.Status = StatusSuccess
+		r.Message = "用例执行完成"
+	case passed == len(r.Assertions):
+		r.Status = StatusSuccess
+		r.Message = fmt.Sprintf("断言全部通过 (%d/%d)", passed, len(r.Assertions))
+	default:
+		r.Status = StatusWarning
+		r.Message = fmt.Sprintf("断言未通过 (%d/%d)", passed, len(r.Assertions))
+	}
+	return r
+}
+
+// String 单行描述，如 "[OK] contains: OK" / "[FAIL] max_length: 10 (实际: 42)"
+func (ar AssertionResult) String() string {
+	icon := "[OK]"
+	if !ar.Passed {
+		icon = "[FAIL]"
+	}
+	target := ar.Value
+	if ar.Path != "" {
+		target = ar.Path + " = " + ar.Value
+	}
+	s := fmt.Sprintf("%s %s: %s", icon, ar.Kind, target)
+	if !ar.Passed && ar.Actual != "" {
+		s += fmt.Sprintf(" (实际: %s)", ar.Actual)
+	}
+	return s
+}
+
+// EvaluateAssertions 依次校验断言，latency 为请求耗时 (ms)
+func EvaluateAssertions(assertions []Assertion, content string, latency int64) []AssertionResult {
+	out := make([]AssertionResult, 0, len(assertions))
+	for _, a := range assertions {
+		passed, actual := evaluate(a, content, latency)
+		ar := AssertionResult{Assertion: a, Passed: passed}
+		if !passed {
+			ar.Actual = actual
+		}
+		out = append(out, ar)
+	}
+	return out
+}
+
+func evaluate(a Assertion, content string, latency int64) (bool, string) {
+	switch a.Kind {
+	case AssertContains:
+		return strings.Contains(content, a.Value), truncate(content, 50)
+	case AssertRegex:
+		re, err := regexp.Compile(a.Value)
+		if err != nil {
+			return false, err.Error()
+		}
+		return re.MatchString(content), truncate(content, 50)
+	case AssertExact:
+		return strings.TrimSpace(content) == strings.TrimSpace(a.Value), truncate(content, 50)
+	case AssertJSONPath:
+		doc, ok := extractJSON(content)
+		if !ok {
+			return false, "回复不是 JSON"
+		}
+		v, ok := lookupJSONPath(doc, a.Path)
+		if !ok {
+			return false, "路径不存在"
+		}
+		return jsonEqual(v, a.Value), jsonString(v)
+	case AssertMaxLength:
+		limit, _ := strconv.Atoi(a.Value)
+		n := utf8.RuneCountInString(strings.TrimSpace(content))
+		return n <= limit, strconv.Itoa(n)
+	case AssertMaxLatency:
+		limit, _ := strconv.ParseInt(a.Value, 10, 64)
+		return latency <= limit, fmt.Sprintf("%dms", latency)
+	case AssertLanguage:
+		want, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(a.Value)), "-")
+		got := DetectLanguage(content)
+		return got == want, got
+	}
+	return false, "不支持的断言类型"
+}
+
+// extractJSON 解析回复中的 JSON，兼容 ``` 代码块和前后的说明文字
+func extractJSON(content string) (any, bool) {
+	s := strings.TrimSpace(content)
+	if strings.HasPrefix(s, "```") {
+		s = strings.TrimPrefix(s, "```")
+		if i := strings.IndexByte(s, '\n'); i >= 0 {
+			s = s[i+1:]
+		}
+		s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	}
+	var v any
+	if json.Unmarshal([]byte(s), &v) == nil {
+		return v, true
+	}
+	start := strings.IndexAny(s, "{[")
+	end := strings.LastIndexAny(s, "}]")
+	if start < 0 || end <= start {
+		return nil, false
+	}
+	if json.Unmarshal([]byte(s[start:end+1]), &v) == nil {
+		return v, true
+	}
+	return nil, false
+}
+
+// lookupJSONPath 按 $.a.b[0].c 或 a.b.0.c 形式的路径取值
+func lookupJSONPath(doc any, path string) (any, bool) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	v := doc
+	for _, seg := range strings.Split(path, ".") {
+		if seg == "" {
+			continue
+		}
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[seg]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// jsonEqual want 可以是 JSON 字面量 (42 / true / "x" / {...}) 或不带引号的字符串
+func jsonEqual(v any, want string) bool {
+	var decoded any
+	if json.Unmarshal([]byte(want), &decoded) == nil && reflect.DeepEqual(v, decoded) {
+		return true
+	}
+	return jsonString(v) == want
+}
+
+func jsonString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// DetectLanguage 按文字的书写系统粗略判断语言，拉丁字母统一视为 en，无法判断时返回空
+// 一个汉字 / 假名 / 谚文音节的信息量约相当于三个字母，计数时加权
+func DetectLanguage(s string) string {
+	const cjkWeight = 3
+	counts := make(map[string]int)
+	kana := 0
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana += cjkWeight
+		case unicode.Is(unicode.Han, r):
+			counts["zh"] += cjkWeight
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"] += cjkWeight
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["ru"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Latin, r):
+			counts["en"]++
+		}
+	}
+	// 日文混用汉字和假名
+	if kana > 0 {
+		counts["ja"] = kana + counts["zh"]
+		delete(counts, "zh")
+	}
+	best, max := "", 0
+	for _, lang := range []string{"zh", "ja", "ko", "ru", "ar", "en"} {
+		if counts[lang] > max {
+			best, max = lang, counts[lang]
+		}
+	}
+	return best
+}
+
+// RunCustomCase 单独执行一个用例，用于编辑时试运行
+func (c *Checker) RunCustomCase(ctx context.Context, baseURL, apiKey, model, proto string, cc CustomCase) CheckResult {
+	adapter := protocol.GetAdapter(protocol.Protocol(proto))
+	return c.checkCustom(ctx, adapter, baseURL, apiKey, model, cc)
+}
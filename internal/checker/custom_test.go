@@ -0,0 +1,151 @@
+package checker
+
+import (
+	"context"
+	"pingai/internal/mockprovider"
+	"pingai/internal/protocol"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvaluateAssertions(t *testing.T) {
+	reply := "Sure:\n```json\n{\"answer\": 42, \"items\": [{\"name\": \"a\"}], \"ok\": true}\n```"
+	cases := []struct {
+		a    Assertion
+		want bool
+	}{
+		{Assertion{Kind: AssertContains, Value: "42"}, true},
+		{Assertion{Kind: AssertContains, Value: "43"}, false},
+		{Assertion{Kind: AssertRegex, Value: `"answer":\s*\d+`}, true},
+		{Assertion{Kind: AssertExact, Value: "Sure"}, false},
+		{Assertion{Kind: AssertJSONPath, Path: "$.answer", Value: "42"}, true},
+		{Assertion{Kind: AssertJSONPath, Path: "$.items[0].name", Value: "a"}, true},
+		{Assertion{Kind: AssertJSONPath, Path: "items.0.name", Value: `"a"`}, true},
+		{Assertion{Kind: AssertJSONPath, Path: "$.ok", Value: "true"}, true},
+		{Assertion{Kind: AssertJSONPath, Path: "$.missing", Value: "1"}, false},
+		{Assertion{Kind: AssertMaxLength, Value: "10"}, false},
+		{Assertion{Kind: AssertMaxLatency, Value: "500"}, true},
+		{Assertion{Kind: AssertMaxLatency, Value: "100"}, false},
+		{Assertion{Kind: AssertLanguage, Value: "en"}, true},
+	}
+	for _, c := range cases {
+		got := EvaluateAssertions([]Assertion{c.a}, reply, 200)[0]
+		if got.Passed != c.want {
+			t.Errorf("%+v: Passed = %v (实际: %s)", c.a, got.Passed, got.Actual)
+		}
+		if !got.Passed && got.Actual == "" {
+			t.Errorf("%+v: 未通过时应记录实际值", c.a)
+		}
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	cases := map[string]string{
+		"Hello, world":         "en",
+		"你好，我是一个 AI assistant": "zh",
+		"こんにちは、世界":             "ja",
+		"안녕하세요":                "ko",
+		"Привет, мир":          "ru",
+		"12345":                "",
+	}
+	for s, want := range cases {
+		if got := DetectLanguage(s); got != want {
+			t.Errorf("DetectLanguage(%q) = %q, 期望 %q", s, got, want)
+		}
+	}
+}
+
+func TestCustomCaseValidate(t *testing.T) {
+	msgs := []protocol.Message{{Role: "user", Content: "hi"}}
+	bad := []CustomCase{
+		{Messages: msgs},
+		{Name: "x"},
+		{Name: "x", Messages: []protocol.Message{{Role: "system", Content: "hi"}}},
+		{Name: "x", Messages: msgs, Assertions: []Assertion{{Kind: AssertRegex, Value: "("}}},
+		{Name: "x", Messages: msgs, Assertions: []Assertion{{Kind: AssertMaxLength, Value: "abc"}}},
+		{Name: "x", Messages: msgs, Assertions: []Assertion{{Kind: "unknown"}}},
+	}
+	for _, cc := range bad {
+		if cc.Validate() == nil {
+			t.Errorf("%+v 应校验失败", cc)
+		}
+	}
+	if err := (CustomCase{Name: "x", Messages: msgs}).Validate(); err != nil {
+		t.Errorf("合法用例校验失败: %v", err)
+	}
+}
+
+func TestRunFullCheckCustomCases(t *testing.T) {
+	srv := mockprovider.NewServer(mockprovider.Behavior{})
+	defer srv.Close()
+
+	c := NewChecker()
+	c.SetCustomCases([]CustomCase{
+		{
+			Name:       "json",
+			System:     "Reply in JSON.",
+			Messages:   []protocol.Message{{Role: "user", Content: "Give me JSON"}},
+			Assertions: []Assertion{{Kind: AssertJSONPath, Path: "$.answer", Value: "42"}, {Kind: AssertLanguage, Value: "zh"}},
+		},
+		{
+			Name:       "stream",
+			Messages:   []protocol.Message{{Role: "user", Content: "Say hi"}},
+			Params:     CaseParams{Stream: true, MaxTokens: 16},
+			Assertions: []Assertion{{Kind: AssertContains, Value: "answer"}},
+		},
+	})
+	srv.Set(mockprovider.Behavior{Reply: `{"answer": 42}`})
+
+	r := c.RunFullCheck(srv.BaseURL(mockprovider.Anthropic), "k", "m", "mock", "Mock", "anthropic")
+	if len(r.Results) != 7 {
+		t.Fatalf("检测项数量 = %d, 期望 5 + 2", len(r.Results))
+	}
+	items := itemsOf(r)
+	js := items[CustomItem("json")]
+	if js.Status != StatusWarning || len(js.Assertions) != 2 || !js.Assertions[0].Passed || js.Assertions[1].Passed {
+		t.Errorf("json 用例 = %+v", js)
+	}
+	if !strings.Contains(js.Detail, "[OK] json_path: $.answer = 42") || !strings.Contains(js.Detail, "[FAIL] language: zh") {
+		t.Errorf("json 用例 Detail = %q", js.Detail)
+	}
+	if st := items[CustomItem("stream")]; st.Status != StatusSuccess || len(st.Assertions) != 1 {
+		t.Errorf("stream 用例 = %+v", st)
+	}
+	if !CustomItem("json").IsCustom() || CheckChat.IsCustom() {
+		t.Error("IsCustom 判断错误")
+	}
+
+	report := GenerateTextSummary([]FullCheckResult{r})
+	if !strings.Contains(report, "[FAIL] language: zh") {
+		t.Errorf("文本报告缺少断言结果:\n%s", report)
+	}
+	if !strings.Contains(GenerateReport([]FullCheckResult{r}), `"assertionsFailed": 1`) {
+		t.Error("JSON 报告缺少断言统计")
+	}
+
+	srv.Set(mockprovider.Behavior{Status: 500})
+	r = c.RunFullCheck(srv.BaseURL(mockprovider.OpenAI), "k", "m", "mock", "Mock", "openai")
+	if len(r.Results) != 1 {
+		t.Errorf("连通性失败时不应执行用例, 得到 %d 项", len(r.Results))
+	}
+}
+
+func TestCustomCaseLongTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("需要等待 30 秒以上")
+	}
+	srv := mockprovider.NewServer(mockprovider.Behavior{TTFT: 31 * time.Second})
+	defer srv.Close()
+
+	cc := CustomCase{
+		Name:     "slow",
+		Messages: []protocol.Message{{Role: "user", Content: "Say hi"}},
+		Params:   CaseParams{TimeoutSeconds: 45},
+	}
+	r := NewChecker().checkCustom(context.Background(), protocol.GetAdapter(protocol.ProtocolOpenAI),
+		srv.BaseURL(mockprovider.OpenAI), "k", "m", cc)
+	if r.Status != StatusSuccess {
+		t.Errorf("超时 45 秒的用例不应在 30 秒时中断: %+v", r)
+	}
+}
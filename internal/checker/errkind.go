@@ -0,0 +1,264 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"pingai/internal/protocol"
+	"strings"
+	"syscall"
+)
+
+// ErrorKind 失败原因分类
+type ErrorKind string
+
+const (
+	KindNone            ErrorKind = ""
+	KindDNS             ErrorKind = "dns"
+	KindConnectRefused  ErrorKind = "connect_refused"
+	KindConnReset       ErrorKind = "connection_reset" // 连接建立后被对端重置
+	KindUnreachable     ErrorKind = "network_unreachable"
+	KindTLS             ErrorKind = "tls"
+	KindTimeout         ErrorKind = "timeout"
+	KindAuthInvalid     ErrorKind = "auth_invalid"
+	KindAuthForbidden   ErrorKind = "auth_forbidden"
+	KindQuotaExhausted  ErrorKind = "quota_exhausted"
+	KindRateLimited     ErrorKind = "rate_limited"
+	KindModelNotFound   ErrorKind = "model_not_found"
+	KindRegionBlocked   ErrorKind = "region_blocked"
+	KindContentFiltered ErrorKind = "content_filtered"
+	KindBadRequest      ErrorKind = "bad_request"
+	KindUpstream5xx     ErrorKind = "upstream_5xx"
+	KindParseError      ErrorKind = "parse_error"
+	KindUnknown         ErrorKind = "unknown"
+)
+
+// IsNetwork 是否为网络层错误 (请求未到达服务端或未得到完整响应)
+func (k ErrorKind) IsNetwork() bool {
+	switch k {
+	case KindDNS, KindConnectRefused, KindConnReset, KindUnreachable, KindTLS, KindTimeout:
+		return true
+	}
+	return false
+}
+
+// ClassifyError 根据 Go 错误类型分类
+func ClassifyError(err error) ErrorKind {
+	if err == nil {
+		return KindNone
+	}
+
+	var httpErr *protocol.HTTPError
+	if errors.As(err, &httpErr) {
+		return ClassifyResponse(httpErr.StatusCode, httpErr.Body)
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsTimeout {
+			return KindTimeout
+		}
+		return KindDNS
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return KindTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return KindTimeout
+	}
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return KindConnectRefused
+	case errors.Is(err, syscall.ECONNRESET):
+		return KindConnReset
+	case errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, syscall.EHOSTUNREACH):
+		return KindUnreachable
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var unknownAuth x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	if errors.As(err, &certErr) || errors.As(err, &unknownAuth) || errors.As(err, &hostErr) ||
+		errors.As(err, &invalidCert) || errors.As(err, &recordErr) || errors.As(err, &alertErr) {
+		return KindTLS
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return KindParseError
+	}
+
+	// 部分错误经过多层包装后只剩文本
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "no such host"):
+		return KindDNS
+	case strings.Contains(msg, "connection refused"):
+		return KindConnectRefused
+	case strings.Contains(msg, "connection reset"):
+		return KindConnReset
+	case strings.Contains(msg, "tls:") || strings.Contains(msg, "x509:"):
+		return KindTLS
+	case strings.Contains(msg, "timeout") || strings.Contains(msg, "deadline exceeded"):
+		return KindTimeout
+	}
+	return KindUnknown
+}
+
+// kindKeywords 错误体中的厂商错误码/关键字 -> 分类，按顺序匹配
+var kindKeywords = []struct {
+	keyword string
+	kind    ErrorKind
+}{
+	// 地区限制
+	{"unsupported_country_region_territory", KindRegionBlocked},
+	{"user location is not supported", KindRegionBlocked},
+	{"not available in your country", KindRegionBlocked},
+	{"region is not supported", KindRegionBlocked},
+	// 额度
+	{"insufficient_quota", KindQuotaExhausted},
+	{"billing_error", KindQuotaExhausted},
+	{"credit balance is too low", KindQuotaExhausted},
+	{"insufficient balance", KindQuotaExhausted},
+	{"insufficient_user_quota", KindQuotaExhausted},
+	{"余额不足", KindQuotaExhausted},
+	{"额度不足", KindQuotaExhausted},
+	// 认证
+	{"api_key_invalid", KindAuthInvalid},
+	{"invalid_api_key", KindAuthInvalid},
+	{"authentication_error", KindAuthInvalid},
+	{"unauthenticated", KindAuthInvalid},
+	{"incorrect api key", KindAuthInvalid},
+	{"api key not valid", KindAuthInvalid},
+	{"invalid x-api-key", KindAuthInvalid},
+	{"account_deactivated", KindAuthForbidden},
+	{"permission_error", KindAuthForbidden},
+	{"permission_denied", KindAuthForbidden},
+	// 内容审核
+	{"content_policy_violation", KindContentFiltered},
+	{"content_filter", KindContentFiltered},
+	{"data_inspection_failed", KindContentFiltered},
+	{"blocked due to safety", KindContentFiltered},
+	// 模型
+	{"model_not_found", KindModelNotFound},
+	{"not_found_error", KindModelNotFound},
+	{"does not exist", KindModelNotFound},
+	{"model not found", KindModelNotFound},
+	{"no such model", KindModelNotFound},
+	{"is not found for api version", KindModelNotFound},
+	// 限流
+	{"rate_limit", KindRateLimited},
+	{"resource_exhausted", KindRateLimited},
+	{"too many requests", KindRateLimited},
+	// 上游
+	{"overloaded_error", KindUpstream5xx},
+}
+
+// ClassifyResponse 根据 HTTP 状态码和错误体分类
+func ClassifyResponse(status int, body string) ErrorKind {
+	if kind := classifyBody(body); kind != KindNone {
+		// 5xx 只在错误体明确指向其他原因时覆盖
+		if status < 500 || kind == KindQuotaExhausted || kind == KindRegionBlocked {
+			return kind
+		}
+	}
+
+	switch {
+	case status == 401:
+		return KindAuthInvalid
+	case status == 402:
+		return KindQuotaExhausted
+	case status == 403:
+		return KindAuthForbidden
+	case status == 404:
+		return KindModelNotFound
+	case status == 408:
+		return KindTimeout
+	case status == 429:
+		return KindRateLimited
+	case status == 451:
+		return KindRegionBlocked
+	case status >= 500:
+		return KindUpstream5xx
+	case status >= 400:
+		return KindBadRequest
+	}
+	return KindNone
+}
+
+// classifyBody 解析 OpenAI / Anthropic / Gemini 错误体中的错误码
+func classifyBody(body string) ErrorKind {
+	if body == "" {
+		return KindNone
+	}
+
+	// 优先使用结构化字段，解析失败 (例如响应体被截断) 时退化为全文匹配
+	text := body
+	var envelope struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal([]byte(body), &envelope) == nil && len(envelope.Error) > 0 {
+		var e struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+			Code    any    `json:"code"`
+			Status  string `json:"status"`
+			Details []struct {
+				Reason string `json:"reason"`
+			} `json:"details"`
+		}
+		if json.Unmarshal(envelope.Error, &e) == nil {
+			parts := []string{e.Type, e.Status, e.Message}
+			if code, ok := e.Code.(string); ok {
+				parts = append(parts, code)
+			}
+			for _, d := range e.Details {
+				parts = append(parts, d.Reason)
+			}
+			text = strings.Join(parts, " ")
+		} else {
+			// {"error": "..."} 形式
+			var msg string
+			json.Unmarshal(envelope.Error, &msg)
+			text = msg
+		}
+	}
+
+	lower := strings.ToLower(text)
+	for _, kw := range kindKeywords {
+		if strings.Contains(lower, kw.keyword) {
+			return kw.kind
+		}
+	}
+	return KindNone
+}
+
+// PrimaryErrorKind 返回一次完整检测中首个失败项的分类
+func PrimaryErrorKind(r FullCheckResult) ErrorKind {
+	for _, item := range r.Results {
+		if item.Status == StatusFailed && item.ErrorKind != KindNone {
+			return item.ErrorKind
+		}
+	}
+	return KindNone
+}
+
+// classifyChatResponse 对带错误的对话响应分类
+func classifyChatResponse(resp *protocol.ChatResponse) ErrorKind {
+	if kind := ClassifyResponse(resp.StatusCode, resp.RawBody); kind != KindNone {
+		return kind
+	}
+	// HTTP 200 但内容无法解析 ("JSON parse error" / "empty choices" 等)
+	return KindParseError
+}
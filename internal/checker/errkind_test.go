@@ -0,0 +1,87 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"pingai/internal/protocol"
+	"syscall"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{"nil", nil, KindNone},
+		{"dns", &net.DNSError{Err: "no such host", Name: "api.example.invalid"}, KindDNS},
+		{"refused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, KindConnectRefused},
+		{"reset", &net.OpError{Op: "read", Err: syscall.ECONNRESET}, KindConnReset},
+		{"unreachable", &net.OpError{Op: "dial", Err: syscall.EHOSTUNREACH}, KindUnreachable},
+		{"deadline", fmt.Errorf("post: %w", context.DeadlineExceeded), KindTimeout},
+		{"http error", &protocol.HTTPError{StatusCode: 401, Body: "unauthorized"}, KindAuthInvalid},
+		{"text only", errors.New("remote error: tls: handshake failure"), KindTLS},
+		{"unknown", errors.New("boom"), KindUnknown},
+	}
+	for _, c := range cases {
+		if got := ClassifyError(c.err); got != c.want {
+			t.Errorf("%s: ClassifyError = %q, 期望 %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestClassifyResponse(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		body   string
+		want   ErrorKind
+	}{
+		// OpenAI
+		{"openai quota", 429, `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`, KindQuotaExhausted},
+		{"openai rate", 429, `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`, KindRateLimited},
+		{"openai key", 401, `{"error":{"message":"Incorrect API key provided","code":"invalid_api_key"}}`, KindAuthInvalid},
+		{"openai model", 404, `{"error":{"message":"The model gpt-5 does not exist","code":"model_not_found"}}`, KindModelNotFound},
+		{"openai region", 403, `{"error":{"code":"unsupported_country_region_territory","message":"Country not supported"}}`, KindRegionBlocked},
+		// Anthropic
+		{"anthropic auth", 401, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`, KindAuthInvalid},
+		{"anthropic billing", 400, `{"type":"error","error":{"type":"invalid_request_error","message":"Your credit balance is too low"}}`, KindQuotaExhausted},
+		{"anthropic overloaded", 529, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, KindUpstream5xx},
+		{"anthropic permission", 403, `{"type":"error","error":{"type":"permission_error","message":"not allowed"}}`, KindAuthForbidden},
+		// Gemini
+		{"gemini key", 400, `{"error":{"code":400,"message":"API key not valid.","status":"INVALID_ARGUMENT","details":[{"reason":"API_KEY_INVALID"}]}}`, KindAuthInvalid},
+		{"gemini region", 400, `{"error":{"code":400,"message":"User location is not supported for the API use.","status":"FAILED_PRECONDITION"}}`, KindRegionBlocked},
+		{"gemini exhausted", 429, `{"error":{"code":429,"status":"RESOURCE_EXHAUSTED","message":"Resource has been exhausted"}}`, KindRateLimited},
+		// 纯状态码 / 截断响应体
+		{"plain 500", 502, "Bad Gateway", KindUpstream5xx},
+		{"plain 400", 400, "bad", KindBadRequest},
+		{"truncated", 401, `{"error":{"message":"Incorrect API key provided: sk-abc...","code":"invalid_api_k`, KindAuthInvalid},
+		{"string error", 400, `{"error":"content_filter triggered"}`, KindContentFiltered},
+	}
+	for _, c := range cases {
+		if got := ClassifyResponse(c.status, c.body); got != c.want {
+			t.Errorf("%s: ClassifyResponse = %q, 期望 %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestReportGroupsErrorKinds(t *testing.T) {
+	results := []FullCheckResult{
+		{Results: []CheckResult{{Item: CheckConnectivity, Status: StatusFailed, ErrorKind: KindDNS}}},
+		{Results: []CheckResult{{Item: CheckChat, Status: StatusFailed, ErrorKind: KindAuthInvalid}}},
+		{Results: []CheckResult{{Item: CheckChat, Status: StatusFailed, ErrorKind: KindAuthInvalid}}},
+		{Results: []CheckResult{{Item: CheckChat, Status: StatusSuccess}}},
+	}
+	var report Report
+	if err := json.Unmarshal([]byte(GenerateReport(results)), &report); err != nil {
+		t.Fatalf("报告解析失败: %v", err)
+	}
+	kinds := report.Summary.ErrorKinds
+	if kinds[KindAuthInvalid] != 2 || kinds[KindDNS] != 1 || len(kinds) != 2 {
+		t.Errorf("ErrorKinds = %v", kinds)
+	}
+}
@@ -0,0 +1,298 @@
+package checker
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"pingai/internal/keys"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MatrixProvider 矩阵中的一个供应商，Models / Keys 为空时使用 MatrixSpec 中的公共值
+type MatrixProvider struct {
+	ProviderID   string   `json:"providerID"`
+	ProviderName string   `json:"providerName"`
+	BaseURL      string   `json:"baseURL"`
+	Protocol     string   `json:"protocol"`
+	Models       []string `json:"models"`
+	Keys         []string `json:"keys"`
+}
+
+// MatrixSpec 供应商 × 模型 × Key 批量检测规格
+type MatrixSpec struct {
+	Providers   []MatrixProvider `json:"providers"`
+	Models      []string         `json:"models"` // 各供应商共用的模型名
+	Keys        []string         `json:"keys"`   // 各供应商共用的 Key
+	Concurrency int              `json:"concurrency"`
+}
+
+// MatrixTarget 展开后的单次检测
+type MatrixTarget struct {
+	ProviderID   string `json:"providerID"`
+	ProviderName string `json:"providerName"`
+	BaseURL      string `json:"baseURL"`
+	Protocol     string `json:"protocol"`
+	Model        string `json:"model"`
+	APIKey       string `json:"-"`
+	MaskedKey    string `json:"maskedKey"`
+	KeyID        string `json:"keyID"`    // 同一次展开中 Key 的序号，Key 维度按此分组
+	KeyLabel     string `json:"keyLabel"` // Key 维度的展示名，脱敏结果相同时附加序号区分
+}
+
+// ExpandMatrix 展开矩阵规格，去掉相同地址、协议、模型和 Key 的重复组合
+func ExpandMatrix(spec MatrixSpec) ([]MatrixTarget, error) {
+	var targets []MatrixTarget
+	seen := make(map[string]bool)
+	// 不同 Key 的脱敏结果可能相同，分组使用序号
+	keyIDs := make(map[string]string)
+	keyLabels := make(map[string]string)
+	masks := make(map[string]int)
+	keyOf := func(key string) (string, string) {
+		if id, ok := keyIDs[key]; ok {
+			return id, keyLabels[key]
+		}
+		id := strconv.Itoa(len(keyIDs) + 1)
+		mask := keys.Mask(key)
+		label := mask
+		if masks[mask]++; masks[mask] > 1 {
+			label = fmt.Sprintf("%s #%s", mask, id)
+		}
+		keyIDs[key], keyLabels[key] = id, label
+		return id, label
+	}
+	for _, p := range spec.Providers {
+		if p.BaseURL == "" {
+			return nil, fmt.Errorf("供应商 %s 缺少 Base URL", p.ProviderID)
+		}
+		models := p.Models
+		if len(models) == 0 {
+			models = spec.Models
+		}
+		apiKeys := p.Keys
+		if len(apiKeys) == 0 {
+			apiKeys = spec.Keys
+		}
+		if len(apiKeys) == 0 {
+			apiKeys = []string{""}
+		}
+		name := p.ProviderName
+		if name == "" {
+			name = p.ProviderID
+		}
+		for _, model := range dedupe(models) {
+			for _, key := range apiKeys {
+				key = strings.TrimSpace(key)
+				id := strings.TrimRight(p.BaseURL, "/") + "\x00" + p.Protocol + "\x00" + model + "\x00" + key
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				keyID, keyLabel := keyOf(key)
+				targets = append(targets, MatrixTarget{
+					ProviderID:   p.ProviderID,
+					ProviderName: name,
+					BaseURL:      p.BaseURL,
+					Protocol:     p.Protocol,
+					Model:        model,
+					APIKey:       key,
+					MaskedKey:    keys.Mask(key),
+					KeyID:        keyID,
+					KeyLabel:     keyLabel,
+				})
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("矩阵为空: 至少需要一个供应商和一个模型")
+	}
+	return targets, nil
+}
+
+// MatrixCell 矩阵中一次检测的结果
+type MatrixCell struct {
+	MatrixTarget
+	Status  CheckStatus     `json:"status"`
+	Verdict KeyVerdict      `json:"verdict"`
+	Latency int64           `json:"latency"` // 对话延迟 (ms)
+	Result  FullCheckResult `json:"result"`
+}
+
+// MatrixGroup 按某一维度分组的汇总，Cells 为 MatrixResult.Cells 中的下标
+type MatrixGroup struct {
+	Value      string `json:"value"` // 供应商维度为供应商 ID，Key 维度为 KeyID
+	Label      string `json:"label"` // 展示名，供应商维度为供应商名称，Key 维度为脱敏 Key
+	Total      int    `json:"total"`
+	Success    int    `json:"success"`
+	AvgLatency int64  `json:"avgLatency"` // 成功项的平均对话延迟
+	Cells      []int  `json:"cells"`
+}
+
+// MatrixResult 矩阵检测结果
+type MatrixResult struct {
+	Cells      []MatrixCell  `json:"cells"`
+	ByProvider []MatrixGroup `json:"byProvider"`
+	ByModel    []MatrixGroup `json:"byModel"`
+	ByKey      []MatrixGroup `json:"byKey"`
+}
+
+// 矩阵维度
+const (
+	AxisProvider = "provider"
+	AxisModel    = "model"
+	AxisKey      = "key"
+)
+
+// axis 分组使用的值，供应商按 ID、Key 按序号区分，避免同名供应商或脱敏结果相同的 Key 被合并
+func (c MatrixCell) axis(name string) string {
+	switch name {
+	case AxisProvider:
+		return c.ProviderID
+	case AxisModel:
+		return c.Model
+	default:
+		return c.KeyID
+	}
+}
+
+// axisLabel 展示用的值，供应商显示名称，Key 显示脱敏结果
+func (c MatrixCell) axisLabel(name string) string {
+	switch {
+	case name == AxisProvider && c.ProviderName != "":
+		return c.ProviderName
+	case name == AxisKey:
+		if c.KeyLabel != "" {
+			return c.KeyLabel
+		}
+		return c.MaskedKey
+	}
+	return c.axis(name)
+}
+
+// RunMatrix 以最多 concurrency 个并发执行展开后的检测，run 负责单次检测
+func RunMatrix(targets []MatrixTarget, concurrency int, run func(MatrixTarget) FullCheckResult) MatrixResult {
+	cells := make([]MatrixCell, len(targets))
+	forEachLimit(concurrency, len(targets), func(i int) {
+		r := run(targets[i])
+		cell := MatrixCell{MatrixTarget: targets[i], Status: StatusSuccess, Verdict: ClassifyKey(r), Result: r}
+		for _, item := range r.Results {
+			if statusRank(item.Status) > statusRank(cell.Status) {
+				cell.Status = item.Status
+			}
+			if item.Item == CheckChat {
+				cell.Latency = item.Latency
+			}
+		}
+		cells[i] = cell
+	})
+	return MatrixResult{
+		Cells:      cells,
+		ByProvider: groupCells(cells, AxisProvider),
+		ByModel:    groupCells(cells, AxisModel),
+		ByKey:      groupCells(cells, AxisKey),
+	}
+}
+
+// groupCells 按维度分组，保持首次出现的顺序
+func groupCells(cells []MatrixCell, axis string) []MatrixGroup {
+	var groups []MatrixGroup
+	index := make(map[string]int)
+	var latency []int64
+	for i, c := range cells {
+		v := c.axis(axis)
+		gi, ok := index[v]
+		if !ok {
+			gi = len(groups)
+			index[v] = gi
+			groups = append(groups, MatrixGroup{Value: v, Label: c.axisLabel(axis)})
+			latency = append(latency, 0)
+		}
+		g := &groups[gi]
+		g.Total++
+		g.Cells = append(g.Cells, i)
+		if c.Status != StatusFailed {
+			g.Success++
+			latency[gi] += c.Latency
+		}
+	}
+	for i := range groups {
+		if groups[i].Success > 0 {
+			groups[i].AvgLatency = latency[i] / int64(groups[i].Success)
+		}
+	}
+	return groups
+}
+
+// MatrixPivotCSV 透视导出：rows / cols 为 provider / model / key，
+// 单元格为 "状态 延迟ms"，第三个维度有多项时以 "; " 连接
+func MatrixPivotCSV(r MatrixResult, rows, cols string) (string, error) {
+	axes := map[string]bool{AxisProvider: true, AxisModel: true, AxisKey: true}
+	if !axes[rows] || !axes[cols] || rows == cols {
+		return "", fmt.Errorf("无效的透视维度: %s × %s", rows, cols)
+	}
+	var rest string
+	for a := range axes {
+		if a != rows && a != cols {
+			rest = a
+		}
+	}
+
+	multi := len(uniqueAxis(r.Cells, rest)) > 1
+	var rowValues, colValues []string
+	rowLabels, colLabels := make(map[string]string), make(map[string]string)
+	seenRow, seenCol := make(map[string]bool), make(map[string]bool)
+	table := make(map[[2]string][]string)
+	for _, c := range r.Cells {
+		rv, cv := c.axis(rows), c.axis(cols)
+		if !seenRow[rv] {
+			seenRow[rv] = true
+			rowValues = append(rowValues, rv)
+			rowLabels[rv] = c.axisLabel(rows)
+		}
+		if !seenCol[cv] {
+			seenCol[cv] = true
+			colValues = append(colValues, cv)
+			colLabels[cv] = c.axisLabel(cols)
+		}
+		v := fmt.Sprintf("%s %dms", c.Status, c.Latency)
+		if c.Status == StatusFailed {
+			v = string(c.Status)
+			if kind := PrimaryErrorKind(c.Result); kind != KindNone {
+				v += " (" + string(kind) + ")"
+			}
+		}
+		if multi {
+			v = c.axisLabel(rest) + ": " + v
+		}
+		key := [2]string{rv, cv}
+		table[key] = append(table[key], v)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := []string{rows + " \\ " + cols}
+	for _, cv := range colValues {
+		header = append(header, colLabels[cv])
+	}
+	w.Write(header)
+	for _, rv := range rowValues {
+		line := []string{rowLabels[rv]}
+		for _, cv := range colValues {
+			vals := table[[2]string{rv, cv}]
+			sort.Strings(vals)
+			line = append(line, strings.Join(vals, "; "))
+		}
+		w.Write(line)
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+func uniqueAxis(cells []MatrixCell, axis string) map[string]bool {
+	m := make(map[string]bool)
+	for _, c := range cells {
+		m[c.axis(axis)] = true
+	}
+	return m
+}
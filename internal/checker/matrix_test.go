@@ -0,0 +1,110 @@
+package checker
+
+import (
+	"pingai/internal/mockprovider"
+	"strings"
+	"testing"
+)
+
+func TestExpandMatrix(t *testing.T) {
+	spec := MatrixSpec{
+		Providers: []MatrixProvider{
+			{ProviderID: "a", BaseURL: "https://a/v1", Protocol: "openai"},
+			{ProviderID: "b", BaseURL: "https://b/v1", Protocol: "openai", Models: []string{"b-1", "b-1"}, Keys: []string{"sk-b"}},
+			{ProviderID: "a2", BaseURL: "https://a/v1/", Protocol: "openai"}, // 与 a 相同的地址
+		},
+		Models: []string{"m-1", "m-2"},
+		Keys:   []string{"sk-1111111111", "sk-2222222222"},
+	}
+	targets, err := ExpandMatrix(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a: 2 模型 × 2 Key，b: 1 × 1，a2 与 a 完全重复
+	if len(targets) != 5 {
+		t.Fatalf("展开 %d 项, 期望 5: %+v", len(targets), targets)
+	}
+	if targets[0].ProviderName != "a" || targets[0].MaskedKey != "sk-...1111" || targets[4].Model != "b-1" {
+		t.Errorf("展开结果 = %+v", targets)
+	}
+
+	if _, err := ExpandMatrix(MatrixSpec{Providers: []MatrixProvider{{ProviderID: "a", BaseURL: "https://a"}}}); err == nil {
+		t.Error("没有模型时应返回错误")
+	}
+}
+
+func TestRunMatrix(t *testing.T) {
+	good := mockprovider.NewServer(mockprovider.Behavior{Models: []string{"m-1", "m-2"}, StrictModels: true})
+	defer good.Close()
+	bad := mockprovider.NewServer(mockprovider.Behavior{APIKey: "sk-right-key"})
+	defer bad.Close()
+
+	targets, err := ExpandMatrix(MatrixSpec{
+		Providers: []MatrixProvider{
+			{ProviderID: "good", ProviderName: "Good", BaseURL: good.BaseURL(mockprovider.OpenAI), Protocol: "openai"},
+			{ProviderID: "bad", ProviderName: "Bad", BaseURL: bad.BaseURL(mockprovider.Anthropic), Protocol: "anthropic"},
+		},
+		Models: []string{"m-1", "m-3"},
+		Keys:   []string{"sk-wrong-key"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewChecker()
+	r := RunMatrix(targets, 2, func(tg MatrixTarget) FullCheckResult {
+		return c.RunFullCheck(tg.BaseURL, tg.APIKey, tg.Model, tg.ProviderID, tg.ProviderName, tg.Protocol)
+	})
+	if len(r.Cells) != 4 {
+		t.Fatalf("结果 %d 项", len(r.Cells))
+	}
+	if r.Cells[0].Status == StatusFailed || r.Cells[1].Verdict != VerdictModelNotAllowed || r.Cells[2].Verdict != VerdictInvalid {
+		t.Errorf("Cells = %+v", r.Cells)
+	}
+	if len(r.ByProvider) != 2 || r.ByProvider[0].Value != "good" || r.ByProvider[0].Label != "Good" || r.ByProvider[0].Success != 1 || r.ByProvider[1].Success != 0 {
+		t.Errorf("ByProvider = %+v", r.ByProvider)
+	}
+	if len(r.ByModel) != 2 || len(r.ByModel[0].Cells) != 2 || len(r.ByKey) != 1 || r.ByKey[0].Total != 4 {
+		t.Errorf("ByModel = %+v, ByKey = %+v", r.ByModel, r.ByKey)
+	}
+
+	pivot, err := MatrixPivotCSV(r, AxisProvider, AxisModel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(pivot), "\n")
+	if len(lines) != 3 || lines[0] != `provider \ model,m-1,m-3` || !strings.HasPrefix(lines[2], "Bad,failed (auth_invalid)") {
+		t.Errorf("透视表 =\n%s", pivot)
+	}
+	if _, err := MatrixPivotCSV(r, AxisModel, AxisModel); err == nil {
+		t.Error("相同维度应返回错误")
+	}
+}
+
+func TestGroupKeysByID(t *testing.T) {
+	targets, err := ExpandMatrix(MatrixSpec{
+		Providers: []MatrixProvider{{ProviderID: "a", BaseURL: "https://a/v1", Protocol: "openai"}},
+		Models:    []string{"m"},
+		Keys:      []string{"sk-aaaa-same", "sk-bbbb-same"}, // 脱敏后相同
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if targets[0].MaskedKey != targets[1].MaskedKey || targets[0].KeyID == targets[1].KeyID {
+		t.Fatalf("展开结果 = %+v", targets)
+	}
+	r := RunMatrix(targets, 1, func(MatrixTarget) FullCheckResult { return FullCheckResult{} })
+	if len(r.ByKey) != 2 || r.ByKey[0].Label == r.ByKey[1].Label || r.ByKey[0].Label != targets[0].MaskedKey {
+		t.Errorf("脱敏相同的 Key 应分开分组并区分展示名: %+v", r.ByKey)
+	}
+}
+
+func TestGroupCellsByProviderID(t *testing.T) {
+	cells := []MatrixCell{
+		{MatrixTarget: MatrixTarget{ProviderID: "relay-1", ProviderName: "Relay", Model: "m"}, Status: StatusSuccess},
+		{MatrixTarget: MatrixTarget{ProviderID: "relay-2", ProviderName: "Relay", Model: "m"}, Status: StatusFailed},
+	}
+	groups := groupCells(cells, AxisProvider)
+	if len(groups) != 2 || groups[0].Value != "relay-1" || groups[1].Label != "Relay" || groups[1].Success != 0 {
+		t.Errorf("同名供应商应按 ID 分组: %+v", groups)
+	}
+}
@@ -16,10 +16,14 @@ type Report struct {
 
 // ReportSummary 报告摘要
 type ReportSummary struct {
-	Total   int `json:"total"`
-	Success int `json:"success"`
-	Failed  int `json:"failed"`
-	Warning int `json:"warning"`
+	Total      int               `json:"total"`
+	Success    int               `json:"success"`
+	Failed     int               `json:"failed"`
+	Warning    int               `json:"warning"`
+	ErrorKinds map[ErrorKind]int `json:"errorKinds,omitempty"` // 失败原因分布
+
+	AssertionsPassed int `json:"assertionsPassed,omitempty"` // 自定义用例断言通过数
+	AssertionsFailed int `json:"assertionsFailed,omitempty"`
 }
 
 // GenerateReport 生成 JSON 报告
@@ -29,6 +33,13 @@ func GenerateReport(results []FullCheckResult) string {
 		allSuccess := true
 		hasFailed := false
 		for _, item := range r.Results {
+			for _, a := range item.Assertions {
+				if a.Passed {
+					summary.AssertionsPassed++
+				} else {
+					summary.AssertionsFailed++
+				}
+			}
 			if item.Status == StatusFailed {
 				hasFailed = true
 				allSuccess = false
@@ -38,6 +49,12 @@ func GenerateReport(results []FullCheckResult) string {
 		}
 		if hasFailed {
 			summary.Failed++
+			if kind := PrimaryErrorKind(r); kind != KindNone {
+				if summary.ErrorKinds == nil {
+					summary.ErrorKinds = make(map[ErrorKind]int)
+				}
+				summary.ErrorKinds[kind]++
+			}
 		} else if allSuccess {
 			summary.Success++
 		} else {
@@ -72,9 +89,17 @@ func GenerateTextSummary(results []FullCheckResult) string {
 				icon = "FAIL"
 			case StatusWarning:
 				icon = "WARN"
+			case StatusSkipped:
+				icon = "SKIP"
+			}
+			if item.ErrorKind != KindNone {
+				icon += ":" + string(item.ErrorKind)
 			}
 			sb.WriteString(fmt.Sprintf("  %-15s [%s] %s (%dms)\n",
 				string(item.Item), icon, item.Message, item.Latency))
+			for _, a := range item.Assertions {
+				sb.WriteString("    " + a.String() + "\n")
+			}
 		}
 		sb.WriteString(fmt.Sprintf("  Total: %dms\n\n", r.TotalLatency))
 	}
@@ -0,0 +1,256 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"pingai/internal/protocol"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBatchConcurrency 批量检测默认同时运行的请求数
+const DefaultBatchConcurrency = 8
+
+// SweepOptions 模型扫描参数
+type SweepOptions struct {
+	Models      []string `json:"models"`      // 指定模型，可不在模型列表中
+	Pattern     string   `json:"pattern"`     // 通配符 (gpt-4*) 或 /正则/，对模型列表筛选
+	Stream      bool     `json:"stream"`      // 同时检测流式输出
+	Concurrency int      `json:"concurrency"` // <= 0 时使用 DefaultBatchConcurrency
+}
+
+// SweepRow 单个模型的扫描结果
+type SweepRow struct {
+	Model     string       `json:"model"`
+	Status    CheckStatus  `json:"status"`
+	Latency   int64        `json:"latency"` // 对话延迟 (ms)
+	TTFT      int64        `json:"ttft"`    // 流式首字延迟 (ms)，未检测流式时为 0
+	ErrorKind ErrorKind    `json:"errorKind,omitempty"`
+	Chat      CheckResult  `json:"chat"`
+	Stream    *CheckResult `json:"stream,omitempty"`
+	Cost      float64      `json:"cost,omitempty"`     // 对话与流式检测的估算费用
+	Currency  string       `json:"currency,omitempty"` // 为空表示价格未知
+}
+
+// SweepSummary 扫描汇总
+type SweepSummary struct {
+	Total   int `json:"total"`
+	Success int `json:"success"`
+	Warning int `json:"warning"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+}
+
+// SweepResult 模型扫描结果：模型 × 状态 × 延迟
+type SweepResult struct {
+	ProviderID   string       `json:"providerID"`
+	ProviderName string       `json:"providerName"`
+	BaseURL      string       `json:"baseURL"`
+	Protocol     string       `json:"protocol"`
+	Listed       []string     `json:"listed"` // 模型列表接口返回的全部模型
+	Rows         []SweepRow   `json:"rows"`
+	Summary      SweepSummary `json:"summary"`
+	StartTime    string       `json:"startTime"`
+	EndTime      string       `json:"endTime"`
+}
+
+// SelectModels 从模型列表中选出要扫描的模型：先取指定模型，再按 pattern 筛选列表；都为空时返回全部
+func SelectModels(listed, models []string, pattern string) ([]string, error) {
+	if len(models) == 0 && pattern == "" {
+		return dedupe(listed), nil
+	}
+	selected := append([]string(nil), models...)
+	if pattern != "" {
+		re, err := compileModelPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range listed {
+			if re.MatchString(m) {
+				selected = append(selected, m)
+			}
+		}
+	}
+	return dedupe(selected), nil
+}
+
+// compileModelPattern /.../ 为正则，其余按通配符 (* ?) 处理，均不区分大小写
+func compileModelPattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile("(?i)" + pattern[1:len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("正则表达式无效: %w", err)
+		}
+		return re, nil
+	}
+	var sb strings.Builder
+	sb.WriteString("(?i)^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+func dedupe(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	return out
+}
+
+// forEachLimit 以最多 limit 个并发执行 fn(0..n-1)
+func forEachLimit(limit, n int, fn func(i int)) {
+	if limit <= 0 {
+		limit = DefaultBatchConcurrency
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() { <-sem; wg.Done() }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// SweepModels 获取模型列表并按选择逐个做轻量对话检测 (可选流式)
+func (c *Checker) SweepModels(ctx context.Context, baseURL, apiKey, providerID, providerName, proto string, opts SweepOptions) (SweepResult, error) {
+	result := SweepResult{
+		ProviderID:   providerID,
+		ProviderName: providerName,
+		BaseURL:      baseURL,
+		Protocol:     proto,
+		StartTime:    time.Now().Format(timeFmt),
+	}
+	adapter := protocol.GetAdapter(protocol.Protocol(proto))
+
+	listResult, listed := c.checkModels(ctx, adapter, baseURL, apiKey)
+	if listed == nil && len(opts.Models) == 0 {
+		return result, fmt.Errorf("%s: %s", listResult.Message, listResult.Detail)
+	}
+	result.Listed = listed
+	models, err := SelectModels(listed, opts.Models, opts.Pattern)
+	if err != nil {
+		return result, err
+	}
+	if len(models) == 0 {
+		return result, fmt.Errorf("没有匹配的模型")
+	}
+
+	result.Rows = make([]SweepRow, len(models))
+	forEachLimit(opts.Concurrency, len(models), func(i int) {
+		model := models[i]
+		row := SweepRow{Model: model}
+		skip := c.unsupported(providerID, model)
+		if reason, ok := skip[CheckChat]; ok {
+			row.Chat = skipped(CheckChat, reason)
+			row.Status = StatusSkipped
+			result.Rows[i] = row
+			return
+		}
+		row.Chat = c.observe(providerID, model, c.checkChat(ctx, adapter, baseURL, apiKey, model))
+		row.Status = row.Chat.Status
+		row.Latency = row.Chat.Latency
+		row.ErrorKind = row.Chat.ErrorKind
+		if reason, ok := skip[CheckStream]; ok && opts.Stream {
+			s := skipped(CheckStream, reason)
+			row.Stream = &s
+		} else if opts.Stream {
+			s := c.observe(providerID, model, c.checkStream(ctx, adapter, baseURL, apiKey, model))
+			row.Stream = &s
+			row.TTFT = s.TTFT
+			if statusRank(s.Status) > statusRank(row.Status) {
+				row.Status = s.Status
+				row.ErrorKind = s.ErrorKind
+			}
+		}
+		if price, ok := c.pricing(providerID, model); ok {
+			row.Currency = price.Currency
+			row.Chat.Cost = ItemCost(row.Chat, price)
+			row.Cost = row.Chat.Cost
+			if row.Stream != nil {
+				row.Stream.Cost = ItemCost(*row.Stream, price)
+				row.Cost += row.Stream.Cost
+			}
+		}
+		result.Rows[i] = row
+	})
+
+	for _, row := range result.Rows {
+		result.Summary.Total++
+		switch row.Status {
+		case StatusSuccess:
+			result.Summary.Success++
+		case StatusWarning:
+			result.Summary.Warning++
+		case StatusSkipped:
+			result.Summary.Skipped++
+		default:
+			result.Summary.Failed++
+		}
+	}
+	result.EndTime = time.Now().Format(timeFmt)
+	return result, nil
+}
+
+// statusRank 状态严重程度，用于合并多项结果
+func statusRank(s CheckStatus) int {
+	switch s {
+	case StatusSuccess, StatusSkipped:
+		return 0
+	case StatusWarning:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// SweepCSV 导出扫描矩阵
+func SweepCSV(r SweepResult) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"model", "status", "chat_latency_ms", "stream_status", "ttft_ms", "error_kind", "message"})
+	for _, row := range r.Rows {
+		streamStatus := ""
+		if row.Stream != nil {
+			streamStatus = string(row.Stream.Status)
+		}
+		msg := row.Chat.Message
+		if row.Chat.Status == StatusSuccess && row.Stream != nil {
+			msg = row.Stream.Message
+		}
+		w.Write([]string{
+			row.Model,
+			string(row.Status),
+			fmt.Sprintf("%d", row.Latency),
+			streamStatus,
+			fmt.Sprintf("%d", row.TTFT),
+			string(row.ErrorKind),
+			msg,
+		})
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
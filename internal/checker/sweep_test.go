@@ -0,0 +1,71 @@
+package checker
+
+import (
+	"context"
+	"pingai/internal/mockprovider"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectModels(t *testing.T) {
+	listed := []string{"gpt-4o", "gpt-4o-mini", "GPT-3.5-turbo", "claude-3-haiku", "openai/gpt-4o"}
+	cases := []struct {
+		models  []string
+		pattern string
+		want    []string
+	}{
+		{nil, "", listed},
+		{nil, "gpt-4o*", []string{"gpt-4o", "gpt-4o-mini"}},
+		{nil, "*gpt-?o", []string{"gpt-4o", "openai/gpt-4o"}},
+		{nil, "/^gpt-\\d/", []string{"gpt-4o", "gpt-4o-mini", "GPT-3.5-turbo"}},
+		{[]string{"o1", "gpt-4o"}, "gpt-4o*", []string{"o1", "gpt-4o", "gpt-4o-mini"}},
+		{nil, "nothing*", []string{}},
+	}
+	for _, c := range cases {
+		got, err := SelectModels(listed, c.models, c.pattern)
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SelectModels(%v, %q) = %v, %v; 期望 %v", c.models, c.pattern, got, err, c.want)
+		}
+	}
+	if _, err := SelectModels(listed, nil, "/[/"); err == nil {
+		t.Error("无效正则应返回错误")
+	}
+}
+
+func TestSweepModels(t *testing.T) {
+	srv := mockprovider.NewServer(mockprovider.Behavior{Models: []string{"m-1", "m-2", "x-1"}, StrictModels: true})
+	defer srv.Close()
+	c := NewChecker()
+	base := srv.BaseURL(mockprovider.OpenAI)
+
+	r, err := c.SweepModels(context.Background(), base, "k", "mock", "Mock", "openai", SweepOptions{Pattern: "m-*", Models: []string{"gone"}, Stream: true, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Listed) != 3 || len(r.Rows) != 3 {
+		t.Fatalf("扫描结果 = %+v", r)
+	}
+	if r.Rows[0].Model != "gone" || r.Rows[0].Status != StatusFailed || r.Rows[0].ErrorKind != KindModelNotFound {
+		t.Errorf("未上架模型 = %+v", r.Rows[0])
+	}
+	for _, row := range r.Rows[1:] {
+		if row.Status != StatusSuccess || row.Stream == nil || row.Stream.Status != StatusSuccess {
+			t.Errorf("%s = %+v", row.Model, row)
+		}
+	}
+	if r.Summary != (SweepSummary{Total: 3, Success: 2, Failed: 1}) {
+		t.Errorf("Summary = %+v", r.Summary)
+	}
+
+	csv, err := SweepCSV(r)
+	if err != nil || strings.Count(csv, "\n") != 4 || !strings.Contains(csv, "gone,failed") {
+		t.Errorf("SweepCSV = %q, %v", csv, err)
+	}
+
+	// 模型列表不可用且未指定模型时无法扫描
+	srv.Set(mockprovider.Behavior{StatusFor: map[mockprovider.Endpoint]int{mockprovider.EndpointModels: 500}})
+	if _, err := c.SweepModels(context.Background(), base, "k", "mock", "Mock", "openai", SweepOptions{}); err == nil {
+		t.Error("模型列表失败时应返回错误")
+	}
+}
@@ -0,0 +1,176 @@
+package checker
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// KeyVerdict API Key 有效性结论
+type KeyVerdict string
+
+const (
+	VerdictValid           KeyVerdict = "valid"
+	VerdictValidLimited    KeyVerdict = "valid_limited"
+	VerdictNoQuota         KeyVerdict = "no_quota"
+	VerdictInvalid         KeyVerdict = "invalid"
+	VerdictRevoked         KeyVerdict = "revoked"
+	VerdictModelNotAllowed KeyVerdict = "model_not_allowed"
+	VerdictNetworkError    KeyVerdict = "network_error"
+)
+
+// IsUsable Key 是否可用 (含受限)
+func (v KeyVerdict) IsUsable() bool {
+	return v == VerdictValid || v == VerdictValidLimited
+}
+
+// KeyResult 单个 Key 的检测结果
+type KeyResult struct {
+	APIKey    string          `json:"apiKey"`
+	MaskedKey string          `json:"maskedKey"`
+	Verdict   KeyVerdict      `json:"verdict"`
+	ErrorKind ErrorKind       `json:"errorKind,omitempty"`
+	Result    FullCheckResult `json:"result"`
+}
+
+// KeySummary 批量 Key 检测汇总
+type KeySummary struct {
+	Total    int                `json:"total"`
+	Usable   int                `json:"usable"`
+	Verdicts map[KeyVerdict]int `json:"verdicts"`
+}
+
+// ClassifyKey 根据完整检测结果判定 Key 有效性
+func ClassifyKey(r FullCheckResult) KeyVerdict {
+	kinds := make(map[ErrorKind]bool)
+	chatOK := false
+	for _, item := range r.Results {
+		// 模型真实性与 Key 本身无关
+		if item.Item == CheckAuthenticity {
+			continue
+		}
+		if item.Item == CheckChat && item.Status == StatusSuccess {
+			chatOK = true
+		}
+		// 连通性检测的认证失败为 warning，同样计入；模型列表失败不影响结论
+		if item.Status == StatusFailed || (item.Item == CheckConnectivity && item.ErrorKind != KindNone) {
+			kinds[item.ErrorKind] = true
+		}
+	}
+
+	// 按严重程度依次判定
+	switch {
+	case kinds[KindAuthInvalid]:
+		return VerdictInvalid
+	case kinds[KindAuthForbidden]:
+		return VerdictRevoked
+	case kinds[KindQuotaExhausted]:
+		return VerdictNoQuota
+	case kinds[KindModelNotFound]:
+		return VerdictModelNotAllowed
+	}
+
+	if len(kinds) == 0 {
+		return VerdictValid
+	}
+	if chatOK || kinds[KindRateLimited] {
+		return VerdictValidLimited
+	}
+	// 对话未成功且无认证类错误，无法确认 Key 本身状态
+	for kind := range kinds {
+		if !kind.IsNetwork() && kind != KindUpstream5xx && kind != KindRegionBlocked && kind != KindUnknown {
+			return VerdictValidLimited
+		}
+	}
+	return VerdictNetworkError
+}
+
+// NewKeyResult 封装单个 Key 的检测结果并给出结论
+func NewKeyResult(apiKey, maskedKey string, r FullCheckResult) KeyResult {
+	return KeyResult{
+		APIKey:    apiKey,
+		MaskedKey: maskedKey,
+		Verdict:   ClassifyKey(r),
+		ErrorKind: PrimaryErrorKind(r),
+		Result:    r,
+	}
+}
+
+// SummarizeKeys 统计各结论数量
+func SummarizeKeys(items []KeyResult) KeySummary {
+	s := KeySummary{Total: len(items), Verdicts: make(map[KeyVerdict]int)}
+	for _, it := range items {
+		s.Verdicts[it.Verdict]++
+		if it.Verdict.IsUsable() {
+			s.Usable++
+		}
+	}
+	return s
+}
+
+// 导出过滤条件
+const (
+	KeyFilterAll     = "all"
+	KeyFilterValid   = "valid"
+	KeyFilterFailing = "failing"
+)
+
+// FilterKeys 按过滤条件筛选 Key，未知的过滤条件返回错误
+func FilterKeys(items []KeyResult, filter string) ([]KeyResult, error) {
+	switch filter {
+	case KeyFilterAll, KeyFilterValid, KeyFilterFailing:
+	default:
+		return nil, fmt.Errorf("不支持的过滤条件: %s", filter)
+	}
+	var out []KeyResult
+	for _, it := range items {
+		switch filter {
+		case KeyFilterValid:
+			if !it.Verdict.IsUsable() {
+				continue
+			}
+		case KeyFilterFailing:
+			if it.Verdict.IsUsable() {
+				continue
+			}
+		}
+		out = append(out, it)
+	}
+	return out, nil
+}
+
+// ExportKeys 导出 Key 列表，format 为 "txt" (每行一个 Key) 或 "csv"
+func ExportKeys(items []KeyResult, filter, format string) (string, error) {
+	items, err := FilterKeys(items, filter)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case "txt":
+		var sb strings.Builder
+		for _, it := range items {
+			sb.WriteString(it.APIKey)
+			sb.WriteString("\n")
+		}
+		return sb.String(), nil
+	case "csv":
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"key", "verdict", "error_kind", "provider", "model", "total_latency_ms"})
+		for _, it := range items {
+			w.Write([]string{
+				it.APIKey,
+				string(it.Verdict),
+				string(it.ErrorKind),
+				it.Result.ProviderID,
+				it.Result.Model,
+				fmt.Sprintf("%d", it.Result.TotalLatency),
+			})
+		}
+		w.Flush()
+		return buf.String(), w.Error()
+	}
+	return "", fmt.Errorf("不支持的导出格式: %s", format)
+}
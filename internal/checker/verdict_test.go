@@ -0,0 +1,74 @@
+package checker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassifyKey(t *testing.T) {
+	ok := func(item CheckItem) CheckResult { return CheckResult{Item: item, Status: StatusSuccess} }
+	fail := func(item CheckItem, kind ErrorKind) CheckResult {
+		return CheckResult{Item: item, Status: StatusFailed, ErrorKind: kind}
+	}
+
+	cases := []struct {
+		name    string
+		results []CheckResult
+		want    KeyVerdict
+	}{
+		{"all ok", []CheckResult{ok(CheckConnectivity), ok(CheckChat), ok(CheckStream)}, VerdictValid},
+		{"models list warning ignored", []CheckResult{ok(CheckChat),
+			{Item: CheckModels, Status: StatusWarning, ErrorKind: KindModelNotFound}}, VerdictValid},
+		{"auth warning", []CheckResult{{Item: CheckConnectivity, Status: StatusWarning, ErrorKind: KindAuthInvalid},
+			fail(CheckChat, KindAuthInvalid)}, VerdictInvalid},
+		{"forbidden", []CheckResult{ok(CheckConnectivity), fail(CheckChat, KindAuthForbidden)}, VerdictRevoked},
+		{"quota", []CheckResult{ok(CheckConnectivity), fail(CheckChat, KindQuotaExhausted)}, VerdictNoQuota},
+		{"model", []CheckResult{ok(CheckConnectivity), fail(CheckChat, KindModelNotFound)}, VerdictModelNotAllowed},
+		{"rate limited", []CheckResult{ok(CheckConnectivity), fail(CheckChat, KindRateLimited)}, VerdictValidLimited},
+		{"stream broken", []CheckResult{ok(CheckChat), fail(CheckStream, KindParseError)}, VerdictValidLimited},
+		{"dns", []CheckResult{fail(CheckConnectivity, KindDNS)}, VerdictNetworkError},
+		{"upstream", []CheckResult{ok(CheckConnectivity), fail(CheckChat, KindUpstream5xx)}, VerdictNetworkError},
+	}
+	for _, c := range cases {
+		if got := ClassifyKey(FullCheckResult{Results: c.results}); got != c.want {
+			t.Errorf("%s: ClassifyKey = %q, 期望 %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSummarizeAndExportKeys(t *testing.T) {
+	items := []KeyResult{
+		{APIKey: "sk-good", Verdict: VerdictValid},
+		{APIKey: "sk-slow", Verdict: VerdictValidLimited},
+		{APIKey: "sk-bad", Verdict: VerdictInvalid, ErrorKind: KindAuthInvalid},
+	}
+
+	s := SummarizeKeys(items)
+	if s.Total != 3 || s.Usable != 2 || s.Verdicts[VerdictInvalid] != 1 {
+		t.Errorf("SummarizeKeys = %+v", s)
+	}
+
+	txt, err := ExportKeys(items, KeyFilterValid, "txt")
+	if err != nil {
+		t.Fatalf("导出 txt 失败: %v", err)
+	}
+	if txt != "sk-good\nsk-slow\n" {
+		t.Errorf("有效 Key 导出 = %q", txt)
+	}
+
+	csv, err := ExportKeys(items, KeyFilterFailing, "csv")
+	if err != nil {
+		t.Fatalf("导出 csv 失败: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "sk-bad,invalid,auth_invalid") {
+		t.Errorf("失败 Key 导出 = %q", csv)
+	}
+
+	if _, err := ExportKeys(items, "vaild", "txt"); err == nil {
+		t.Error("未知过滤条件应返回错误")
+	}
+	if _, err := ExportKeys(items, KeyFilterAll, "xlsx"); err == nil {
+		t.Error("不支持的格式应返回错误")
+	}
+}
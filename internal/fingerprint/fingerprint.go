@@ -0,0 +1,403 @@
+// Package fingerprint 检测模型是否被替换 (如请求 gpt-4o 实际由 gpt-4o-mini 应答)
+//
+// 证据来源：
+//   - 响应及流式分片中回显的模型名与请求是否一致
+//   - 模型自述身份是否属于同一家族
+//   - 固定文本的输入 token 数 (分词器签名)
+//   - 知识截止时间
+//   - 有确定答案的探针题目
+//
+// 后三项需要与参考档案比较，档案通过对可信渠道运行同一组探针采集 (Capture)。
+package fingerprint
+
+import (
+	"context"
+	"fmt"
+	"pingai/internal/protocol"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProbeKind 探针类型
+type ProbeKind string
+
+const (
+	KindIdentity  ProbeKind = "identity"  // 自述模型名
+	KindTokenizer ProbeKind = "tokenizer" // 固定文本的输入 token 数
+	KindCutoff    ProbeKind = "cutoff"    // 知识截止时间
+	KindAnswer    ProbeKind = "answer"    // 有确定答案的题目
+)
+
+// Probe 一条探针
+type Probe struct {
+	ID     string    `json:"id"`
+	Kind   ProbeKind `json:"kind"`
+	Prompt string    `json:"prompt"`
+	Stream bool      `json:"stream"` // 通过流式接口发送，用于检查分片中的模型名
+}
+
+// tokenizerText 混合多语言、emoji、代码和数字，不同分词器的 token 数差异明显
+const tokenizerText = "Tokenizer probe: café naïve Ünïcödé 東京タワー 서울 🤖🧪 " +
+	"`func main() { fmt.Println(\"hi\") }` 3.14159265358979 0x7fffffff " +
+	"supercalifragilisticexpialidocious. Reply with OK only."
+
+// DefaultProbes 默认探针组
+var DefaultProbes = []Probe{
+	{ID: "identity", Kind: KindIdentity, Prompt: "Which AI model are you, exactly? Reply with your model name only.", Stream: true},
+	{ID: "tokenizer", Kind: KindTokenizer, Prompt: tokenizerText},
+	{ID: "cutoff", Kind: KindCutoff, Prompt: "What is your knowledge cutoff date? Reply in YYYY-MM format only."},
+	{ID: "strawberry", Kind: KindAnswer, Prompt: "How many times does the letter r appear in the word strawberry? Reply with the digit only."},
+	{ID: "reverse", Kind: KindAnswer, Prompt: "Reverse the string pingai-7f3k character by character. Reply with the result only."},
+	{ID: "multiply", Kind: KindAnswer, Prompt: "What is 487 * 36? Reply with the number only."},
+}
+
+// Observation 一条探针的观测结果
+type Observation struct {
+	ProbeID      string    `json:"probeID"`
+	Kind         ProbeKind `json:"kind"`
+	Answer       string    `json:"answer"`
+	EchoedModel  string    `json:"echoedModel"`
+	PromptTokens int       `json:"promptTokens"`
+	CompTokens   int       `json:"compTokens"`
+	Latency      int64     `json:"latency"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// Run 并发执行探针，base 提供 BaseURL / APIKey / Model，温度固定为 0
+func Run(ctx context.Context, adapter protocol.Adapter, base protocol.ChatRequest, probes []Probe) []Observation {
+	zero := 0.0
+	out := make([]Observation, len(probes))
+	var wg sync.WaitGroup
+	for i, p := range probes {
+		wg.Add(1)
+		go func(i int, p Probe) {
+			defer wg.Done()
+			req := base
+			req.Messages = []protocol.Message{{Role: "user", Content: p.Prompt}}
+			req.Stream = p.Stream
+			req.Temperature = &zero
+			req.MaxTokens = 64
+
+			start := time.Now()
+			var resp *protocol.ChatResponse
+			var err error
+			if p.Stream {
+				resp, err = adapter.ChatStream(ctx, req, nil)
+			} else {
+				resp, err = adapter.Chat(ctx, req)
+			}
+			o := Observation{ProbeID: p.ID, Kind: p.Kind, Latency: time.Since(start).Milliseconds()}
+			switch {
+			case err != nil:
+				o.Error = err.Error()
+			case resp.Error != "":
+				o.Error = resp.Error
+			default:
+				o.Answer = strings.TrimSpace(resp.Content)
+				o.EchoedModel = resp.Model
+				o.PromptTokens = resp.PromptTokens
+				o.CompTokens = resp.CompTokens
+			}
+			out[i] = o
+		}(i, p)
+	}
+	wg.Wait()
+	return out
+}
+
+// Profile 某个模型的参考档案
+type Profile struct {
+	Model        string            `json:"model"`
+	Cutoff       string            `json:"cutoff,omitempty"`       // YYYY-MM
+	PromptTokens map[string]int    `json:"promptTokens,omitempty"` // tokenizer 探针 -> 输入 token 数
+	Answers      map[string]string `json:"answers,omitempty"`      // answer 探针 -> 归一化后的回答
+	Source       string            `json:"source,omitempty"`       // 采集渠道，如供应商 ID
+	CapturedAt   string            `json:"capturedAt,omitempty"`
+}
+
+// Capture 由可信渠道的观测结果生成参考档案
+func Capture(model, source string, obs []Observation) Profile {
+	p := Profile{
+		Model:        model,
+		PromptTokens: make(map[string]int),
+		Answers:      make(map[string]string),
+		Source:       source,
+		CapturedAt:   time.Now().Format(time.RFC3339),
+	}
+	for _, o := range obs {
+		if o.Error != "" {
+			continue
+		}
+		switch o.Kind {
+		case KindTokenizer:
+			if o.PromptTokens > 0 {
+				p.PromptTokens[o.ProbeID] = o.PromptTokens
+			}
+		case KindCutoff:
+			p.Cutoff = parseCutoff(o.Answer)
+		case KindAnswer:
+			if a := normalizeAnswer(o.Answer); a != "" {
+				p.Answers[o.ProbeID] = a
+			}
+		}
+	}
+	return p
+}
+
+// Verdict 结论
+type Verdict string
+
+const (
+	VerdictGenuine     Verdict = "genuine"     // 与请求的模型一致
+	VerdictSuspicious  Verdict = "suspicious"  // 证据互相矛盾
+	VerdictSubstituted Verdict = "substituted" // 很可能被替换
+	VerdictUnknown     Verdict = "unknown"     // 没有可判定的证据
+)
+
+// Evidence 一条证据
+type Evidence struct {
+	Signal   string `json:"signal"` // echo / identity / tokenizer / cutoff / answer:<探针>
+	Expected string `json:"expected"`
+	Observed string `json:"observed"`
+	Match    bool   `json:"match"`
+	Weight   int    `json:"weight"`
+}
+
+// 各类证据的权重，回显的模型名最直接
+const (
+	weightEcho      = 4
+	weightTokenizer = 2
+	weightIdentity  = 1
+	weightCutoff    = 1
+	weightAnswer    = 1
+)
+
+// Result 检测结论
+type Result struct {
+	Model        string        `json:"model"`
+	Confidence   float64       `json:"confidence"` // 0~1，模型为真的可信度
+	Verdict      Verdict       `json:"verdict"`
+	HasProfile   bool          `json:"hasProfile"`
+	Evidence     []Evidence    `json:"evidence"`
+	Observations []Observation `json:"observations"`
+}
+
+// Evaluate 根据观测结果和参考档案 (可为 nil) 计算可信度
+func Evaluate(model string, obs []Observation, profile *Profile) Result {
+	r := Result{Model: model, HasProfile: profile != nil, Observations: obs, Evidence: []Evidence{}}
+
+	// 回显的模型名，每个不同的名称一条证据
+	seen := make(map[string]bool)
+	for _, o := range obs {
+		if o.EchoedModel == "" || seen[o.EchoedModel] {
+			continue
+		}
+		seen[o.EchoedModel] = true
+		r.Evidence = append(r.Evidence, Evidence{
+			Signal: "echo", Expected: model, Observed: o.EchoedModel,
+			Match: SameModel(model, o.EchoedModel), Weight: weightEcho,
+		})
+	}
+
+	for _, o := range obs {
+		if o.Error != "" {
+			continue
+		}
+		switch o.Kind {
+		case KindIdentity:
+			if family, re := Family(model); re != nil {
+				r.Evidence = append(r.Evidence, Evidence{
+					Signal: "identity", Expected: family, Observed: truncate(o.Answer, 60),
+					Match: re.MatchString(o.Answer), Weight: weightIdentity,
+				})
+			}
+		case KindTokenizer:
+			want, ok := 0, false
+			if profile != nil {
+				want, ok = profile.PromptTokens[o.ProbeID]
+			}
+			if ok && o.PromptTokens > 0 {
+				r.Evidence = append(r.Evidence, Evidence{
+					Signal: "tokenizer", Expected: strconv.Itoa(want), Observed: strconv.Itoa(o.PromptTokens),
+					Match: abs(o.PromptTokens-want) <= 1, Weight: weightTokenizer,
+				})
+			}
+		case KindCutoff:
+			got := parseCutoff(o.Answer)
+			if profile != nil && profile.Cutoff != "" && got != "" {
+				r.Evidence = append(r.Evidence, Evidence{
+					Signal: "cutoff", Expected: profile.Cutoff, Observed: got,
+					Match: monthsBetween(profile.Cutoff, got) <= 3, Weight: weightCutoff,
+				})
+			}
+		case KindAnswer:
+			if profile == nil {
+				continue
+			}
+			if want, ok := profile.Answers[o.ProbeID]; ok {
+				got := normalizeAnswer(o.Answer)
+				r.Evidence = append(r.Evidence, Evidence{
+					Signal: "answer:" + o.ProbeID, Expected: want, Observed: got,
+					Match: got == want, Weight: weightAnswer,
+				})
+			}
+		}
+	}
+
+	matched, total := 0, 0
+	for _, e := range r.Evidence {
+		total += e.Weight
+		if e.Match {
+			matched += e.Weight
+		}
+	}
+	if total == 0 {
+		r.Verdict = VerdictUnknown
+		r.Confidence = 0.5
+		return r
+	}
+	// 拉普拉斯平滑：证据越多结论越确定
+	r.Confidence = float64(matched+1) / float64(total+2)
+	switch {
+	case r.Confidence >= 0.65:
+		r.Verdict = VerdictGenuine
+	case r.Confidence >= 0.4:
+		r.Verdict = VerdictSuspicious
+	default:
+		r.Verdict = VerdictSubstituted
+	}
+	return r
+}
+
+// versionSuffix 模型名末尾的日期或版本后缀，如 -2024-08-06 / -20241022 / -0613 / -latest / -001
+var versionSuffix = regexp.MustCompile(`-(\d{4}-\d{2}-\d{2}|\d{8}|\d{4}|\d{3}|latest|preview|exp)$`)
+
+// SameModel 回显的模型名是否与请求一致，忽略大小写、路由前缀 (openai/) 和日期版本后缀
+func SameModel(requested, echoed string) bool {
+	return BaseModel(requested) == BaseModel(echoed)
+}
+
+// BaseModel 去掉路由前缀和日期版本后缀后的小写模型名，如 openai/gpt-4o-2024-08-06 -> gpt-4o
+func BaseModel(m string) string {
+	m = normalizeModel(m)
+	for {
+		stripped := versionSuffix.ReplaceAllString(m, "")
+		if stripped == m {
+			return m
+		}
+		m = stripped
+	}
+}
+
+func normalizeModel(m string) string {
+	m = strings.ToLower(strings.TrimSpace(m))
+	m = strings.TrimPrefix(m, "models/")
+	if i := strings.LastIndexByte(m, '/'); i >= 0 {
+		m = m[i+1:]
+	}
+	return m
+}
+
+// families 模型名前缀 -> 自述身份应包含的关键词
+var families = []struct {
+	prefixes []string
+	name     string
+	pattern  *regexp.Regexp
+}{
+	{[]string{"gpt", "chatgpt", "o1", "o3", "o4"}, "OpenAI", regexp.MustCompile(`(?i)gpt|openai|chatgpt|\bo[134]\b`)},
+	{[]string{"claude"}, "Anthropic", regexp.MustCompile(`(?i)claude|anthropic`)},
+	{[]string{"gemini", "gemma"}, "Google", regexp.MustCompile(`(?i)gemini|gemma|google`)},
+	{[]string{"deepseek"}, "DeepSeek", regexp.MustCompile(`(?i)deepseek|深度求索`)},
+	{[]string{"qwen", "qwq"}, "Qwen", regexp.MustCompile(`(?i)qwen|qwq|通义|alibaba|阿里`)},
+	{[]string{"glm", "chatglm"}, "GLM", regexp.MustCompile(`(?i)glm|智谱|zhipu`)},
+	{[]string{"moonshot", "kimi"}, "Moonshot", regexp.MustCompile(`(?i)kimi|moonshot|月之暗面`)},
+	{[]string{"doubao"}, "Doubao", regexp.MustCompile(`(?i)doubao|豆包|bytedance|字节`)},
+	{[]string{"baichuan"}, "Baichuan", regexp.MustCompile(`(?i)baichuan|百川`)},
+	{[]string{"yi-"}, "01.AI", regexp.MustCompile(`(?i)\byi\b|01\.ai|零一万物`)},
+	{[]string{"llama", "meta-llama"}, "Meta", regexp.MustCompile(`(?i)llama|meta`)},
+	{[]string{"mistral", "mixtral", "codestral", "ministral"}, "Mistral", regexp.MustCompile(`(?i)mistral|mixtral|codestral`)},
+}
+
+// Family 模型所属家族及自述身份的匹配规则，未知家族返回 nil
+func Family(model string) (string, *regexp.Regexp) {
+	m := normalizeModel(model)
+	for _, f := range families {
+		for _, p := range f.prefixes {
+			if strings.HasPrefix(m, p) {
+				return f.name, f.pattern
+			}
+		}
+	}
+	return "", nil
+}
+
+var cutoffPattern = regexp.MustCompile(`(20\d{2})[-/年.\s]*(\d{1,2})?`)
+
+// parseCutoff 从回答中提取 YYYY-MM，只有年份时记为该年 1 月
+func parseCutoff(answer string) string {
+	m := cutoffPattern.FindStringSubmatch(answer)
+	if m == nil {
+		return ""
+	}
+	month := 1
+	if m[2] != "" {
+		month, _ = strconv.Atoi(m[2])
+	}
+	if month < 1 || month > 12 {
+		month = 1
+	}
+	return fmt.Sprintf("%s-%02d", m[1], month)
+}
+
+func monthsBetween(a, b string) int {
+	parse := func(s string) int {
+		var y, m int
+		fmt.Sscanf(s, "%d-%d", &y, &m)
+		return y*12 + m
+	}
+	return abs(parse(a) - parse(b))
+}
+
+// normalizeAnswer 去除空白、标点和大小写差异
+func normalizeAnswer(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.Trim(s, " .。!！\"'`*")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Summary 按权重排序的证据描述，用于检测项详情
+func (r Result) Summary() string {
+	ev := append([]Evidence(nil), r.Evidence...)
+	sort.SliceStable(ev, func(i, j int) bool { return ev[i].Weight > ev[j].Weight })
+	lines := make([]string, 0, len(ev)+1)
+	for _, e := range ev {
+		icon := "[OK]"
+		if !e.Match {
+			icon = "[FAIL]"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s: 期望 %s, 实际 %s", icon, e.Signal, e.Expected, e.Observed))
+	}
+	if !r.HasProfile {
+		lines = append(lines, "无参考档案，仅比较回显模型名和自述身份")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func truncate(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,92 @@
+package fingerprint
+
+import (
+	"context"
+	"pingai/internal/mockprovider"
+	"pingai/internal/protocol"
+	"testing"
+)
+
+func TestSameModel(t *testing.T) {
+	cases := []struct {
+		requested, echoed string
+		want              bool
+	}{
+		{"gpt-4o", "gpt-4o-2024-08-06", true},
+		{"gpt-4o", "openai/GPT-4o", true},
+		{"claude-3-5-sonnet-latest", "claude-3-5-sonnet-20241022", true},
+		{"gemini-1.5-pro", "models/gemini-1.5-pro-002", true},
+		{"gpt-4o", "gpt-4o-mini", false},
+		{"gpt-4o", "gpt-4o-mini-2024-07-18", false},
+		{"claude-3-opus", "claude-3-haiku-20240307", false},
+	}
+	for _, c := range cases {
+		if got := SameModel(c.requested, c.echoed); got != c.want {
+			t.Errorf("SameModel(%q, %q) = %v, 期望 %v", c.requested, c.echoed, got, c.want)
+		}
+	}
+}
+
+func TestParseCutoff(t *testing.T) {
+	cases := map[string]string{
+		"My knowledge cutoff is 2024-06.":   "2024-06",
+		"训练数据截止到 2023 年 10 月":               "2023-10",
+		"I was trained on data up to 2023.": "2023-01",
+		"I don't know":                      "",
+	}
+	for s, want := range cases {
+		if got := parseCutoff(s); got != want {
+			t.Errorf("parseCutoff(%q) = %q, 期望 %q", s, got, want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	srv := mockprovider.NewServer(mockprovider.Behavior{})
+	defer srv.Close()
+	base := protocol.ChatRequest{BaseURL: srv.BaseURL(mockprovider.OpenAI), APIKey: "k", Model: "gpt-4o"}
+	adapter := protocol.GetAdapter(protocol.ProtocolOpenAI)
+
+	obs := Run(context.Background(), adapter, base, DefaultProbes)
+	for _, o := range obs {
+		if o.Error != "" {
+			t.Fatalf("探针 %s 失败: %s", o.ProbeID, o.Error)
+		}
+	}
+
+	// 无参考档案：只有回显和自述身份
+	r := Evaluate("gpt-4o", obs, nil)
+	if r.Verdict != VerdictGenuine || r.HasProfile {
+		t.Errorf("无档案结论 = %s (%.2f)\n%s", r.Verdict, r.Confidence, r.Summary())
+	}
+
+	profile := Capture("gpt-4o", "mock", obs)
+	if profile.Cutoff != mockprovider.MockCutoff || len(profile.PromptTokens) == 0 || len(profile.Answers) == 0 {
+		t.Fatalf("采集的档案不完整: %+v", profile)
+	}
+	genuine := Evaluate("gpt-4o", obs, &profile)
+	if genuine.Verdict != VerdictGenuine {
+		t.Errorf("同一渠道结论 = %s (%.2f)\n%s", genuine.Verdict, genuine.Confidence, genuine.Summary())
+	}
+
+	// 回显其他模型且分词结果不同
+	srv.Set(mockprovider.Behavior{EchoModel: "gpt-4o-mini", PromptTokens: 7})
+	swapped := Evaluate("gpt-4o", Run(context.Background(), adapter, base, DefaultProbes), &profile)
+	if swapped.Verdict == VerdictGenuine || swapped.Confidence >= genuine.Confidence {
+		t.Errorf("替换后结论 = %s (%.2f)\n%s", swapped.Verdict, swapped.Confidence, swapped.Summary())
+	}
+	mismatched := map[string]bool{}
+	for _, e := range swapped.Evidence {
+		if !e.Match {
+			mismatched[e.Signal] = true
+		}
+	}
+	if !mismatched["echo"] || !mismatched["tokenizer"] {
+		t.Errorf("证据应包含回显和分词不一致: %+v", swapped.Evidence)
+	}
+
+	// 没有任何证据时无法判断
+	if r := Evaluate("unknown-model", nil, nil); r.Verdict != VerdictUnknown {
+		t.Errorf("无证据结论 = %s", r.Verdict)
+	}
+}
@@ -0,0 +1,160 @@
+package keys
+
+import (
+	"pingai/internal/provider"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Candidate 从文本中提取出的候选 Key
+type Candidate struct {
+	Key      string   `json:"key"`
+	Masked   string   `json:"masked"`
+	Provider string   `json:"provider"` // 推测的供应商 ID，无法判断时为空
+	Line     int      `json:"line"`     // 首次出现的行号 (从 1 开始)
+	Stored   bool     `json:"stored"`   // 是否已保存在配置中
+	StoredIn []string `json:"storedIn"` // 已保存该 Key 的供应商 ID
+	EnvName  string   `json:"envName"`  // .env 中的变量名
+}
+
+// ParseResult 提取结果
+type ParseResult struct {
+	Keys       []Candidate `json:"keys"`
+	Duplicates int         `json:"duplicates"`
+}
+
+// keyPattern Key 格式规则，越具体的规则越靠前
+type keyPattern struct {
+	provider string
+	re       *regexp.Regexp
+}
+
+var patterns = []keyPattern{
+	{"anthropic", regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_\-]{20,}`)},
+	{"openrouter", regexp.MustCompile(`\bsk-or-v1-[A-Za-z0-9]{32,}`)},
+	{"openai", regexp.MustCompile(`\bsk-(?:proj|svcacct|admin)-[A-Za-z0-9_\-]{20,}`)},
+	{"", regexp.MustCompile(`\bsk-[A-Za-z0-9]{20,}`)},
+	{"gemini", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}`)},
+	{"groq", regexp.MustCompile(`\bgsk_[A-Za-z0-9]{20,}`)},
+	{"", regexp.MustCompile(`\bxai-[A-Za-z0-9]{20,}`)}, // xAI 不在预设中，只提取不推测供应商
+	{"zhipu", regexp.MustCompile(`\b[a-f0-9]{32}\.[A-Za-z0-9]{16}\b`)},
+}
+
+// envLine 匹配 .env 风格的 NAME=value 行
+var envLine = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*[=:]`)
+
+// envHints 环境变量名中的关键字 -> 供应商 ID (预设 ID 之外的别名)
+var envHints = map[string]string{
+	"CLAUDE":    "anthropic",
+	"GOOGLE":    "gemini",
+	"DASHSCOPE": "qwen",
+	"ARK":       "doubao",
+	"VOLC":      "doubao",
+	"GLM":       "zhipu",
+	"BIGMODEL":  "zhipu",
+	"KIMI":      "moonshot",
+	"YI":        "lingyiwanwu",
+}
+
+// Extract 从任意文本 (.env / CSV / 纯文本) 中提取候选 Key 并去重
+func Extract(text string) ParseResult {
+	var result ParseResult
+	seen := make(map[string]int)
+
+	for lineNo, line := range strings.Split(text, "\n") {
+		envName := ""
+		if m := envLine.FindStringSubmatch(line); m != nil {
+			envName = m[1]
+		}
+
+		for _, c := range extractLine(line) {
+			if idx, ok := seen[c.key]; ok {
+				result.Duplicates++
+				// 后出现的 .env 变量名可补充供应商推测
+				if result.Keys[idx].Provider == "" && envName != "" {
+					result.Keys[idx].Provider = providerFromEnv(envName)
+				}
+				continue
+			}
+			p := c.provider
+			if p == "" && envName != "" {
+				p = providerFromEnv(envName)
+			}
+			seen[c.key] = len(result.Keys)
+			result.Keys = append(result.Keys, Candidate{
+				Key:      c.key,
+				Masked:   Mask(c.key),
+				Provider: p,
+				Line:     lineNo + 1,
+				EnvName:  envName,
+			})
+		}
+	}
+	return result
+}
+
+type match struct {
+	key      string
+	provider string
+	start    int
+}
+
+// extractLine 提取单行中的 Key，已被更具体规则命中的区间不再重复匹配
+func extractLine(line string) []match {
+	var out []match
+	var taken [][2]int
+	for _, p := range patterns {
+		for _, loc := range p.re.FindAllStringIndex(line, -1) {
+			overlap := false
+			for _, t := range taken {
+				if loc[0] < t[1] && t[0] < loc[1] {
+					overlap = true
+					break
+				}
+			}
+			if overlap {
+				continue
+			}
+			taken = append(taken, [2]int{loc[0], loc[1]})
+			out = append(out, match{key: line[loc[0]:loc[1]], provider: p.provider, start: loc[0]})
+		}
+	}
+	// 保持行内出现顺序
+	sort.Slice(out, func(i, j int) bool { return out[i].start < out[j].start })
+	return out
+}
+
+// providerFromEnv 根据变量名推测供应商，例如 DEEPSEEK_API_KEY -> deepseek
+func providerFromEnv(name string) string {
+	upper := strings.ToUpper(name)
+	for _, p := range provider.GetPresets() {
+		if strings.Contains(upper, strings.ToUpper(p.ID)) {
+			return p.ID
+		}
+	}
+	for _, part := range strings.Split(upper, "_") {
+		if id, ok := envHints[part]; ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// MarkStored 标记已保存的 Key，owners 为 Key -> 供应商 ID 列表
+func MarkStored(r *ParseResult, owners map[string][]string) {
+	for i := range r.Keys {
+		if ids, ok := owners[r.Keys[i].Key]; ok && len(ids) > 0 {
+			r.Keys[i].Stored = true
+			r.Keys[i].StoredIn = ids
+		}
+	}
+}
+
+// Mask 脱敏 Key，保留前3后4位
+func Mask(key string) string {
+	if len(key) <= 8 {
+		return "***"
+	}
+	return key[:3] + "..." + key[len(key)-4:]
+}
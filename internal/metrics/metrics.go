@@ -0,0 +1,231 @@
+// Package metrics 以 Prometheus 文本格式导出检测指标
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"pingai/internal/checker"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets 延迟直方图的桶上限 (秒)
+var latencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// seriesKey 供应商/模型/检测项
+type seriesKey struct {
+	provider, model, item string
+}
+
+type failureKey struct {
+	seriesKey
+	kind string
+}
+
+type series struct {
+	up        float64
+	latency   float64 // 秒
+	ttft      float64 // 秒，0 表示无
+	timestamp float64 // Unix 秒
+	total     uint64
+	buckets   []uint64 // 与 latencyBuckets 对应的累计计数
+	sum       float64
+	count     uint64
+}
+
+// Registry 保存检测指标，可作为 checker.Observer 使用
+type Registry struct {
+	mu       sync.Mutex
+	series   map[seriesKey]*series
+	failures map[failureKey]uint64
+	now      func() time.Time
+}
+
+// NewRegistry 创建指标仓库
+func NewRegistry() *Registry {
+	return &Registry{
+		series:   make(map[seriesKey]*series),
+		failures: make(map[failureKey]uint64),
+		now:      time.Now,
+	}
+}
+
+// Observe 记录一个检测项结果，签名与 checker.Observer 一致
+func (r *Registry) Observe(providerID, model string, res checker.CheckResult) {
+	key := seriesKey{provider: providerID, model: model, item: string(res.Item)}
+	latency := float64(res.Latency) / 1000
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.series[key]
+	if !ok {
+		s = &series{buckets: make([]uint64, len(latencyBuckets))}
+		r.series[key] = s
+	}
+	s.up = 0
+	if res.Status != checker.StatusFailed {
+		s.up = 1
+	}
+	s.latency = latency
+	s.ttft = float64(res.TTFT) / 1000
+	s.timestamp = float64(r.now().UnixMilli()) / 1000
+	s.total++
+
+	for i, le := range latencyBuckets {
+		if latency <= le {
+			s.buckets[i]++
+		}
+	}
+	s.sum += latency
+	s.count++
+
+	if res.Status == checker.StatusFailed {
+		kind := string(res.ErrorKind)
+		if kind == "" {
+			kind = string(checker.KindUnknown)
+		}
+		r.failures[failureKey{seriesKey: key, kind: kind}]++
+	}
+}
+
+// WriteTo 按 Prometheus 文本格式输出全部指标
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	keys := make([]seriesKey, 0, len(r.series))
+	for k := range r.series {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].less(keys[j]) })
+	failKeys := make([]failureKey, 0, len(r.failures))
+	for k := range r.failures {
+		failKeys = append(failKeys, k)
+	}
+	sort.Slice(failKeys, func(i, j int) bool {
+		if failKeys[i].seriesKey != failKeys[j].seriesKey {
+			return failKeys[i].seriesKey.less(failKeys[j].seriesKey)
+		}
+		return failKeys[i].kind < failKeys[j].kind
+	})
+
+	var b strings.Builder
+	gauge := func(name, help string, value func(*series) (float64, bool)) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		for _, k := range keys {
+			if v, ok := value(r.series[k]); ok {
+				fmt.Fprintf(&b, "%s%s %s\n", name, k.labels(), formatFloat(v))
+			}
+		}
+	}
+
+	gauge("pingai_check_up", "Whether the last check of the item passed (1) or failed (0).",
+		func(s *series) (float64, bool) { return s.up, true })
+	gauge("pingai_check_last_latency_seconds", "Latency of the last check of the item.",
+		func(s *series) (float64, bool) { return s.latency, true })
+	gauge("pingai_check_last_ttft_seconds", "Time to first token of the last streaming check.",
+		func(s *series) (float64, bool) { return s.ttft, s.ttft > 0 })
+	gauge("pingai_check_last_timestamp_seconds", "Unix time of the last check of the item.",
+		func(s *series) (float64, bool) { return s.timestamp, true })
+
+	b.WriteString("# HELP pingai_checks_total Number of checks run.\n# TYPE pingai_checks_total counter\n")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "pingai_checks_total%s %d\n", k.labels(), r.series[k].total)
+	}
+
+	b.WriteString("# HELP pingai_check_failures_total Number of failed checks by error kind.\n# TYPE pingai_check_failures_total counter\n")
+	for _, k := range failKeys {
+		fmt.Fprintf(&b, "pingai_check_failures_total%s %d\n", k.labels(`error_kind`, k.kind), r.failures[k])
+	}
+
+	b.WriteString("# HELP pingai_check_latency_seconds Check latency distribution.\n# TYPE pingai_check_latency_seconds histogram\n")
+	for _, k := range keys {
+		s := r.series[k]
+		for i, le := range latencyBuckets {
+			fmt.Fprintf(&b, "pingai_check_latency_seconds_bucket%s %d\n", k.labels("le", formatFloat(le)), s.buckets[i])
+		}
+		fmt.Fprintf(&b, "pingai_check_latency_seconds_bucket%s %d\n", k.labels("le", "+Inf"), s.count)
+		fmt.Fprintf(&b, "pingai_check_latency_seconds_sum%s %s\n", k.labels(), formatFloat(s.sum))
+		fmt.Fprintf(&b, "pingai_check_latency_seconds_count%s %d\n", k.labels(), s.count)
+	}
+	r.mu.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP 实现 /metrics
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+func (k seriesKey) less(o seriesKey) bool {
+	if k.provider != o.provider {
+		return k.provider < o.provider
+	}
+	if k.model != o.model {
+		return k.model < o.model
+	}
+	return k.item < o.item
+}
+
+// labels 生成标签字符串，extra 为额外的 name, value 对
+func (k seriesKey) labels(extra ...string) string {
+	pairs := []string{"provider", k.provider, "model", k.model, "item", k.item}
+	pairs = append(pairs, extra...)
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, pairs[i]+`="`+escapeLabel(pairs[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Server 指标 HTTP 监听
+type Server struct {
+	srv *http.Server
+	ln  net.Listener
+}
+
+// Listen 在 addr 上启动 /metrics 监听
+func Listen(addr string, reg *Registry) (*Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", reg)
+	s := &Server{
+		srv: &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second},
+		ln:  ln,
+	}
+	go s.srv.Serve(ln)
+	return s, nil
+}
+
+// Addr 实际监听地址
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Close 停止监听
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.srv.Shutdown(ctx)
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"pingai/internal/checker"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistryExposition(t *testing.T) {
+	reg := NewRegistry()
+	reg.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	reg.Observe("groq", "llama", checker.CheckResult{Item: checker.CheckStream, Status: checker.StatusSuccess, Latency: 800, TTFT: 120})
+	reg.Observe("groq", "llama", checker.CheckResult{Item: checker.CheckStream, Status: checker.StatusFailed, Latency: 3000, ErrorKind: checker.KindRateLimited})
+	reg.Observe("groq", "llama", checker.CheckResult{Item: checker.CheckChat, Status: checker.StatusFailed, Latency: 50})
+	reg.Observe(`we"ird`, "m", checker.CheckResult{Item: checker.CheckChat, Status: checker.StatusWarning, Latency: 100})
+
+	var b strings.Builder
+	reg.WriteTo(&b)
+	out := b.String()
+
+	want := []string{
+		`# TYPE pingai_check_up gauge`,
+		`pingai_check_up{provider="groq",model="llama",item="stream"} 0`,
+		`pingai_check_up{provider="we\"ird",model="m",item="chat"} 1`,
+		`pingai_check_last_latency_seconds{provider="groq",model="llama",item="stream"} 3`,
+		`pingai_check_last_timestamp_seconds{provider="groq",model="llama",item="stream"} 1.7e+09`,
+		`pingai_checks_total{provider="groq",model="llama",item="stream"} 2`,
+		`pingai_check_failures_total{provider="groq",model="llama",item="chat",error_kind="unknown"} 1`,
+		`pingai_check_failures_total{provider="groq",model="llama",item="stream",error_kind="rate_limited"} 1`,
+		`# TYPE pingai_check_latency_seconds histogram`,
+		`pingai_check_latency_seconds_bucket{provider="groq",model="llama",item="stream",le="1"} 1`,
+		`pingai_check_latency_seconds_bucket{provider="groq",model="llama",item="stream",le="2.5"} 1`,
+		`pingai_check_latency_seconds_bucket{provider="groq",model="llama",item="stream",le="5"} 2`,
+		`pingai_check_latency_seconds_bucket{provider="groq",model="llama",item="stream",le="+Inf"} 2`,
+		`pingai_check_latency_seconds_sum{provider="groq",model="llama",item="stream"} 3.8`,
+		`pingai_check_latency_seconds_count{provider="groq",model="llama",item="stream"} 2`,
+	}
+	for _, line := range want {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("缺少指标行: %s", line)
+		}
+	}
+	// 最后一次流式检测失败，TTFT 为 0 时不输出
+	if strings.Contains(out, `pingai_check_last_ttft_seconds{provider="groq",model="llama",item="stream"}`) {
+		t.Error("TTFT 为 0 时不应输出")
+	}
+}
+
+func TestListen(t *testing.T) {
+	reg := NewRegistry()
+	var _ checker.Observer = reg.Observe
+
+	srv, err := Listen("127.0.0.1:0", reg)
+	if err != nil {
+		t.Fatalf("Listen 失败: %v", err)
+	}
+	defer srv.Close()
+
+	reg.Observe("openai", "gpt-4o", checker.CheckResult{Item: checker.CheckChat, Status: checker.StatusSuccess, Latency: 200})
+	resp, err := http.Get("http://" + srv.Addr() + "/metrics")
+	if err != nil {
+		t.Fatalf("请求 /metrics 失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(string(body), `pingai_check_up{provider="openai",model="gpt-4o",item="chat"} 1`) {
+		t.Errorf("响应缺少指标: %s", body)
+	}
+}
@@ -0,0 +1,391 @@
+// Package mockprovider 模拟 OpenAI / Anthropic / Gemini 接口，用于离线测试和演示
+//
+// 三种协议挂在同一个服务的不同前缀下，BaseURL 返回对应协议的地址：
+//
+//	/openai/v1     OpenAI 兼容 (/chat/completions, /models)
+//	/anthropic/v1  Anthropic (/messages, /models)
+//	/gemini/v1beta Gemini (/models, /models/{model}:generateContent)
+package mockprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 协议，与 protocol.Protocol 取值一致
+const (
+	OpenAI    = "openai"
+	Anthropic = "anthropic"
+	Gemini    = "gemini"
+)
+
+var prefixes = map[string]string{
+	OpenAI:    "/openai/v1",
+	Anthropic: "/anthropic/v1",
+	Gemini:    "/gemini/v1beta",
+}
+
+// Endpoint 接口类型
+type Endpoint string
+
+const (
+	EndpointModels Endpoint = "models"
+	EndpointChat   Endpoint = "chat"
+	EndpointStream Endpoint = "stream"
+)
+
+// DefaultModel 未配置 Models 时模型列表中的唯一模型
+const DefaultModel = "mock-model"
+
+// Behavior 可编排的响应行为，零值为一切正常
+type Behavior struct {
+	Reply        string   // 固定回复，为空时按提示词给出合理回复
+	Models       []string // 模型列表，为空时为 [DefaultModel]
+	EchoModel    string   // 响应中回显的模型名，为空时回显请求的模型
+	APIKey       string   // 非空时只接受该 Key，其余返回 401
+	StrictModels bool     // 请求的模型不在 Models 中时返回 404
+
+	Status    int              // 所有接口返回该状态码 (如 401/429/500)
+	StatusFor map[Endpoint]int // 按接口覆盖 Status
+	Malformed bool             // 返回 200 但响应体不是合法 JSON
+
+	TTFT            time.Duration // 首个分片 (非流式为整个响应) 前的延迟
+	ChunkDelay      time.Duration // 分片之间的延迟
+	DisconnectAfter int           // 大于 0 时发送该数量的分片后断开连接
+	PromptTokens    int           // 用量中的输入 token 数，为 0 时按消息长度估算
+}
+
+// Mock 模拟供应商，实现 http.Handler，行为可随时修改
+type Mock struct {
+	mu       sync.Mutex
+	behavior Behavior
+	calls    map[Endpoint]int
+}
+
+// New 创建模拟供应商
+func New(b Behavior) *Mock {
+	return &Mock{behavior: b, calls: make(map[Endpoint]int)}
+}
+
+// Set 替换响应行为
+func (m *Mock) Set(b Behavior) {
+	m.mu.Lock()
+	m.behavior = b
+	m.mu.Unlock()
+}
+
+// Calls 某接口被调用的次数
+func (m *Mock) Calls(e Endpoint) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls[e]
+}
+
+// BaseURL 返回服务地址下某协议的 Base URL
+func BaseURL(serverURL, protocol string) string {
+	prefix, ok := prefixes[protocol]
+	if !ok {
+		prefix = prefixes[OpenAI]
+	}
+	return strings.TrimSuffix(serverURL, "/") + prefix
+}
+
+// Server httptest 服务
+type Server struct {
+	*httptest.Server
+	*Mock
+}
+
+// NewServer 启动 httptest 服务，测试结束后调用 Close
+func NewServer(b Behavior) *Server {
+	m := New(b)
+	return &Server{Server: httptest.NewServer(m), Mock: m}
+}
+
+// BaseURL 某协议的 Base URL
+func (s *Server) BaseURL(protocol string) string {
+	return BaseURL(s.URL, protocol)
+}
+
+// Listener 在真实地址上运行的模拟供应商，用于界面中的演示供应商
+type Listener struct {
+	*Mock
+	srv *http.Server
+	ln  net.Listener
+}
+
+// Listen 在 addr 上启动模拟供应商
+func Listen(addr string, b Behavior) (*Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	m := New(b)
+	l := &Listener{Mock: m, srv: &http.Server{Handler: m, ReadHeaderTimeout: 10 * time.Second}, ln: ln}
+	go l.srv.Serve(ln)
+	return l, nil
+}
+
+// URL 服务地址
+func (l *Listener) URL() string {
+	return "http://" + l.ln.Addr().String()
+}
+
+// Close 停止服务
+func (l *Listener) Close() error {
+	return l.srv.Close()
+}
+
+// request 解析后的请求
+type request struct {
+	protocol string
+	endpoint Endpoint
+	model    string
+	apiKey   string
+	messages []message
+}
+
+type message struct {
+	Role    string
+	Content string
+}
+
+func (m *Mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, ok := parseRequest(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	m.mu.Lock()
+	b := m.behavior
+	m.calls[req.endpoint]++
+	m.mu.Unlock()
+
+	models := b.Models
+	if len(models) == 0 {
+		models = []string{DefaultModel}
+	}
+
+	status := b.Status
+	if s, ok := b.StatusFor[req.endpoint]; ok {
+		status = s
+	}
+	switch {
+	case b.APIKey != "" && req.apiKey != b.APIKey:
+		writeError(w, req.protocol, http.StatusUnauthorized)
+		return
+	case status != 0 && status != http.StatusOK:
+		writeError(w, req.protocol, status)
+		return
+	case b.StrictModels && req.endpoint != EndpointModels && !contains(models, req.model):
+		writeError(w, req.protocol, http.StatusNotFound)
+		return
+	}
+
+	if req.endpoint == EndpointModels {
+		if b.Malformed {
+			writeMalformed(w)
+			return
+		}
+		writeModels(w, req.protocol, models)
+		return
+	}
+
+	if b.TTFT > 0 {
+		select {
+		case <-time.After(b.TTFT):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	echo := b.EchoModel
+	if echo == "" {
+		echo = req.model
+	}
+	reply := b.Reply
+	if reply == "" {
+		reply = defaultReply(req.messages, echo)
+	}
+	promptTokens := b.PromptTokens
+	if promptTokens == 0 {
+		for _, msg := range req.messages {
+			promptTokens += estimateTokens(msg.Content)
+		}
+	}
+	usage := usage{prompt: promptTokens, completion: estimateTokens(reply)}
+
+	if req.endpoint == EndpointStream {
+		m.stream(w, r, req.protocol, echo, reply, usage, b)
+		return
+	}
+	if b.Malformed {
+		writeMalformed(w)
+		return
+	}
+	writeJSON(w, chatBody(req.protocol, echo, reply, usage))
+}
+
+// parseRequest 根据路径识别协议和接口
+func parseRequest(r *http.Request) (request, bool) {
+	var req request
+	var rest string
+	for proto, prefix := range prefixes {
+		if after, ok := strings.CutPrefix(r.URL.Path, prefix); ok {
+			req.protocol, rest = proto, after
+			break
+		}
+	}
+	if req.protocol == "" {
+		return req, false
+	}
+
+	switch req.protocol {
+	case OpenAI:
+		req.apiKey = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	case Anthropic:
+		req.apiKey = r.Header.Get("x-api-key")
+	case Gemini:
+		req.apiKey = r.URL.Query().Get("key")
+	}
+
+	switch {
+	case r.Method == http.MethodGet && rest == "/models":
+		req.endpoint = EndpointModels
+		return req, true
+	case r.Method != http.MethodPost:
+		return req, false
+	}
+
+	var body struct {
+		Model    string `json:"model"`
+		Stream   bool   `json:"stream"`
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+		Contents []struct {
+			Role  string `json:"role"`
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"contents"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	for _, msg := range body.Messages {
+		req.messages = append(req.messages, message{Role: msg.Role, Content: msg.Content})
+	}
+	for _, c := range body.Contents {
+		var text strings.Builder
+		for _, p := range c.Parts {
+			text.WriteString(p.Text)
+		}
+		req.messages = append(req.messages, message{Role: c.Role, Content: text.String()})
+	}
+
+	switch req.protocol {
+	case OpenAI:
+		if rest != "/chat/completions" {
+			return req, false
+		}
+	case Anthropic:
+		if rest != "/messages" {
+			return req, false
+		}
+	case Gemini:
+		// /models/{model}:generateContent 或 :streamGenerateContent
+		name, action, ok := strings.Cut(strings.TrimPrefix(rest, "/models/"), ":")
+		if !ok || !strings.HasPrefix(rest, "/models/") {
+			return req, false
+		}
+		body.Model = name
+		switch action {
+		case "generateContent":
+		case "streamGenerateContent":
+			body.Stream = true
+		default:
+			return req, false
+		}
+	}
+	req.model = body.Model
+	req.endpoint = EndpointChat
+	if body.Stream {
+		req.endpoint = EndpointStream
+	}
+	return req, true
+}
+
+// MockCutoff 知识截止时间探针的回复
+const MockCutoff = "2024-06"
+
+// defaultReply 针对检测用的提示词给出合理回复，询问身份时回答 model
+func defaultReply(msgs []message, model string) string {
+	if len(msgs) == 0 {
+		return "OK"
+	}
+	last := msgs[len(msgs)-1].Content
+	switch {
+	case strings.Contains(last, "What number"):
+		for _, msg := range msgs {
+			if strings.Contains(msg.Content, "42") {
+				return "You asked me to remember 42."
+			}
+		}
+		return "I don't know."
+	case strings.Contains(last, "Count from 1 to 5"):
+		return "1, 2, 3, 4, 5"
+	case strings.Contains(last, "Which AI model are you"):
+		return model
+	case strings.Contains(last, "knowledge cutoff"):
+		return MockCutoff
+	}
+	return "OK"
+}
+
+// estimateTokens 粗略估算 token 数 (约 4 字符一个)
+func estimateTokens(s string) int {
+	if s == "" {
+		return 0
+	}
+	return (len(s) + 3) / 4
+}
+
+// splitChunks 将回复切成若干流式分片
+func splitChunks(s string) []string {
+	var chunks []string
+	for len(s) > 0 {
+		n := 3
+		if n > len(s) {
+			n = len(s)
+		}
+		chunks = append(chunks, s[:n])
+		s = s[n:]
+	}
+	return chunks
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeMalformed(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, `{"choices": [ {"message": `)
+}
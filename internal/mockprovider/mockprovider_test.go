@@ -0,0 +1,47 @@
+package mockprovider
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRoutingAndCalls(t *testing.T) {
+	l, err := Listen("127.0.0.1:0", Behavior{EchoModel: "gpt-4o-mini"})
+	if err != nil {
+		t.Fatalf("Listen 失败: %v", err)
+	}
+	defer l.Close()
+
+	post := func(path, body string) (int, string) {
+		resp, err := http.Post(l.URL()+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("请求 %s 失败: %v", path, err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(b)
+	}
+
+	code, body := post("/openai/v1/chat/completions", `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)
+	if code != http.StatusOK || !strings.Contains(body, `"model":"gpt-4o-mini"`) {
+		t.Errorf("EchoModel 响应 = %d %s", code, body)
+	}
+	code, body = post("/gemini/v1beta/models/gemini-pro:streamGenerateContent?alt=sse", `{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`)
+	if code != http.StatusOK || !strings.Contains(body, "data: ") {
+		t.Errorf("Gemini 流式响应 = %d %s", code, body)
+	}
+	if code, _ := post("/unknown", `{}`); code != http.StatusNotFound {
+		t.Errorf("未知路径 = %d", code)
+	}
+	if l.Calls(EndpointChat) != 1 || l.Calls(EndpointStream) != 1 || l.Calls(EndpointModels) != 0 {
+		t.Errorf("调用次数 chat=%d stream=%d", l.Calls(EndpointChat), l.Calls(EndpointStream))
+	}
+
+	l.Set(Behavior{Status: http.StatusTooManyRequests})
+	code, body = post("/anthropic/v1/messages", `{"model":"claude"}`)
+	if code != http.StatusTooManyRequests || !strings.Contains(body, "rate_limit_error") {
+		t.Errorf("Anthropic 429 = %d %s", code, body)
+	}
+}
@@ -0,0 +1,203 @@
+package mockprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type usage struct {
+	prompt, completion int
+}
+
+// errorCodes 状态码 -> 各协议错误体中的错误码
+var errorCodes = map[int]struct{ openai, anthropic, gemini string }{
+	http.StatusUnauthorized:        {"invalid_api_key", "authentication_error", "UNAUTHENTICATED"},
+	http.StatusForbidden:           {"permission_denied", "permission_error", "PERMISSION_DENIED"},
+	http.StatusNotFound:            {"model_not_found", "not_found_error", "NOT_FOUND"},
+	http.StatusTooManyRequests:     {"rate_limit_exceeded", "rate_limit_error", "RESOURCE_EXHAUSTED"},
+	http.StatusInternalServerError: {"server_error", "api_error", "INTERNAL"},
+	529:                            {"server_error", "overloaded_error", "UNAVAILABLE"},
+}
+
+// writeError 按协议格式返回错误
+func writeError(w http.ResponseWriter, protocol string, status int) {
+	codes, ok := errorCodes[status]
+	if !ok {
+		codes = errorCodes[http.StatusInternalServerError]
+	}
+	message := fmt.Sprintf("mock error (HTTP %d)", status)
+
+	var body any
+	switch protocol {
+	case Anthropic:
+		body = map[string]any{
+			"type":  "error",
+			"error": map[string]string{"type": codes.anthropic, "message": message},
+		}
+	case Gemini:
+		body = map[string]any{
+			"error": map[string]any{"code": status, "message": message, "status": codes.gemini},
+		}
+	default:
+		body = map[string]any{
+			"error": map[string]string{"message": message, "type": codes.openai, "code": codes.openai},
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeModels(w http.ResponseWriter, protocol string, models []string) {
+	switch protocol {
+	case Gemini:
+		list := make([]map[string]any, len(models))
+		for i, m := range models {
+			list[i] = map[string]any{"name": "models/" + m, "inputTokenLimit": 1048576}
+		}
+		writeJSON(w, map[string]any{"models": list})
+	default:
+		list := make([]map[string]string, len(models))
+		for i, m := range models {
+			list[i] = map[string]string{"id": m, "object": "model", "type": "model"}
+		}
+		writeJSON(w, map[string]any{"object": "list", "data": list})
+	}
+}
+
+// chatBody 非流式响应
+func chatBody(protocol, model, reply string, u usage) any {
+	switch protocol {
+	case Anthropic:
+		return map[string]any{
+			"id":          "msg_mock",
+			"type":        "message",
+			"role":        "assistant",
+			"model":       model,
+			"content":     []map[string]string{{"type": "text", "text": reply}},
+			"stop_reason": "end_turn",
+			"usage":       map[string]int{"input_tokens": u.prompt, "output_tokens": u.completion},
+		}
+	case Gemini:
+		return map[string]any{
+			"candidates": []map[string]any{{
+				"content":      map[string]any{"role": "model", "parts": []map[string]string{{"text": reply}}},
+				"finishReason": "STOP",
+			}},
+			"usageMetadata": map[string]int{"promptTokenCount": u.prompt, "candidatesTokenCount": u.completion},
+			"modelVersion":  model,
+		}
+	default:
+		return map[string]any{
+			"id":      "chatcmpl-mock",
+			"object":  "chat.completion",
+			"created": time.Now().Unix(),
+			"model":   model,
+			"choices": []map[string]any{{
+				"index":         0,
+				"message":       map[string]string{"role": "assistant", "content": reply},
+				"finish_reason": "stop",
+			}},
+			"usage": map[string]int{
+				"prompt_tokens":     u.prompt,
+				"completion_tokens": u.completion,
+				"total_tokens":      u.prompt + u.completion,
+			},
+		}
+	}
+}
+
+// chunkBody 流式分片
+func chunkBody(protocol, model, text string) any {
+	switch protocol {
+	case Anthropic:
+		return map[string]any{
+			"type":  "content_block_delta",
+			"index": 0,
+			"delta": map[string]string{"type": "text_delta", "text": text},
+		}
+	case Gemini:
+		return map[string]any{
+			"candidates": []map[string]any{{
+				"content": map[string]any{"role": "model", "parts": []map[string]string{{"text": text}}},
+			}},
+			"modelVersion": model,
+		}
+	default:
+		return map[string]any{
+			"id":      "chatcmpl-mock",
+			"object":  "chat.completion.chunk",
+			"model":   model,
+			"choices": []map[string]any{{"index": 0, "delta": map[string]string{"content": text}}},
+		}
+	}
+}
+
+// stream 按协议格式输出 SSE，可模拟分片延迟、中途断开和损坏数据
+func (m *Mock) stream(w http.ResponseWriter, r *http.Request, protocol, model, reply string, u usage, b Behavior) {
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event string, v any) {
+		data, _ := json.Marshal(v)
+		if event != "" {
+			fmt.Fprintf(w, "event: %s\n", event)
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	if protocol == Anthropic {
+		send("message_start", map[string]any{
+			"type":    "message_start",
+			"message": map[string]any{"model": model, "usage": map[string]int{"input_tokens": u.prompt}},
+		})
+	}
+	if b.Malformed {
+		fmt.Fprint(w, "data: {\"choices\": [{\"delta\": \n\n")
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return
+	}
+
+	for i, chunk := range splitChunks(reply) {
+		if b.DisconnectAfter > 0 && i >= b.DisconnectAfter {
+			// 中止连接，客户端读到意外 EOF
+			panic(http.ErrAbortHandler)
+		}
+		if i > 0 && b.ChunkDelay > 0 {
+			select {
+			case <-time.After(b.ChunkDelay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		event := ""
+		if protocol == Anthropic {
+			event = "content_block_delta"
+		}
+		send(event, chunkBody(protocol, model, chunk))
+	}
+
+	switch protocol {
+	case Anthropic:
+		send("message_delta", map[string]any{
+			"type":  "message_delta",
+			"delta": map[string]string{"stop_reason": "end_turn"},
+			"usage": map[string]int{"output_tokens": u.completion},
+		})
+		send("message_stop", map[string]string{"type": "message_stop"})
+	case OpenAI:
+		fmt.Fprint(w, "data: [DONE]\n\n")
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
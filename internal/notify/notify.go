@@ -0,0 +1,218 @@
+// Package notify 检测状态变化时通过 Webhook 发送通知
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Kind Webhook 类型，决定消息格式
+type Kind string
+
+const (
+	KindSlack    Kind = "slack"
+	KindFeishu   Kind = "feishu" // 飞书 / Lark
+	KindDingTalk Kind = "dingtalk"
+	KindWeCom    Kind = "wecom"
+	KindDiscord  Kind = "discord"
+	KindGeneric  Kind = "generic"
+)
+
+// Kinds 支持的 Webhook 类型
+var Kinds = []Kind{KindSlack, KindFeishu, KindDingTalk, KindWeCom, KindDiscord, KindGeneric}
+
+// EventType 事件类型
+type EventType string
+
+const (
+	EventDown EventType = "down" // 由正常变为失败
+	EventUp   EventType = "up"   // 由失败恢复
+	EventTest EventType = "test" // 测试发送
+)
+
+// Event 一次状态变化
+type Event struct {
+	Type         EventType `json:"type"`
+	ProviderID   string    `json:"providerID"`
+	ProviderName string    `json:"providerName"`
+	Model        string    `json:"model"`
+	MaskedKey    string    `json:"maskedKey,omitempty"` // 状态按 Key 分别判断
+	Status       string    `json:"status"`
+	ErrorKind    string    `json:"errorKind,omitempty"`
+	Message      string    `json:"message,omitempty"`
+	FailCount    int       `json:"failCount,omitempty"` // 触发通知时的连续失败次数
+	Time         time.Time `json:"time"`
+}
+
+// Webhook 发送目标
+type Webhook struct {
+	Name          string
+	Kind          Kind
+	URL           string
+	Enabled       bool
+	ProviderIDs   []string // 为空表示全部供应商
+	FailThreshold int      // 连续失败多少次后通知，<= 0 视为 1
+	QuietStart    string   // "22:00"
+	QuietEnd      string   // "08:00"
+}
+
+// Matches 是否需要接收该供应商的通知
+func (w Webhook) Matches(providerID string) bool {
+	if len(w.ProviderIDs) == 0 {
+		return true
+	}
+	for _, id := range w.ProviderIDs {
+		if id == providerID {
+			return true
+		}
+	}
+	return false
+}
+
+// Threshold 连续失败阈值
+func (w Webhook) Threshold() int {
+	if w.FailThreshold <= 0 {
+		return 1
+	}
+	return w.FailThreshold
+}
+
+// InQuietHours t 是否处于免打扰时段，支持跨午夜 (如 22:00-08:00)
+func (w Webhook) InQuietHours(t time.Time) bool {
+	start, ok1 := parseClock(w.QuietStart)
+	end, ok2 := parseClock(w.QuietEnd)
+	if !ok1 || !ok2 || start == end {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+	if start < end {
+		return now >= start && now < end
+	}
+	return now >= start || now < end
+}
+
+func parseClock(s string) (int, bool) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// Detect 根据最近的整体状态 (最新在前) 判断是否发生需要通知的状态变化
+// 连续 threshold 次失败且之前为正常时返回 EventDown；
+// 最新一次正常且之前连续 threshold 次失败 (即已发出过失败通知) 时返回 EventUp
+func Detect(statuses []string, threshold int) (EventType, bool) {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	if len(statuses) == 0 {
+		return "", false
+	}
+	failed := func(i int) bool { return statuses[i] == "failed" }
+
+	if failed(0) {
+		if len(statuses) < threshold {
+			return "", false
+		}
+		for i := 0; i < threshold; i++ {
+			if !failed(i) {
+				return "", false
+			}
+		}
+		// 恰好达到阈值时通知一次
+		if len(statuses) > threshold && failed(threshold) {
+			return "", false
+		}
+		return EventDown, true
+	}
+
+	if len(statuses) <= threshold {
+		return "", false
+	}
+	for i := 1; i <= threshold; i++ {
+		if !failed(i) {
+			return "", false
+		}
+	}
+	return EventUp, true
+}
+
+// Notifier 发送 Webhook 通知
+type Notifier struct {
+	Client *http.Client
+}
+
+// New 创建通知器
+func New() *Notifier {
+	return &Notifier{Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Send 向单个 Webhook 发送事件，不检查免打扰和供应商过滤
+func (n *Notifier) Send(ctx context.Context, w Webhook, ev Event) error {
+	payload, err := Payload(w.Kind, ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return checkBody(w.Kind, body)
+}
+
+// checkBody 飞书、钉钉、企业微信在 HTTP 200 中返回错误码
+func checkBody(kind Kind, body []byte) error {
+	switch kind {
+	case KindFeishu, KindDingTalk, KindWeCom:
+	default:
+		return nil
+	}
+	var r struct {
+		Code    *int   `json:"code"`
+		Msg     string `json:"msg"`
+		ErrCode *int   `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if json.Unmarshal(body, &r) != nil {
+		return nil
+	}
+	if r.Code != nil && *r.Code != 0 {
+		return fmt.Errorf("%s 返回错误 %d: %s", kind, *r.Code, r.Msg)
+	}
+	if r.ErrCode != nil && *r.ErrCode != 0 {
+		return fmt.Errorf("%s 返回错误 %d: %s", kind, *r.ErrCode, r.ErrMsg)
+	}
+	return nil
+}
+
+// Dispatch 将事件发送给所有匹配的 Webhook，跳过未启用、免打扰中的目标，返回各目标的错误
+func (n *Notifier) Dispatch(ctx context.Context, hooks []Webhook, ev Event) map[string]error {
+	errs := make(map[string]error)
+	for _, w := range hooks {
+		if !w.Enabled || !w.Matches(ev.ProviderID) || w.InQuietHours(ev.Time) {
+			continue
+		}
+		if err := n.Send(ctx, w, ev); err != nil {
+			errs[w.Name] = err
+		}
+	}
+	return errs
+}
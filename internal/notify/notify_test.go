@@ -0,0 +1,156 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetect(t *testing.T) {
+	cases := []struct {
+		statuses  []string
+		threshold int
+		want      EventType
+	}{
+		{[]string{"failed", "success"}, 1, EventDown},
+		{[]string{"failed"}, 1, EventDown},
+		{[]string{"failed", "failed"}, 1, ""}, // 已通知过
+		{[]string{"failed", "failed", "warning"}, 3, ""},
+		{[]string{"failed", "failed", "failed", "success"}, 3, EventDown},
+		{[]string{"failed", "failed", "failed", "failed"}, 3, ""},
+		{[]string{"success", "failed"}, 1, EventUp},
+		{[]string{"warning", "failed", "failed", "failed"}, 3, EventUp},
+		{[]string{"success", "failed", "failed", "success"}, 3, ""}, // 未达阈值，不发恢复
+		{[]string{"success", "success"}, 1, ""},
+		{nil, 1, ""},
+	}
+	for _, c := range cases {
+		got, _ := Detect(c.statuses, c.threshold)
+		if got != c.want {
+			t.Errorf("Detect(%v, %d) = %q, 期望 %q", c.statuses, c.threshold, got, c.want)
+		}
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	at := func(clock string) time.Time {
+		tm, _ := time.Parse("15:04", clock)
+		return tm
+	}
+	overnight := Webhook{QuietStart: "22:00", QuietEnd: "08:00"}
+	daytime := Webhook{QuietStart: "12:00", QuietEnd: "13:30"}
+
+	checks := []struct {
+		w    Webhook
+		at   string
+		want bool
+	}{
+		{overnight, "23:15", true},
+		{overnight, "07:59", true},
+		{overnight, "08:00", false},
+		{overnight, "12:00", false},
+		{daytime, "12:45", true},
+		{daytime, "13:30", false},
+		{Webhook{}, "03:00", false},
+	}
+	for _, c := range checks {
+		if got := c.w.InQuietHours(at(c.at)); got != c.want {
+			t.Errorf("%s-%s @ %s = %v, 期望 %v", c.w.QuietStart, c.w.QuietEnd, c.at, got, c.want)
+		}
+	}
+}
+
+func TestSendTemplates(t *testing.T) {
+	var got map[string]any
+	reply := `{}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		got = nil
+		json.Unmarshal(body, &got)
+		io.WriteString(w, reply)
+	}))
+	defer srv.Close()
+
+	ev := Event{
+		Type: EventDown, ProviderID: "groq", ProviderName: "Groq", Model: "llama", MaskedKey: "gsk_...abcd",
+		Status: "failed", ErrorKind: "rate_limited", FailCount: 3,
+		Time: time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC),
+	}
+	textOf := map[Kind]func(map[string]any) string{
+		KindSlack:   func(m map[string]any) string { return m["text"].(string) },
+		KindDiscord: func(m map[string]any) string { return m["content"].(string) },
+		KindFeishu: func(m map[string]any) string {
+			return m["content"].(map[string]any)["text"].(string)
+		},
+		KindDingTalk: func(m map[string]any) string {
+			return m["text"].(map[string]any)["content"].(string)
+		},
+		KindWeCom: func(m map[string]any) string {
+			return m["text"].(map[string]any)["content"].(string)
+		},
+		KindGeneric: func(m map[string]any) string {
+			if m["type"] != "down" || m["providerID"] != "groq" || m["errorKind"] != "rate_limited" {
+				t.Errorf("generic 字段 = %v", m)
+			}
+			return m["text"].(string)
+		},
+	}
+
+	n := New()
+	for _, kind := range Kinds {
+		if err := n.Send(context.Background(), Webhook{Kind: kind, URL: srv.URL}, ev); err != nil {
+			t.Errorf("%s: Send 失败: %v", kind, err)
+			continue
+		}
+		text := textOf[kind](got)
+		if !strings.Contains(text, "Groq / llama") || !strings.Contains(text, "连续 3 次") || !strings.Contains(text, "rate_limited") ||
+			!strings.Contains(text, "Key: gsk_...abcd") {
+			t.Errorf("%s: 消息内容 = %q", kind, text)
+		}
+	}
+
+	// 飞书、钉钉在 200 响应中返回错误码
+	reply = `{"errcode":310000,"errmsg":"keywords not in content"}`
+	if err := n.Send(context.Background(), Webhook{Kind: KindDingTalk, URL: srv.URL}, ev); err == nil {
+		t.Error("钉钉错误码应返回错误")
+	}
+	reply = `{"code":0,"msg":"success"}`
+	if err := n.Send(context.Background(), Webhook{Kind: KindFeishu, URL: srv.URL}, ev); err != nil {
+		t.Errorf("飞书成功响应: %v", err)
+	}
+}
+
+func TestDispatchFilters(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if strings.Contains(r.URL.Path, "broken") {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	ev := Event{Type: EventUp, ProviderID: "groq", Time: time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC)}
+	hooks := []Webhook{
+		{Name: "all", URL: srv.URL, Enabled: true},
+		{Name: "disabled", URL: srv.URL},
+		{Name: "other", URL: srv.URL, Enabled: true, ProviderIDs: []string{"openai"}},
+		{Name: "quiet", URL: srv.URL, Enabled: true, QuietStart: "22:00", QuietEnd: "07:00"},
+		{Name: "broken", URL: srv.URL + "/broken", Enabled: true, ProviderIDs: []string{"groq"}},
+	}
+	errs := New().Dispatch(context.Background(), hooks, ev)
+	if hits != 2 {
+		t.Errorf("发送次数 = %d, 期望 2", hits)
+	}
+	if len(errs) != 1 || errs["broken"] == nil {
+		t.Errorf("错误 = %v", errs)
+	}
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Text 事件的纯文本描述
+func Text(ev Event) string {
+	name := ev.ProviderName
+	if name == "" {
+		name = ev.ProviderID
+	}
+
+	var b strings.Builder
+	switch ev.Type {
+	case EventDown:
+		fmt.Fprintf(&b, "🔴 [PingAI] %s / %s 检测失败", name, ev.Model)
+		if ev.FailCount > 1 {
+			fmt.Fprintf(&b, " (连续 %d 次)", ev.FailCount)
+		}
+	case EventUp:
+		fmt.Fprintf(&b, "🟢 [PingAI] %s / %s 已恢复", name, ev.Model)
+	default:
+		fmt.Fprintf(&b, "🔔 [PingAI] 测试通知: %s", name)
+	}
+	if ev.MaskedKey != "" {
+		fmt.Fprintf(&b, "\nKey: %s", ev.MaskedKey)
+	}
+	if ev.ErrorKind != "" {
+		fmt.Fprintf(&b, "\n原因: %s", ev.ErrorKind)
+	}
+	if ev.Message != "" {
+		fmt.Fprintf(&b, "\n信息: %s", ev.Message)
+	}
+	if !ev.Time.IsZero() {
+		fmt.Fprintf(&b, "\n时间: %s", ev.Time.Format("2006-01-02 15:04:05"))
+	}
+	return b.String()
+}
+
+// Payload 按 Webhook 类型生成请求体
+func Payload(kind Kind, ev Event) ([]byte, error) {
+	text := Text(ev)
+	var body any
+	switch kind {
+	case KindSlack:
+		body = map[string]any{"text": text}
+	case KindDiscord:
+		body = map[string]any{"content": text}
+	case KindFeishu:
+		body = map[string]any{"msg_type": "text", "content": map[string]any{"text": text}}
+	case KindDingTalk, KindWeCom:
+		body = map[string]any{"msgtype": "text", "text": map[string]any{"content": text}}
+	case KindGeneric, "":
+		body = struct {
+			Event
+			Text string `json:"text"`
+		}{ev, text}
+	default:
+		return nil, fmt.Errorf("不支持的 Webhook 类型: %s", kind)
+	}
+	return json.Marshal(body)
+}
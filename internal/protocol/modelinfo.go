@@ -0,0 +1,256 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ModelInfo 模型元数据，数值为 0 或指针为 nil 表示未知
+type ModelInfo struct {
+	ID               string   `json:"id"`
+	ContextWindow    int      `json:"contextWindow,omitempty"`    // 上下文长度 (token)
+	MaxOutput        int      `json:"maxOutput,omitempty"`        // 最大输出 token
+	InputModalities  []string `json:"inputModalities,omitempty"`  // text / image / audio / video / file
+	OutputModalities []string `json:"outputModalities,omitempty"` // text / image / audio / embedding
+	Tools            *bool    `json:"tools,omitempty"`            // 是否支持工具调用
+	Streaming        *bool    `json:"streaming,omitempty"`        // 是否支持流式输出
+	InputPrice       float64  `json:"inputPrice,omitempty"`       // 每百万输入 token 价格
+	OutputPrice      float64  `json:"outputPrice,omitempty"`      // 每百万输出 token 价格
+	Currency         string   `json:"currency,omitempty"`         // USD / CNY，为空表示价格未知
+	Source           string   `json:"source,omitempty"`           // preset / openrouter / gemini / ollama
+}
+
+// OutputsText 模型是否输出文本，输出模态未知时视为支持
+func (m ModelInfo) OutputsText() bool {
+	return len(m.OutputModalities) == 0 || contains(m.OutputModalities, "text")
+}
+
+// Merge 用 other 补全 m 中未知的字段，m 的已知字段优先
+func (m ModelInfo) Merge(other ModelInfo) ModelInfo {
+	if m.ID == "" {
+		m.ID = other.ID
+	}
+	if m.ContextWindow == 0 {
+		m.ContextWindow = other.ContextWindow
+	}
+	if m.MaxOutput == 0 {
+		m.MaxOutput = other.MaxOutput
+	}
+	if len(m.InputModalities) == 0 {
+		m.InputModalities = other.InputModalities
+	}
+	if len(m.OutputModalities) == 0 {
+		m.OutputModalities = other.OutputModalities
+	}
+	if m.Tools == nil {
+		m.Tools = other.Tools
+	}
+	if m.Streaming == nil {
+		m.Streaming = other.Streaming
+	}
+	if m.Currency == "" {
+		m.InputPrice, m.OutputPrice, m.Currency = other.InputPrice, other.OutputPrice, other.Currency
+	}
+	if m.Source == "" {
+		m.Source = other.Source
+	}
+	return m
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+// getBody 发送请求并返回 200 响应体
+func getBody(req *http.Request) ([]byte, error) {
+	resp, err := clientFor(req.Context()).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 {
+		return nil, &HTTPError{StatusCode: resp.StatusCode, Body: truncate(string(body), 200)}
+	}
+	return body, nil
+}
+
+// ListModelInfo 获取模型列表及接口提供的元数据：
+// OpenAI 兼容接口解析 OpenRouter 扩展字段，Gemini 解析 token 上限和支持的方法，Anthropic 只有模型 ID
+func ListModelInfo(ctx context.Context, proto Protocol, baseURL, apiKey string) ([]ModelInfo, error) {
+	base := strings.TrimSuffix(baseURL, "/")
+	switch proto {
+	case ProtocolGemini:
+		req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/models?key=%s", base, apiKey), nil)
+		if err != nil {
+			return nil, err
+		}
+		body, err := getBody(req)
+		if err != nil {
+			return nil, err
+		}
+		return parseGeminiModels(body)
+	case ProtocolAnthropic:
+		models, err := GetAdapter(proto).ListModels(ctx, baseURL, apiKey)
+		if err != nil {
+			return nil, err
+		}
+		infos := make([]ModelInfo, len(models))
+		for i, m := range models {
+			infos[i] = ModelInfo{ID: m}
+		}
+		return infos, nil
+	default:
+		req, err := http.NewRequestWithContext(ctx, "GET", base+"/models", nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		body, err := getBody(req)
+		if err != nil {
+			return nil, err
+		}
+		return parseOpenAIModels(body)
+	}
+}
+
+// parseOpenAIModels 解析 /models，OpenRouter 额外提供上下文长度、模态、价格 (每 token 美元) 和支持的参数
+func parseOpenAIModels(body []byte) ([]ModelInfo, error) {
+	var result struct {
+		Data []struct {
+			ID            string `json:"id"`
+			ContextLength int    `json:"context_length"`
+			Architecture  *struct {
+				InputModalities  []string `json:"input_modalities"`
+				OutputModalities []string `json:"output_modalities"`
+			} `json:"architecture"`
+			TopProvider *struct {
+				MaxCompletionTokens int `json:"max_completion_tokens"`
+			} `json:"top_provider"`
+			Pricing *struct {
+				Prompt     string `json:"prompt"`
+				Completion string `json:"completion"`
+			} `json:"pricing"`
+			SupportedParameters []string `json:"supported_parameters"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	infos := make([]ModelInfo, len(result.Data))
+	for i, m := range result.Data {
+		info := ModelInfo{ID: m.ID, ContextWindow: m.ContextLength}
+		if m.Architecture != nil {
+			info.InputModalities = m.Architecture.InputModalities
+			info.OutputModalities = m.Architecture.OutputModalities
+		}
+		if m.TopProvider != nil {
+			info.MaxOutput = m.TopProvider.MaxCompletionTokens
+		}
+		if m.Pricing != nil {
+			in, _ := strconv.ParseFloat(m.Pricing.Prompt, 64)
+			out, _ := strconv.ParseFloat(m.Pricing.Completion, 64)
+			info.InputPrice, info.OutputPrice, info.Currency = in*1e6, out*1e6, "USD"
+		}
+		if m.SupportedParameters != nil {
+			info.Tools = boolPtr(contains(m.SupportedParameters, "tools"))
+		}
+		if m.ContextLength > 0 || m.Architecture != nil || m.Pricing != nil {
+			info.Source = "openrouter"
+		}
+		infos[i] = info
+	}
+	return infos, nil
+}
+
+// parseGeminiModels 解析 Gemini /models 的 token 上限和 supportedGenerationMethods
+func parseGeminiModels(body []byte) ([]ModelInfo, error) {
+	var result struct {
+		Models []struct {
+			Name             string   `json:"name"`
+			InputTokenLimit  int      `json:"inputTokenLimit"`
+			OutputTokenLimit int      `json:"outputTokenLimit"`
+			Methods          []string `json:"supportedGenerationMethods"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	infos := make([]ModelInfo, len(result.Models))
+	for i, m := range result.Models {
+		name := m.Name
+		if idx := strings.LastIndex(name, "/"); idx >= 0 {
+			name = name[idx+1:]
+		}
+		info := ModelInfo{ID: name, ContextWindow: m.InputTokenLimit, MaxOutput: m.OutputTokenLimit, Source: "gemini"}
+		if m.Methods != nil {
+			generates := contains(m.Methods, "generateContent")
+			switch {
+			case generates:
+				info.OutputModalities = []string{"text"}
+			case contains(m.Methods, "embedContent"):
+				info.OutputModalities = []string{"embedding"}
+			}
+			// 流式接口与 generateContent 共用能力，不支持生成的模型同样无法流式输出
+			info.Streaming = boolPtr(generates)
+		}
+		infos[i] = info
+	}
+	return infos, nil
+}
+
+// OllamaShow 通过 Ollama 原生接口 /api/show 获取模型的上下文长度和能力，baseURL 可带 /v1
+func OllamaShow(ctx context.Context, baseURL, model string) (ModelInfo, error) {
+	root := strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1")
+	payload, _ := json.Marshal(map[string]string{"model": model})
+	req, err := http.NewRequestWithContext(ctx, "POST", root+"/api/show", bytes.NewReader(payload))
+	if err != nil {
+		return ModelInfo{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	body, err := getBody(req)
+	if err != nil {
+		return ModelInfo{}, err
+	}
+
+	var result struct {
+		ModelInfo    map[string]any `json:"model_info"`
+		Capabilities []string       `json:"capabilities"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return ModelInfo{}, err
+	}
+	info := ModelInfo{ID: model, Source: "ollama", Streaming: boolPtr(true)}
+	// 键名带架构前缀，如 llama.context_length
+	for k, v := range result.ModelInfo {
+		if n, ok := v.(float64); ok && strings.HasSuffix(k, ".context_length") {
+			info.ContextWindow = int(n)
+		}
+	}
+	if result.Capabilities != nil {
+		info.Tools = boolPtr(contains(result.Capabilities, "tools"))
+		info.InputModalities = []string{"text"}
+		if contains(result.Capabilities, "vision") {
+			info.InputModalities = append(info.InputModalities, "image")
+		}
+		if contains(result.Capabilities, "completion") {
+			info.OutputModalities = []string{"text"}
+		} else if contains(result.Capabilities, "embedding") {
+			info.OutputModalities = []string{"embedding"}
+		}
+	}
+	return info, nil
+}
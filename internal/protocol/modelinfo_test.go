@@ -0,0 +1,74 @@
+package protocol
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListModelInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/openrouter/models":
+			w.Write([]byte(`{"data":[
+				{"id":"deepseek/deepseek-chat","context_length":64000,
+				 "architecture":{"input_modalities":["text"],"output_modalities":["text"]},
+				 "top_provider":{"max_completion_tokens":8192},
+				 "pricing":{"prompt":"0.00000027","completion":"0.0000011"},
+				 "supported_parameters":["temperature","tools"]},
+				{"id":"openai/dall-e","architecture":{"input_modalities":["text"],"output_modalities":["image"]}}]}`))
+		case "/plain/models":
+			w.Write([]byte(`{"data":[{"id":"gpt-4o"}]}`))
+		case "/gemini/models":
+			w.Write([]byte(`{"models":[
+				{"name":"models/gemini-1.5-pro","inputTokenLimit":2000000,"outputTokenLimit":8192,"supportedGenerationMethods":["generateContent","countTokens"]},
+				{"name":"models/text-embedding-004","inputTokenLimit":2048,"supportedGenerationMethods":["embedContent"]}]}`))
+		case "/api/show":
+			w.Write([]byte(`{"model_info":{"general.architecture":"llama","llama.context_length":131072},"capabilities":["completion","tools","vision"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	infos, err := ListModelInfo(ctx, ProtocolOpenAI, srv.URL+"/openrouter", "k")
+	if err != nil || len(infos) != 2 {
+		t.Fatalf("OpenRouter = %+v, %v", infos, err)
+	}
+	ds := infos[0]
+	if ds.ContextWindow != 64000 || ds.MaxOutput != 8192 || ds.Tools == nil || !*ds.Tools || ds.Source != "openrouter" ||
+		ds.Currency != "USD" || ds.InputPrice < 0.269 || ds.InputPrice > 0.271 || ds.OutputPrice < 1.09 || ds.OutputPrice > 1.11 {
+		t.Errorf("deepseek = %+v", ds)
+	}
+	if infos[1].OutputsText() {
+		t.Errorf("图像模型不应输出文本: %+v", infos[1])
+	}
+
+	infos, err = ListModelInfo(ctx, ProtocolOpenAI, srv.URL+"/plain", "k")
+	if err != nil || len(infos) != 1 || infos[0].Source != "" || infos[0].Tools != nil || !infos[0].OutputsText() {
+		t.Errorf("普通 OpenAI 列表 = %+v, %v", infos, err)
+	}
+
+	infos, err = ListModelInfo(ctx, ProtocolGemini, srv.URL+"/gemini", "k")
+	if err != nil || len(infos) != 2 {
+		t.Fatalf("Gemini = %+v, %v", infos, err)
+	}
+	if infos[0].ID != "gemini-1.5-pro" || infos[0].ContextWindow != 2000000 || !*infos[0].Streaming || !infos[0].OutputsText() {
+		t.Errorf("gemini-1.5-pro = %+v", infos[0])
+	}
+	if infos[1].OutputsText() || *infos[1].Streaming {
+		t.Errorf("embedding 模型 = %+v", infos[1])
+	}
+
+	info, err := OllamaShow(ctx, srv.URL+"/v1", "llama3.2-vision")
+	if err != nil || info.ContextWindow != 131072 || !*info.Tools || len(info.InputModalities) != 2 || !info.OutputsText() {
+		t.Errorf("OllamaShow = %+v, %v", info, err)
+	}
+
+	merged := ModelInfo{ID: "x", ContextWindow: 1000}.Merge(ModelInfo{ContextWindow: 2000, MaxOutput: 100, InputPrice: 1, Currency: "USD"})
+	if merged.ContextWindow != 1000 || merged.MaxOutput != 100 || merged.InputPrice != 1 || merged.Currency != "USD" {
+		t.Errorf("Merge = %+v", merged)
+	}
+}
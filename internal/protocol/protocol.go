@@ -27,6 +27,10 @@ type ChatRequest struct {
 	Model    string
 	Messages []Message
 	Stream   bool
+
+	System      string   // 系统提示词，为空时不发送
+	Temperature *float64 // 为空时使用服务端默认值
+	MaxTokens   int      // 为 0 时使用默认值
 }
 
 // Message 统一消息
@@ -38,11 +42,13 @@ type Message struct {
 // ChatResponse 统一响应
 type ChatResponse struct {
 	Content      string
+	Model        string // 响应 (流式为首个带模型名的分片) 中回显的模型名，未返回时为空
 	PromptTokens int
 	CompTokens   int
 	StatusCode   int
 	RawBody      string
 	Error        string
+	Truncated    bool // 因达到输出 token 上限而截断
 }
 
 // StreamCallback 流式回调
@@ -68,21 +74,70 @@ func GetAdapter(p Protocol) Adapter {
 	}
 }
 
-var httpClient = &http.Client{Timeout: 30 * time.Second}
+// httpClient 默认客户端，单次请求 (含读取响应体) 最长 30 秒
+var httpClient = &http.Client{Timeout: 30 * time.Second, Transport: contextTransport{}}
+
+// NoTimeoutClient 没有任何固定超时的客户端，完全由调用方的 context 控制取消；
+// 用于超时由调用方决定的请求，同样支持 WithTransport 录制
+var NoTimeoutClient = &http.Client{Transport: contextTransport{}}
+
+type clientKey struct{}
+
+// WithClient 返回使用 c 发送请求的 context，替代默认 30 秒超时的客户端
+func WithClient(ctx context.Context, c *http.Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, c)
+}
+
+// clientFor 返回 context 指定的客户端，未指定时为默认客户端
+func clientFor(ctx context.Context) *http.Client {
+	if c, ok := ctx.Value(clientKey{}).(*http.Client); ok && c != nil {
+		return c
+	}
+	return httpClient
+}
+
+// HTTPError 非 200 响应错误，保留状态码和响应体供错误分类使用
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Body)
+}
 
 // --- OpenAI 适配器 ---
 
 type OpenAIAdapter struct{}
 
-func (a *OpenAIAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
-	msgs := make([]map[string]string, len(req.Messages))
-	for i, m := range req.Messages {
-		msgs[i] = map[string]string{"role": m.Role, "content": m.Content}
+// openAIBody 构造 /chat/completions 请求体，系统提示词作为第一条 system 消息
+func openAIBody(req ChatRequest, stream bool) []byte {
+	msgs := make([]map[string]string, 0, len(req.Messages)+1)
+	if req.System != "" {
+		msgs = append(msgs, map[string]string{"role": "system", "content": req.System})
 	}
-	body, _ := json.Marshal(map[string]any{
+	for _, m := range req.Messages {
+		msgs = append(msgs, map[string]string{"role": m.Role, "content": m.Content})
+	}
+	body := map[string]any{
 		"model":    req.Model,
 		"messages": msgs,
-	})
+	}
+	if stream {
+		body["stream"] = true
+	}
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		body["max_tokens"] = req.MaxTokens
+	}
+	data, _ := json.Marshal(body)
+	return data
+}
+
+func (a *OpenAIAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	body := openAIBody(req, false)
 
 	url := strings.TrimSuffix(req.BaseURL, "/") + "/chat/completions"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
@@ -92,7 +147,7 @@ func (a *OpenAIAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatRespons
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+req.APIKey)
 
-	resp, err := httpClient.Do(httpReq)
+	resp, err := clientFor(httpReq.Context()).Do(httpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -105,10 +160,12 @@ func (a *OpenAIAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatRespons
 	}
 
 	var result struct {
+		Model   string `json:"model"`
 		Choices []struct {
 			Message struct {
 				Content string `json:"content"`
 			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
 		} `json:"choices"`
 		Usage *struct {
 			PromptTokens     int `json:"prompt_tokens"`
@@ -122,7 +179,7 @@ func (a *OpenAIAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatRespons
 		return &ChatResponse{StatusCode: resp.StatusCode, Error: "JSON parse error", RawBody: truncate(string(respBody), 300)}, nil
 	}
 	if result.Error != nil {
-		return &ChatResponse{StatusCode: resp.StatusCode, Error: result.Error.Message}, nil
+		return &ChatResponse{StatusCode: resp.StatusCode, Error: result.Error.Message, RawBody: truncate(string(respBody), 300)}, nil
 	}
 	if len(result.Choices) == 0 {
 		return &ChatResponse{StatusCode: resp.StatusCode, Error: "empty choices"}, nil
@@ -130,7 +187,9 @@ func (a *OpenAIAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatRespons
 
 	cr := &ChatResponse{
 		Content:    result.Choices[0].Message.Content,
+		Model:      result.Model,
 		StatusCode: 200,
+		Truncated:  result.Choices[0].FinishReason == "length",
 	}
 	if result.Usage != nil {
 		cr.PromptTokens = result.Usage.PromptTokens
@@ -140,15 +199,7 @@ func (a *OpenAIAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatRespons
 }
 
 func (a *OpenAIAdapter) ChatStream(ctx context.Context, req ChatRequest, cb StreamCallback) (*ChatResponse, error) {
-	msgs := make([]map[string]string, len(req.Messages))
-	for i, m := range req.Messages {
-		msgs[i] = map[string]string{"role": m.Role, "content": m.Content}
-	}
-	body, _ := json.Marshal(map[string]any{
-		"model":    req.Model,
-		"messages": msgs,
-		"stream":   true,
-	})
+	body := openAIBody(req, true)
 
 	url := strings.TrimSuffix(req.BaseURL, "/") + "/chat/completions"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
@@ -159,7 +210,7 @@ func (a *OpenAIAdapter) ChatStream(ctx context.Context, req ChatRequest, cb Stre
 	httpReq.Header.Set("Authorization", "Bearer "+req.APIKey)
 	httpReq.Header.Set("Accept", "text/event-stream")
 
-	resp, err := httpClient.Do(httpReq)
+	resp, err := clientFor(httpReq.Context()).Do(httpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -182,7 +233,7 @@ func (a *OpenAIAdapter) ListModels(ctx context.Context, baseURL, apiKey string)
 	}
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 
-	resp, err := httpClient.Do(req)
+	resp, err := clientFor(req.Context()).Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -190,7 +241,7 @@ func (a *OpenAIAdapter) ListModels(ctx context.Context, baseURL, apiKey string)
 
 	body, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, truncate(string(body), 200))
+		return nil, &HTTPError{StatusCode: resp.StatusCode, Body: truncate(string(body), 200)}
 	}
 
 	var result struct {
@@ -217,7 +268,7 @@ func (a *OpenAIAdapter) CheckConnectivity(ctx context.Context, baseURL, apiKey s
 	}
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 
-	resp, err := httpClient.Do(req)
+	resp, err := clientFor(req.Context()).Do(req)
 	if err != nil {
 		return 0, err
 	}
@@ -229,16 +280,38 @@ func (a *OpenAIAdapter) CheckConnectivity(ctx context.Context, baseURL, apiKey s
 
 type AnthropicAdapter struct{}
 
-func (a *AnthropicAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+// anthropicDefaultMaxTokens Anthropic 要求必填 max_tokens
+const anthropicDefaultMaxTokens = 256
+
+// anthropicBody 构造 /messages 请求体
+func anthropicBody(req ChatRequest, stream bool) []byte {
 	msgs := make([]map[string]string, len(req.Messages))
 	for i, m := range req.Messages {
 		msgs[i] = map[string]string{"role": m.Role, "content": m.Content}
 	}
-	body, _ := json.Marshal(map[string]any{
+	body := map[string]any{
 		"model":      req.Model,
 		"messages":   msgs,
-		"max_tokens": 256,
-	})
+		"max_tokens": anthropicDefaultMaxTokens,
+	}
+	if stream {
+		body["stream"] = true
+	}
+	if req.System != "" {
+		body["system"] = req.System
+	}
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		body["max_tokens"] = req.MaxTokens
+	}
+	data, _ := json.Marshal(body)
+	return data
+}
+
+func (a *AnthropicAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	body := anthropicBody(req, false)
 
 	url := strings.TrimSuffix(req.BaseURL, "/") + "/messages"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
@@ -249,7 +322,7 @@ func (a *AnthropicAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatResp
 	httpReq.Header.Set("x-api-key", req.APIKey)
 	httpReq.Header.Set("anthropic-version", "2023-06-01")
 
-	resp, err := httpClient.Do(httpReq)
+	resp, err := clientFor(httpReq.Context()).Do(httpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -262,10 +335,12 @@ func (a *AnthropicAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatResp
 	}
 
 	var result struct {
+		Model   string `json:"model"`
 		Content []struct {
 			Text string `json:"text"`
 		} `json:"content"`
-		Usage *struct {
+		StopReason string `json:"stop_reason"`
+		Usage      *struct {
 			InputTokens  int `json:"input_tokens"`
 			OutputTokens int `json:"output_tokens"`
 		} `json:"usage"`
@@ -277,13 +352,13 @@ func (a *AnthropicAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatResp
 		return &ChatResponse{StatusCode: resp.StatusCode, Error: "JSON parse error", RawBody: truncate(string(respBody), 300)}, nil
 	}
 	if result.Error != nil {
-		return &ChatResponse{StatusCode: resp.StatusCode, Error: result.Error.Message}, nil
+		return &ChatResponse{StatusCode: resp.StatusCode, Error: result.Error.Message, RawBody: truncate(string(respBody), 300)}, nil
 	}
 	if len(result.Content) == 0 {
 		return &ChatResponse{StatusCode: resp.StatusCode, Error: "empty content"}, nil
 	}
 
-	cr := &ChatResponse{Content: result.Content[0].Text, StatusCode: 200}
+	cr := &ChatResponse{Content: result.Content[0].Text, Model: result.Model, StatusCode: 200, Truncated: result.StopReason == "max_tokens"}
 	if result.Usage != nil {
 		cr.PromptTokens = result.Usage.InputTokens
 		cr.CompTokens = result.Usage.OutputTokens
@@ -292,16 +367,7 @@ func (a *AnthropicAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatResp
 }
 
 func (a *AnthropicAdapter) ChatStream(ctx context.Context, req ChatRequest, cb StreamCallback) (*ChatResponse, error) {
-	msgs := make([]map[string]string, len(req.Messages))
-	for i, m := range req.Messages {
-		msgs[i] = map[string]string{"role": m.Role, "content": m.Content}
-	}
-	body, _ := json.Marshal(map[string]any{
-		"model":      req.Model,
-		"messages":   msgs,
-		"max_tokens": 256,
-		"stream":     true,
-	})
+	body := anthropicBody(req, true)
 
 	url := strings.TrimSuffix(req.BaseURL, "/") + "/messages"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
@@ -313,7 +379,7 @@ func (a *AnthropicAdapter) ChatStream(ctx context.Context, req ChatRequest, cb S
 	httpReq.Header.Set("anthropic-version", "2023-06-01")
 	httpReq.Header.Set("Accept", "text/event-stream")
 
-	resp, err := httpClient.Do(httpReq)
+	resp, err := clientFor(httpReq.Context()).Do(httpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -337,7 +403,7 @@ func (a *AnthropicAdapter) ListModels(ctx context.Context, baseURL, apiKey strin
 	req.Header.Set("x-api-key", apiKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
 
-	resp, err := httpClient.Do(req)
+	resp, err := clientFor(req.Context()).Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -345,7 +411,7 @@ func (a *AnthropicAdapter) ListModels(ctx context.Context, baseURL, apiKey strin
 
 	body, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, truncate(string(body), 200))
+		return nil, &HTTPError{StatusCode: resp.StatusCode, Body: truncate(string(body), 200)}
 	}
 
 	var result struct {
@@ -372,7 +438,7 @@ func (a *AnthropicAdapter) CheckConnectivity(ctx context.Context, baseURL, apiKe
 	req.Header.Set("x-api-key", apiKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
 
-	resp, err := httpClient.Do(req)
+	resp, err := clientFor(req.Context()).Do(req)
 	if err != nil {
 		return 0, err
 	}
@@ -384,7 +450,8 @@ func (a *AnthropicAdapter) CheckConnectivity(ctx context.Context, baseURL, apiKe
 
 type GeminiAdapter struct{}
 
-func (a *GeminiAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+// geminiBody 构造 generateContent 请求体，流式与否由 URL 区分
+func geminiBody(req ChatRequest) []byte {
 	contents := make([]map[string]any, len(req.Messages))
 	for i, m := range req.Messages {
 		role := m.Role
@@ -396,9 +463,28 @@ func (a *GeminiAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatRespons
 			"parts": []map[string]string{{"text": m.Content}},
 		}
 	}
-	body, _ := json.Marshal(map[string]any{
+	body := map[string]any{
 		"contents": contents,
-	})
+	}
+	if req.System != "" {
+		body["systemInstruction"] = map[string]any{"parts": []map[string]string{{"text": req.System}}}
+	}
+	config := map[string]any{}
+	if req.Temperature != nil {
+		config["temperature"] = *req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		config["maxOutputTokens"] = req.MaxTokens
+	}
+	if len(config) > 0 {
+		body["generationConfig"] = config
+	}
+	data, _ := json.Marshal(body)
+	return data
+}
+
+func (a *GeminiAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	body := geminiBody(req)
 
 	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s",
 		strings.TrimSuffix(req.BaseURL, "/"), req.Model, req.APIKey)
@@ -408,7 +494,7 @@ func (a *GeminiAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatRespons
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := httpClient.Do(httpReq)
+	resp, err := clientFor(httpReq.Context()).Do(httpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -421,12 +507,14 @@ func (a *GeminiAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatRespons
 	}
 
 	var result struct {
-		Candidates []struct {
+		ModelVersion string `json:"modelVersion"`
+		Candidates   []struct {
 			Content struct {
 				Parts []struct {
 					Text string `json:"text"`
 				} `json:"parts"`
 			} `json:"content"`
+			FinishReason string `json:"finishReason"`
 		} `json:"candidates"`
 		UsageMetadata *struct {
 			PromptTokenCount     int `json:"promptTokenCount"`
@@ -440,13 +528,14 @@ func (a *GeminiAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatRespons
 		return &ChatResponse{StatusCode: resp.StatusCode, Error: "JSON parse error", RawBody: truncate(string(respBody), 300)}, nil
 	}
 	if result.Error != nil {
-		return &ChatResponse{StatusCode: resp.StatusCode, Error: result.Error.Message}, nil
+		return &ChatResponse{StatusCode: resp.StatusCode, Error: result.Error.Message, RawBody: truncate(string(respBody), 300)}, nil
 	}
 	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
 		return &ChatResponse{StatusCode: resp.StatusCode, Error: "empty response"}, nil
 	}
 
-	cr := &ChatResponse{Content: result.Candidates[0].Content.Parts[0].Text, StatusCode: 200}
+	cr := &ChatResponse{Content: result.Candidates[0].Content.Parts[0].Text, Model: result.ModelVersion, StatusCode: 200,
+		Truncated: result.Candidates[0].FinishReason == "MAX_TOKENS"}
 	if result.UsageMetadata != nil {
 		cr.PromptTokens = result.UsageMetadata.PromptTokenCount
 		cr.CompTokens = result.UsageMetadata.CandidatesTokenCount
@@ -455,20 +544,7 @@ func (a *GeminiAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatRespons
 }
 
 func (a *GeminiAdapter) ChatStream(ctx context.Context, req ChatRequest, cb StreamCallback) (*ChatResponse, error) {
-	contents := make([]map[string]any, len(req.Messages))
-	for i, m := range req.Messages {
-		role := m.Role
-		if role == "assistant" {
-			role = "model"
-		}
-		contents[i] = map[string]any{
-			"role":  role,
-			"parts": []map[string]string{{"text": m.Content}},
-		}
-	}
-	body, _ := json.Marshal(map[string]any{
-		"contents": contents,
-	})
+	body := geminiBody(req)
 
 	url := fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse&key=%s",
 		strings.TrimSuffix(req.BaseURL, "/"), req.Model, req.APIKey)
@@ -478,7 +554,7 @@ func (a *GeminiAdapter) ChatStream(ctx context.Context, req ChatRequest, cb Stre
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := httpClient.Do(httpReq)
+	resp, err := clientFor(httpReq.Context()).Do(httpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -500,7 +576,7 @@ func (a *GeminiAdapter) ListModels(ctx context.Context, baseURL, apiKey string)
 		return nil, err
 	}
 
-	resp, err := httpClient.Do(req)
+	resp, err := clientFor(req.Context()).Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -508,7 +584,7 @@ func (a *GeminiAdapter) ListModels(ctx context.Context, baseURL, apiKey string)
 
 	body, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, truncate(string(body), 200))
+		return nil, &HTTPError{StatusCode: resp.StatusCode, Body: truncate(string(body), 200)}
 	}
 
 	var result struct {
@@ -538,7 +614,7 @@ func (a *GeminiAdapter) CheckConnectivity(ctx context.Context, baseURL, apiKey s
 		return 0, err
 	}
 
-	resp, err := httpClient.Do(req)
+	resp, err := clientFor(req.Context()).Do(req)
 	if err != nil {
 		return 0, err
 	}
@@ -551,8 +627,11 @@ func (a *GeminiAdapter) CheckConnectivity(ctx context.Context, baseURL, apiKey s
 func readSSE(reader io.Reader, cb StreamCallback) (*ChatResponse, error) {
 	buf := make([]byte, 4096)
 	var fullContent strings.Builder
+	var model string
 	chunkCount := 0
 	isFirst := true
+	truncated := false
+	var streamErr error
 
 	for {
 		n, err := reader.Read(buf)
@@ -568,13 +647,24 @@ func readSSE(reader io.Reader, cb StreamCallback) (*ChatResponse, error) {
 					continue
 				}
 				var chunk struct {
+					Model   string `json:"model"`
 					Choices []struct {
 						Delta struct {
 							Content string `json:"content"`
 						} `json:"delta"`
+						FinishReason string `json:"finish_reason"`
 					} `json:"choices"`
 				}
-				if json.Unmarshal([]byte(data), &chunk) == nil && len(chunk.Choices) > 0 {
+				if json.Unmarshal([]byte(data), &chunk) != nil {
+					continue
+				}
+				if model == "" {
+					model = chunk.Model
+				}
+				if len(chunk.Choices) > 0 {
+					if chunk.Choices[0].FinishReason == "length" {
+						truncated = true
+					}
 					text := chunk.Choices[0].Delta.Content
 					if text != "" {
 						fullContent.WriteString(text)
@@ -588,20 +678,28 @@ func readSSE(reader io.Reader, cb StreamCallback) (*ChatResponse, error) {
 			}
 		}
 		if err != nil {
+			if err != io.EOF {
+				streamErr = fmt.Errorf("stream interrupted: %w", err)
+			}
 			break
 		}
 	}
 
 	return &ChatResponse{
 		Content:    fullContent.String(),
+		Model:      model,
 		StatusCode: 200,
-	}, nil
+		Truncated:  truncated,
+	}, streamErr
 }
 
 func readAnthropicSSE(reader io.Reader, cb StreamCallback) (*ChatResponse, error) {
 	buf := make([]byte, 4096)
 	var fullContent strings.Builder
+	var model string
 	isFirst := true
+	truncated := false
+	var streamErr error
 
 	for {
 		n, err := reader.Read(buf)
@@ -614,13 +712,26 @@ func readAnthropicSSE(reader io.Reader, cb StreamCallback) (*ChatResponse, error
 				}
 				data := strings.TrimPrefix(line, "data: ")
 				var event struct {
-					Type  string `json:"type"`
+					Type    string `json:"type"`
+					Message *struct {
+						Model string `json:"model"`
+					} `json:"message"` // message_start
 					Delta *struct {
-						Type string `json:"type"`
-						Text string `json:"text"`
+						Type       string `json:"type"`
+						Text       string `json:"text"`
+						StopReason string `json:"stop_reason"` // message_delta
 					} `json:"delta"`
 				}
-				if json.Unmarshal([]byte(data), &event) == nil && event.Delta != nil && event.Delta.Text != "" {
+				if json.Unmarshal([]byte(data), &event) != nil {
+					continue
+				}
+				if model == "" && event.Message != nil {
+					model = event.Message.Model
+				}
+				if event.Delta != nil && event.Delta.StopReason == "max_tokens" {
+					truncated = true
+				}
+				if event.Delta != nil && event.Delta.Text != "" {
 					fullContent.WriteString(event.Delta.Text)
 					if cb != nil {
 						cb(event.Delta.Text, isFirst)
@@ -630,17 +741,23 @@ func readAnthropicSSE(reader io.Reader, cb StreamCallback) (*ChatResponse, error
 			}
 		}
 		if err != nil {
+			if err != io.EOF {
+				streamErr = fmt.Errorf("stream interrupted: %w", err)
+			}
 			break
 		}
 	}
 
-	return &ChatResponse{Content: fullContent.String(), StatusCode: 200}, nil
+	return &ChatResponse{Content: fullContent.String(), Model: model, StatusCode: 200, Truncated: truncated}, streamErr
 }
 
 func readGeminiSSE(reader io.Reader, cb StreamCallback) (*ChatResponse, error) {
 	buf := make([]byte, 4096)
 	var fullContent strings.Builder
+	var model string
 	isFirst := true
+	truncated := false
+	var streamErr error
 
 	for {
 		n, err := reader.Read(buf)
@@ -653,15 +770,26 @@ func readGeminiSSE(reader io.Reader, cb StreamCallback) (*ChatResponse, error) {
 				}
 				data := strings.TrimPrefix(line, "data: ")
 				var chunk struct {
-					Candidates []struct {
+					ModelVersion string `json:"modelVersion"`
+					Candidates   []struct {
 						Content struct {
 							Parts []struct {
 								Text string `json:"text"`
 							} `json:"parts"`
 						} `json:"content"`
+						FinishReason string `json:"finishReason"`
 					} `json:"candidates"`
 				}
-				if json.Unmarshal([]byte(data), &chunk) == nil && len(chunk.Candidates) > 0 {
+				if json.Unmarshal([]byte(data), &chunk) != nil {
+					continue
+				}
+				if model == "" {
+					model = chunk.ModelVersion
+				}
+				if len(chunk.Candidates) > 0 {
+					if chunk.Candidates[0].FinishReason == "MAX_TOKENS" {
+						truncated = true
+					}
 					for _, part := range chunk.Candidates[0].Content.Parts {
 						if part.Text != "" {
 							fullContent.WriteString(part.Text)
@@ -675,11 +803,14 @@ func readGeminiSSE(reader io.Reader, cb StreamCallback) (*ChatResponse, error) {
 			}
 		}
 		if err != nil {
+			if err != io.EOF {
+				streamErr = fmt.Errorf("stream interrupted: %w", err)
+			}
 			break
 		}
 	}
 
-	return &ChatResponse{Content: fullContent.String(), StatusCode: 200}, nil
+	return &ChatResponse{Content: fullContent.String(), Model: model, StatusCode: 200, Truncated: truncated}, streamErr
 }
 
 func truncate(s string, max int) string {
@@ -0,0 +1,172 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"pingai/internal/mockprovider"
+	"strings"
+	"testing"
+	"time"
+)
+
+var protocols = []Protocol{ProtocolOpenAI, ProtocolAnthropic, ProtocolGemini}
+
+func chatReq(baseURL, model, prompt string) ChatRequest {
+	return ChatRequest{
+		BaseURL:  baseURL,
+		APIKey:   "sk-mock",
+		Model:    model,
+		Messages: []Message{{Role: "user", Content: prompt}},
+	}
+}
+
+func TestAdapters(t *testing.T) {
+	srv := mockprovider.NewServer(mockprovider.Behavior{APIKey: "sk-mock", Models: []string{"m-1", "m-2"}})
+	defer srv.Close()
+	ctx := context.Background()
+
+	for _, p := range protocols {
+		adapter := GetAdapter(p)
+		base := srv.BaseURL(string(p))
+
+		if code, err := adapter.CheckConnectivity(ctx, base, "sk-mock"); err != nil || code != http.StatusOK {
+			t.Errorf("%s: CheckConnectivity = %d, %v", p, code, err)
+		}
+		if code, _ := adapter.CheckConnectivity(ctx, base, "wrong"); code != http.StatusUnauthorized {
+			t.Errorf("%s: 错误 Key 的 CheckConnectivity = %d", p, code)
+		}
+
+		models, err := adapter.ListModels(ctx, base, "sk-mock")
+		if err != nil || strings.Join(models, ",") != "m-1,m-2" {
+			t.Errorf("%s: ListModels = %v, %v", p, models, err)
+		}
+
+		resp, err := adapter.Chat(ctx, chatReq(base, "m-1", "Hi, reply with exactly: OK"))
+		if err != nil || resp.Error != "" || resp.Content != "OK" || resp.Model != "m-1" || resp.PromptTokens == 0 || resp.CompTokens == 0 {
+			t.Errorf("%s: Chat = %+v, %v", p, resp, err)
+		}
+
+		var chunks []string
+		firsts := 0
+		resp, err = adapter.ChatStream(ctx, chatReq(base, "m-1", "Count from 1 to 5"), func(chunk string, isFirst bool) {
+			chunks = append(chunks, chunk)
+			if isFirst {
+				firsts++
+			}
+		})
+		if err != nil || resp.Content != "1, 2, 3, 4, 5" || resp.Model != "m-1" || len(chunks) < 2 || firsts != 1 {
+			t.Errorf("%s: ChatStream = %+v, %d chunks, %d firsts, %v", p, resp, len(chunks), firsts, err)
+		}
+	}
+}
+
+func TestAdapterErrors(t *testing.T) {
+	srv := mockprovider.NewServer(mockprovider.Behavior{})
+	defer srv.Close()
+	ctx := context.Background()
+
+	for _, p := range protocols {
+		adapter := GetAdapter(p)
+		base := srv.BaseURL(string(p))
+
+		srv.Set(mockprovider.Behavior{Status: http.StatusTooManyRequests})
+		resp, err := adapter.Chat(ctx, chatReq(base, "m", "hi"))
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || resp.Error != "HTTP 429" || resp.RawBody == "" {
+			t.Errorf("%s: 429 Chat = %+v, %v", p, resp, err)
+		}
+		resp, err = adapter.ChatStream(ctx, chatReq(base, "m", "hi"), nil)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("%s: 429 ChatStream = %+v, %v", p, resp, err)
+		}
+
+		srv.Set(mockprovider.Behavior{Status: http.StatusInternalServerError})
+		_, err = adapter.ListModels(ctx, base, "k")
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusInternalServerError {
+			t.Errorf("%s: 500 ListModels err = %v", p, err)
+		}
+
+		srv.Set(mockprovider.Behavior{Malformed: true})
+		resp, err = adapter.Chat(ctx, chatReq(base, "m", "hi"))
+		if err != nil || resp.Error != "JSON parse error" {
+			t.Errorf("%s: 损坏 JSON Chat = %+v, %v", p, resp, err)
+		}
+		if _, err := adapter.ListModels(ctx, base, "k"); err == nil {
+			t.Errorf("%s: 损坏 JSON ListModels 应返回错误", p)
+		}
+		chunks := 0
+		resp, err = adapter.ChatStream(ctx, chatReq(base, "m", "hi"), func(string, bool) { chunks++ })
+		if err != nil || chunks != 0 || resp.Content != "" {
+			t.Errorf("%s: 损坏 SSE = %+v, %d chunks, %v", p, resp, chunks, err)
+		}
+
+		srv.Set(mockprovider.Behavior{Reply: "abcdefghijkl", DisconnectAfter: 2})
+		resp, err = adapter.ChatStream(ctx, chatReq(base, "m", "hi"), nil)
+		if err == nil || resp == nil || resp.Content != "abcdef" {
+			t.Errorf("%s: 中途断开 = %+v, %v", p, resp, err)
+		}
+	}
+}
+
+func TestRequestParams(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	temp := 0.2
+	req := chatReq(srv.URL, "m", "hi")
+	req.System, req.Temperature, req.MaxTokens = "Be terse.", &temp, 64
+
+	GetAdapter(ProtocolOpenAI).Chat(context.Background(), req)
+	msgs := got["messages"].([]any)
+	if first := msgs[0].(map[string]any); len(msgs) != 2 || first["role"] != "system" || got["temperature"] != 0.2 || got["max_tokens"] != 64.0 {
+		t.Errorf("OpenAI 请求体 = %v", got)
+	}
+
+	GetAdapter(ProtocolAnthropic).Chat(context.Background(), req)
+	if got["system"] != "Be terse." || got["max_tokens"] != 64.0 || len(got["messages"].([]any)) != 1 {
+		t.Errorf("Anthropic 请求体 = %v", got)
+	}
+
+	GetAdapter(ProtocolGemini).Chat(context.Background(), req)
+	config, _ := got["generationConfig"].(map[string]any)
+	if got["systemInstruction"] == nil || config["maxOutputTokens"] != 64.0 || config["temperature"] != 0.2 {
+		t.Errorf("Gemini 请求体 = %v", got)
+	}
+
+	// 未设置时不发送，保持默认请求体不变
+	GetAdapter(ProtocolGemini).Chat(context.Background(), chatReq(srv.URL, "m", "hi"))
+	if _, ok := got["generationConfig"]; ok {
+		t.Errorf("未设置参数时 Gemini 请求体 = %v", got)
+	}
+}
+
+func TestWithClient(t *testing.T) {
+	srv := mockprovider.NewServer(mockprovider.Behavior{
+		Reply:      "a reply long enough to span several chunks",
+		ChunkDelay: 20 * time.Millisecond,
+	})
+	defer srv.Close()
+
+	// 缩短默认客户端的总超时，代替真实的 30 秒
+	old := httpClient.Timeout
+	httpClient.Timeout = 80 * time.Millisecond
+	defer func() { httpClient.Timeout = old }()
+
+	adapter := GetAdapter(ProtocolAnthropic)
+	req := chatReq(srv.BaseURL(mockprovider.Anthropic), "m", "hi")
+	if resp, err := adapter.ChatStream(context.Background(), req, func(string, bool) {}); err == nil && resp.Error == "" {
+		t.Fatal("默认客户端应在总超时后中断流式响应")
+	}
+	ctx := WithClient(context.Background(), NoTimeoutClient)
+	if resp, err := adapter.ChatStream(ctx, req, func(string, bool) {}); err != nil || resp.Error != "" {
+		t.Errorf("WithClient 应不受总超时限制: %+v, %v", resp, err)
+	}
+}
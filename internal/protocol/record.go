@@ -0,0 +1,497 @@
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type transportKey struct{}
+
+// WithTransport 返回使用 rt 发送请求的 context，用于录制和回放
+func WithTransport(ctx context.Context, rt http.RoundTripper) context.Context {
+	return context.WithValue(ctx, transportKey{}, rt)
+}
+
+// contextTransport 优先使用 context 中的 RoundTripper，否则使用 next (为 nil 时 http.DefaultTransport)
+type contextTransport struct {
+	next http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt, ok := req.Context().Value(transportKey{}).(http.RoundTripper); ok && rt != nil {
+		return rt.RoundTrip(req)
+	}
+	if t.next != nil {
+		return t.next.RoundTrip(req)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// Interaction 一次录制的请求/响应
+type Interaction struct {
+	StartedAt string           `json:"startedAt"` // RFC3339Nano
+	HeaderAt  int64            `json:"headerAt"`  // 收到响应头的耗时 (ms)
+	Duration  int64            `json:"duration"`  // 响应体读完的耗时 (ms)
+	Request   RecordedRequest  `json:"request"`
+	Response  RecordedResponse `json:"response"`
+	Error     string           `json:"error,omitempty"` // 网络错误，此时无响应
+}
+
+// RecordedRequest 录制的请求，认证信息已脱敏
+type RecordedRequest struct {
+	Method string            `json:"method"`
+	URL    string            `json:"url"`
+	Header map[string]string `json:"header"`
+	Body   string            `json:"body"`
+}
+
+// RecordedResponse 录制的响应，流式响应另记录各分片及到达时间
+type RecordedResponse struct {
+	Status int               `json:"status"`
+	Header map[string]string `json:"header"`
+	Body   string            `json:"body"`
+	Chunks []Chunk           `json:"chunks,omitempty"`
+}
+
+// Chunk 流式响应分片
+type Chunk struct {
+	Offset int64  `json:"offset"` // 距请求开始的毫秒数
+	Data   string `json:"data"`
+}
+
+// redacted 脱敏后的占位符
+const redacted = "REDACTED"
+
+// secretHeaders 需要脱敏的请求头 (小写)
+var secretHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"x-api-key":           true,
+	"x-goog-api-key":      true,
+	"api-key":             true,
+}
+
+// Recorder 录制经过的请求和响应，可作为 WithTransport 的参数
+type Recorder struct {
+	next    http.RoundTripper
+	secrets []string
+	now     func() time.Time
+
+	mu           sync.Mutex
+	interactions []*Interaction
+}
+
+// NewRecorder 创建录制器，next 为空时使用 http.DefaultTransport；secrets 会从所有录制内容中抹去
+func NewRecorder(next http.RoundTripper, secrets ...string) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	var nonEmpty []string
+	for _, s := range secrets {
+		if s != "" {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	return &Recorder{next: next, secrets: nonEmpty, now: time.Now}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := r.now()
+	var reqBody []byte
+	if req.Body != nil {
+		reqBody, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	it := &Interaction{
+		StartedAt: start.Format(time.RFC3339Nano),
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    r.redact(redactURL(req.URL)),
+			Header: r.headers(req.Header),
+			Body:   r.redact(string(reqBody)),
+		},
+	}
+	r.mu.Lock()
+	r.interactions = append(r.interactions, it)
+	r.mu.Unlock()
+
+	resp, err := r.next.RoundTrip(req)
+	elapsed := r.now().Sub(start).Milliseconds()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	it.HeaderAt = elapsed
+	if err != nil {
+		it.Error = r.redact(err.Error())
+		it.Duration = elapsed
+		return nil, err
+	}
+	it.Response.Status = resp.StatusCode
+	it.Response.Header = r.headers(resp.Header)
+	resp.Body = &recordingBody{
+		rc:     resp.Body,
+		rec:    r,
+		it:     it,
+		start:  start,
+		stream: strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"),
+	}
+	return resp, nil
+}
+
+// Interactions 返回已录制内容的副本
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Interaction, len(r.interactions))
+	for i, it := range r.interactions {
+		out[i] = *it
+		out[i].Response.Chunks = append([]Chunk(nil), it.Response.Chunks...)
+	}
+	return out
+}
+
+func (r *Recorder) headers(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		value := strings.Join(values, ", ")
+		if secretHeaders[strings.ToLower(name)] {
+			if strings.HasPrefix(value, "Bearer ") {
+				value = "Bearer " + redacted
+			} else {
+				value = redacted
+			}
+		}
+		out[name] = r.redact(value)
+	}
+	return out
+}
+
+func (r *Recorder) redact(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+// redactURL 抹去 URL 中的 key 参数 (Gemini)
+func redactURL(u *url.URL) string {
+	q := u.Query()
+	if !q.Has("key") {
+		return u.String()
+	}
+	q.Set("key", redacted)
+	c := *u
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+// recordingBody 边读边记录响应体
+type recordingBody struct {
+	rc     io.ReadCloser
+	rec    *Recorder
+	it     *Interaction
+	start  time.Time
+	stream bool
+	body   strings.Builder
+	done   bool
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	b.rec.mu.Lock()
+	defer b.rec.mu.Unlock()
+	if n > 0 {
+		b.body.Write(p[:n])
+		b.it.Response.Body = b.rec.redact(b.body.String())
+		if b.stream {
+			b.it.Response.Chunks = append(b.it.Response.Chunks, Chunk{
+				Offset: b.rec.now().Sub(b.start).Milliseconds(),
+				Data:   b.rec.redact(string(p[:n])),
+			})
+		}
+	}
+	if err != nil {
+		b.finish(err)
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.rec.mu.Lock()
+	b.finish(nil)
+	b.rec.mu.Unlock()
+	return b.rc.Close()
+}
+
+// finish 记录总耗时，调用方持有 rec.mu
+func (b *recordingBody) finish(err error) {
+	if b.done {
+		return
+	}
+	b.done = true
+	b.it.Duration = b.rec.now().Sub(b.start).Milliseconds()
+	if err != nil && err != io.EOF {
+		b.it.Error = b.rec.redact(err.Error())
+	}
+}
+
+// --- 录制文件 ---
+
+// WriteCassette 以 JSONL 格式写出，每行一个 Interaction
+func WriteCassette(w io.Writer, interactions []Interaction) error {
+	enc := json.NewEncoder(w)
+	for _, it := range interactions {
+		if err := enc.Encode(it); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadCassette 读取 JSONL 录制文件，忽略空行
+func ReadCassette(r io.Reader) ([]Interaction, error) {
+	var out []Interaction
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		var it Interaction
+		if err := json.Unmarshal([]byte(text), &it); err != nil {
+			return nil, fmt.Errorf("录制文件第 %d 行: %w", line, err)
+		}
+		out = append(out, it)
+	}
+	return out, sc.Err()
+}
+
+// WriteHAR 以 HAR 1.2 格式写出，流式分片放在扩展字段 _chunks 中
+func WriteHAR(w io.Writer, interactions []Interaction) error {
+	type nv struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	pairs := func(m map[string]string) []nv {
+		out := make([]nv, 0, len(m))
+		for k, v := range m {
+			out = append(out, nv{k, v})
+		}
+		return out
+	}
+
+	entries := make([]map[string]any, 0, len(interactions))
+	for _, it := range interactions {
+		var query []nv
+		if u, err := url.Parse(it.Request.URL); err == nil {
+			for k, vs := range u.Query() {
+				for _, v := range vs {
+					query = append(query, nv{k, v})
+				}
+			}
+		}
+		if query == nil {
+			query = []nv{}
+		}
+		request := map[string]any{
+			"method":      it.Request.Method,
+			"url":         it.Request.URL,
+			"httpVersion": "HTTP/1.1",
+			"headers":     pairs(it.Request.Header),
+			"queryString": query,
+			"cookies":     []nv{},
+			"headersSize": -1,
+			"bodySize":    len(it.Request.Body),
+		}
+		if it.Request.Body != "" {
+			request["postData"] = map[string]string{"mimeType": it.Request.Header["Content-Type"], "text": it.Request.Body}
+		}
+		entry := map[string]any{
+			"startedDateTime": it.StartedAt,
+			"time":            it.Duration,
+			"request":         request,
+			"response": map[string]any{
+				"status":      it.Response.Status,
+				"statusText":  http.StatusText(it.Response.Status),
+				"httpVersion": "HTTP/1.1",
+				"headers":     pairs(it.Response.Header),
+				"cookies":     []nv{},
+				"content": map[string]any{
+					"size":     len(it.Response.Body),
+					"mimeType": it.Response.Header["Content-Type"],
+					"text":     it.Response.Body,
+				},
+				"redirectURL": "",
+				"headersSize": -1,
+				"bodySize":    len(it.Response.Body),
+			},
+			"cache": map[string]any{},
+			"timings": map[string]int64{
+				"send":    0,
+				"wait":    it.HeaderAt,
+				"receive": it.Duration - it.HeaderAt,
+			},
+		}
+		if len(it.Response.Chunks) > 0 {
+			entry["_chunks"] = it.Response.Chunks
+		}
+		if it.Error != "" {
+			entry["_error"] = it.Error
+		}
+		entries = append(entries, entry)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{
+		"log": map[string]any{
+			"version": "1.2",
+			"creator": map[string]string{"name": "PingAI", "version": "1"},
+			"entries": entries,
+		},
+	})
+}
+
+// --- 回放 ---
+
+// ErrNoInteraction 录制中没有与请求匹配的记录
+var ErrNoInteraction = errors.New("录制中没有匹配的请求")
+
+// Replayer 按录制内容应答请求，可作为 WithTransport 的参数
+type Replayer struct {
+	// NoDelay 为 true 时立即返回，否则按录制时的响应头和分片时间回放
+	NoDelay bool
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer 创建回放器
+func NewReplayer(interactions []Interaction) *Replayer {
+	return &Replayer{interactions: interactions, used: make([]bool, len(interactions))}
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	var body string
+	if req.Body != nil {
+		b, _ := io.ReadAll(req.Body)
+		req.Body.Close()
+		body = string(b)
+	}
+
+	it, ok := r.match(req.Method, req.URL, body)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL.Path)
+	}
+	if err := r.wait(req.Context(), start, it.HeaderAt); err != nil {
+		return nil, err
+	}
+	if it.Error != "" {
+		return nil, errors.New(it.Error)
+	}
+
+	header := make(http.Header, len(it.Response.Header))
+	for k, v := range it.Response.Header {
+		header.Set(k, v)
+	}
+	var rc io.ReadCloser = io.NopCloser(strings.NewReader(it.Response.Body))
+	if len(it.Response.Chunks) > 0 {
+		rc = &chunkReader{r: r, ctx: req.Context(), start: start, chunks: it.Response.Chunks}
+	}
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", it.Response.Status, http.StatusText(it.Response.Status)),
+		StatusCode: it.Response.Status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+		Body:       rc,
+		Request:    req,
+	}, nil
+}
+
+// match 依次按 方法+路径+请求体、方法+路径 查找尚未使用的记录
+func (r *Replayer) match(method string, u *url.URL, body string) (Interaction, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, exact := range []bool{true, false} {
+		for i, it := range r.interactions {
+			if r.used[i] || it.Request.Method != method {
+				continue
+			}
+			recorded, err := url.Parse(it.Request.URL)
+			if err != nil || recorded.Path != u.Path {
+				continue
+			}
+			if exact && it.Request.Body != body {
+				continue
+			}
+			r.used[i] = true
+			return it, true
+		}
+	}
+	return Interaction{}, false
+}
+
+// wait 等到距 start 的 offset 毫秒
+func (r *Replayer) wait(ctx context.Context, start time.Time, offset int64) error {
+	if r.NoDelay {
+		return nil
+	}
+	d := time.Until(start.Add(time.Duration(offset) * time.Millisecond))
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// chunkReader 按录制时间逐个返回分片
+type chunkReader struct {
+	r      *Replayer
+	ctx    context.Context
+	start  time.Time
+	chunks []Chunk
+	buf    []byte
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if len(c.buf) == 0 {
+		if len(c.chunks) == 0 {
+			return 0, io.EOF
+		}
+		next := c.chunks[0]
+		c.chunks = c.chunks[1:]
+		if err := c.r.wait(c.ctx, c.start, next.Offset); err != nil {
+			return 0, err
+		}
+		c.buf = []byte(next.Data)
+	}
+	n := copy(p, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+func (c *chunkReader) Close() error {
+	return nil
+}
@@ -0,0 +1,122 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"pingai/internal/mockprovider"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordReplay(t *testing.T) {
+	srv := mockprovider.NewServer(mockprovider.Behavior{APIKey: "sk-mock", ChunkDelay: 20 * time.Millisecond})
+	base := map[Protocol]string{}
+	for _, p := range protocols {
+		base[p] = srv.BaseURL(string(p))
+	}
+
+	type result struct{ chat, stream string }
+	run := func(ctx context.Context) (map[Protocol]result, error) {
+		out := map[Protocol]result{}
+		for _, p := range protocols {
+			adapter := GetAdapter(p)
+			chat, err := adapter.Chat(ctx, chatReq(base[p], "m-1", "Hi"))
+			if err != nil {
+				return nil, err
+			}
+			stream, err := adapter.ChatStream(ctx, chatReq(base[p], "m-1", "Count from 1 to 5"), func(string, bool) {})
+			if err != nil {
+				return nil, err
+			}
+			out[p] = result{chat.Content, stream.Content}
+		}
+		return out, nil
+	}
+
+	rec := NewRecorder(nil, "sk-mock")
+	want, err := run(WithTransport(context.Background(), rec))
+	if err != nil {
+		t.Fatalf("录制失败: %v", err)
+	}
+	srv.Close()
+
+	interactions := rec.Interactions()
+	if len(interactions) != 2*len(protocols) {
+		t.Fatalf("录制了 %d 个请求, 期望 %d", len(interactions), 2*len(protocols))
+	}
+	var cassette bytes.Buffer
+	if err := WriteCassette(&cassette, interactions); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(cassette.String(), "sk-mock") {
+		t.Errorf("录制内容未脱敏:\n%s", cassette.String())
+	}
+	stream := interactions[1]
+	if len(stream.Response.Chunks) < 2 || stream.Response.Chunks[len(stream.Response.Chunks)-1].Offset < 20 {
+		t.Errorf("流式分片时间未记录: %+v", stream.Response.Chunks)
+	}
+
+	loaded, err := ReadCassette(&cassette)
+	if err != nil || len(loaded) != len(interactions) {
+		t.Fatalf("ReadCassette = %d, %v", len(loaded), err)
+	}
+	replayer := NewReplayer(loaded)
+	start := time.Now()
+	got, err := run(WithTransport(context.Background(), replayer))
+	if err != nil {
+		t.Fatalf("回放失败: %v", err)
+	}
+	for p, r := range want {
+		if got[p] != r {
+			t.Errorf("%s: 回放结果 %+v, 录制时 %+v", p, got[p], r)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("回放未按分片时间等待, 耗时 %v", elapsed)
+	}
+
+	// 每条记录只使用一次
+	_, err = GetAdapter(ProtocolOpenAI).Chat(WithTransport(context.Background(), replayer), chatReq(base[ProtocolOpenAI], "m-1", "Hi"))
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("记录用完后应返回 ErrNoInteraction, 得到 %v", err)
+	}
+}
+
+func TestWriteHAR(t *testing.T) {
+	interactions := []Interaction{{
+		StartedAt: "2026-01-01T00:00:00Z",
+		HeaderAt:  10,
+		Duration:  30,
+		Request:   RecordedRequest{Method: "POST", URL: "https://x/v1/models?key=REDACTED", Header: map[string]string{"Content-Type": "application/json"}, Body: "{}"},
+		Response:  RecordedResponse{Status: 200, Header: map[string]string{}, Body: "data: a\n\n", Chunks: []Chunk{{Offset: 20, Data: "data: a\n\n"}}},
+	}}
+	var buf bytes.Buffer
+	if err := WriteHAR(&buf, interactions); err != nil {
+		t.Fatal(err)
+	}
+	var har struct {
+		Log struct {
+			Version string `json:"version"`
+			Entries []struct {
+				Request struct {
+					QueryString []struct{ Name, Value string } `json:"queryString"`
+				} `json:"request"`
+				Timings struct{ Wait, Receive int64 } `json:"timings"`
+				Chunks  []Chunk                       `json:"_chunks"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &har); err != nil {
+		t.Fatalf("HAR 不是合法 JSON: %v", err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 1 {
+		t.Fatalf("HAR = %s", buf.String())
+	}
+	e := har.Log.Entries[0]
+	if len(e.Request.QueryString) != 1 || e.Timings.Wait != 10 || e.Timings.Receive != 20 || len(e.Chunks) != 1 {
+		t.Errorf("HAR entry = %+v", e)
+	}
+}
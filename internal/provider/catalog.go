@@ -0,0 +1,157 @@
+package provider
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ModelAlias 规范模型名及其在各供应商处的模型 ID
+type ModelAlias struct {
+	Canonical string            `json:"canonical"`
+	IDs       map[string]string `json:"ids"` // 供应商 ID -> 模型 ID
+}
+
+// DefaultAliases 内置预设供应商的模型别名
+func DefaultAliases() []ModelAlias {
+	return []ModelAlias{
+		{Canonical: "gpt-4o", IDs: map[string]string{"openai": "gpt-4o", "openrouter": "openai/gpt-4o"}},
+		{Canonical: "gpt-4o-mini", IDs: map[string]string{"openai": "gpt-4o-mini", "openrouter": "openai/gpt-4o-mini"}},
+		{Canonical: "o3-mini", IDs: map[string]string{"openai": "o3-mini", "openrouter": "openai/o3-mini"}},
+		{Canonical: "claude-sonnet-4", IDs: map[string]string{"anthropic": "claude-sonnet-4-20250514", "openrouter": "anthropic/claude-sonnet-4"}},
+		{Canonical: "claude-sonnet-4.5", IDs: map[string]string{"anthropic": "claude-sonnet-4-5", "openrouter": "anthropic/claude-sonnet-4.5", "antigravity": "claude-sonnet-4-5"}},
+		{Canonical: "claude-3.5-sonnet", IDs: map[string]string{"anthropic": "claude-3-5-sonnet-20241022", "openrouter": "anthropic/claude-3.5-sonnet"}},
+		{Canonical: "claude-3.5-haiku", IDs: map[string]string{"anthropic": "claude-3-5-haiku-20241022", "openrouter": "anthropic/claude-3.5-haiku"}},
+		{Canonical: "claude-3-opus", IDs: map[string]string{"anthropic": "claude-3-opus-20240229", "openrouter": "anthropic/claude-3-opus"}},
+		{Canonical: "gemini-2.0-flash", IDs: map[string]string{"gemini": "gemini-2.0-flash", "openrouter": "google/gemini-2.0-flash-001"}},
+		{Canonical: "gemini-1.5-pro", IDs: map[string]string{"gemini": "gemini-1.5-pro", "openrouter": "google/gemini-pro-1.5"}},
+		{Canonical: "gemini-3-flash", IDs: map[string]string{"antigravity": "gemini-3-flash", "gemini": "gemini-3-flash-preview"}},
+		{Canonical: "deepseek-v3", IDs: map[string]string{"deepseek": "deepseek-chat", "siliconflow": "deepseek-ai/DeepSeek-V3", "openrouter": "deepseek/deepseek-chat"}},
+		{Canonical: "deepseek-r1", IDs: map[string]string{"deepseek": "deepseek-reasoner", "siliconflow": "deepseek-ai/DeepSeek-R1", "openrouter": "deepseek/deepseek-r1", "ollama": "deepseek-r1"}},
+		{Canonical: "qwen-max", IDs: map[string]string{"qwen": "qwen-max", "openrouter": "qwen/qwen-max"}},
+		{Canonical: "qwen2.5-72b", IDs: map[string]string{"siliconflow": "Qwen/Qwen2.5-72B-Instruct", "openrouter": "qwen/qwen-2.5-72b-instruct", "ollama": "qwen2.5:72b"}},
+		{Canonical: "llama-3.3-70b", IDs: map[string]string{"groq": "llama-3.3-70b-versatile", "siliconflow": "meta-llama/Llama-3.3-70B-Instruct", "openrouter": "meta-llama/llama-3.3-70b-instruct", "ollama": "llama3.3"}},
+		{Canonical: "llama-3.1-8b", IDs: map[string]string{"groq": "llama-3.1-8b-instant", "openrouter": "meta-llama/llama-3.1-8b-instruct", "ollama": "llama3.1:8b"}},
+		{Canonical: "gemma2-9b", IDs: map[string]string{"groq": "gemma2-9b-it", "openrouter": "google/gemma-2-9b-it", "ollama": "gemma2"}},
+		{Canonical: "mistral-large", IDs: map[string]string{"mistral": "mistral-large-latest", "openrouter": "mistralai/mistral-large"}},
+		{Canonical: "mistral-nemo", IDs: map[string]string{"mistral": "open-mistral-nemo", "openrouter": "mistralai/mistral-nemo"}},
+		{Canonical: "glm-4-plus", IDs: map[string]string{"zhipu": "glm-4-plus"}},
+		{Canonical: "moonshot-v1-8k", IDs: map[string]string{"moonshot": "moonshot-v1-8k"}},
+		{Canonical: "doubao-1.5-pro-32k", IDs: map[string]string{"doubao": "doubao-1.5-pro-32k"}},
+		{Canonical: "baichuan4", IDs: map[string]string{"baichuan": "Baichuan4"}},
+		{Canonical: "yi-large", IDs: map[string]string{"lingyiwanwu": "yi-large"}},
+		{Canonical: "phi-4", IDs: map[string]string{"ollama": "phi4", "openrouter": "microsoft/phi-4"}},
+	}
+}
+
+// Catalog 模型别名目录，可并发使用
+type Catalog struct {
+	mu      sync.RWMutex
+	aliases map[string]*ModelAlias // 小写规范名 -> 别名
+	byID    map[string]string      // 小写模型 ID -> 小写规范名
+}
+
+// NewCatalog 创建别名目录，后出现的映射覆盖先出现的
+func NewCatalog(aliases ...[]ModelAlias) *Catalog {
+	c := &Catalog{}
+	c.Set(aliases...)
+	return c
+}
+
+// Set 替换目录内容
+func (c *Catalog) Set(aliases ...[]ModelAlias) {
+	m := make(map[string]*ModelAlias)
+	for _, list := range aliases {
+		for _, a := range list {
+			key := strings.ToLower(strings.TrimSpace(a.Canonical))
+			if key == "" {
+				continue
+			}
+			entry, ok := m[key]
+			if !ok {
+				entry = &ModelAlias{Canonical: strings.TrimSpace(a.Canonical), IDs: make(map[string]string)}
+				m[key] = entry
+			}
+			for providerID, id := range a.IDs {
+				if id = strings.TrimSpace(id); id != "" {
+					entry.IDs[providerID] = id
+				}
+			}
+		}
+	}
+	byID := make(map[string]string)
+	for key, a := range m {
+		for _, id := range a.IDs {
+			// 同一 ID 属于多个规范名时取名称最小的，保证结果稳定
+			lower := strings.ToLower(id)
+			if old, ok := byID[lower]; !ok || key < old {
+				byID[lower] = key
+			}
+		}
+	}
+
+	c.mu.Lock()
+	c.aliases, c.byID = m, byID
+	c.mu.Unlock()
+}
+
+// lookup 按规范名或任一供应商的模型 ID 查找别名
+func (c *Catalog) lookup(model string) *ModelAlias {
+	key := strings.ToLower(strings.TrimSpace(model))
+	if a, ok := c.aliases[key]; ok {
+		return a
+	}
+	if canonical, ok := c.byID[key]; ok {
+		return c.aliases[canonical]
+	}
+	return nil
+}
+
+// Resolve 将规范名 (或其他供应商的模型 ID) 解析为该供应商的模型 ID，没有映射时原样返回
+func (c *Catalog) Resolve(model, providerID string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if a := c.lookup(model); a != nil {
+		if id, ok := a.IDs[providerID]; ok {
+			return id
+		}
+	}
+	return model
+}
+
+// ResolveOwn 与 Resolve 相同，但 model 是该供应商自己的模型 ID (own，如预设或列表接口返回的模型) 时原样返回，
+// 避免明确配置的模型被替换成目录中的另一个 ID
+func (c *Catalog) ResolveOwn(model, providerID string, own []string) string {
+	for _, id := range own {
+		if strings.EqualFold(strings.TrimSpace(id), strings.TrimSpace(model)) {
+			return model
+		}
+	}
+	return c.Resolve(model, providerID)
+}
+
+// Canonical 返回模型对应的规范名，不在目录中时返回空字符串
+func (c *Catalog) Canonical(model string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if a := c.lookup(model); a != nil {
+		return a.Canonical
+	}
+	return ""
+}
+
+// Aliases 按规范名排序返回目录内容的副本
+func (c *Catalog) Aliases() []ModelAlias {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]ModelAlias, 0, len(c.aliases))
+	for _, a := range c.aliases {
+		ids := make(map[string]string, len(a.IDs))
+		for k, v := range a.IDs {
+			ids[k] = v
+		}
+		out = append(out, ModelAlias{Canonical: a.Canonical, IDs: ids})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Canonical < out[j].Canonical })
+	return out
+}
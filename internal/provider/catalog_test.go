@@ -0,0 +1,79 @@
+package provider
+
+import "testing"
+
+func TestDefaultAliasesCoverPresets(t *testing.T) {
+	covered := make(map[string]bool)
+	seen := make(map[string]string) // 供应商/模型 ID -> 规范名
+	for _, a := range DefaultAliases() {
+		if a.Canonical == "" || len(a.IDs) == 0 {
+			t.Errorf("别名无效: %+v", a)
+		}
+		for providerID, id := range a.IDs {
+			covered[providerID] = true
+			key := providerID + "/" + id
+			if old, ok := seen[key]; ok {
+				t.Errorf("%s 同时属于 %s 和 %s", key, old, a.Canonical)
+			}
+			seen[key] = a.Canonical
+		}
+	}
+	for _, p := range GetPresets() {
+		if !covered[p.ID] {
+			t.Errorf("预设 %q 没有内置别名", p.ID)
+		}
+	}
+}
+
+func TestCatalogResolve(t *testing.T) {
+	c := NewCatalog(DefaultAliases(), []ModelAlias{
+		{Canonical: "DeepSeek-V3", IDs: map[string]string{"myrelay": "ds-v3"}},
+		{Canonical: "my-model", IDs: map[string]string{"myrelay": "x"}},
+	})
+	cases := []struct {
+		model, providerID, want string
+	}{
+		{"deepseek-v3", "deepseek", "deepseek-chat"},
+		{"deepseek-v3", "siliconflow", "deepseek-ai/DeepSeek-V3"},
+		{"DeepSeek-V3", "openrouter", "deepseek/deepseek-chat"},
+		{"deepseek-v3", "myrelay", "ds-v3"},
+		// 其他供应商的模型 ID 也可解析
+		{"deepseek-chat", "siliconflow", "deepseek-ai/DeepSeek-V3"},
+		{"deepseek-ai/deepseek-v3", "openrouter", "deepseek/deepseek-chat"},
+		// 没有映射时原样返回
+		{"deepseek-v3", "groq", "deepseek-v3"},
+		{"unknown", "openai", "unknown"},
+	}
+	for _, cs := range cases {
+		if got := c.Resolve(cs.model, cs.providerID); got != cs.want {
+			t.Errorf("Resolve(%q, %q) = %q, 期望 %q", cs.model, cs.providerID, got, cs.want)
+		}
+	}
+	// 供应商自己的模型 ID 不替换，规范名和其他供应商的 ID 照常解析
+	own := []string{"gemini-3-flash", "gemini-2.0-flash"}
+	if got := c.ResolveOwn("gemini-3-flash", "gemini", own); got != "gemini-3-flash" {
+		t.Errorf("ResolveOwn(自有 ID) = %q", got)
+	}
+	if got := c.ResolveOwn("gemini-3-flash", "gemini", nil); got != "gemini-3-flash-preview" {
+		t.Errorf("ResolveOwn(规范名) = %q", got)
+	}
+	if got := c.ResolveOwn("google/gemini-2.0-flash-001", "gemini", own); got != "gemini-2.0-flash" {
+		t.Errorf("ResolveOwn(其他供应商 ID) = %q", got)
+	}
+
+	if got := c.Canonical("deepseek/deepseek-chat"); got != "deepseek-v3" {
+		t.Errorf("Canonical = %q", got)
+	}
+	if c.Canonical("unknown") != "" {
+		t.Error("未知模型的规范名应为空")
+	}
+
+	aliases := c.Aliases()
+	if len(aliases) != len(DefaultAliases())+1 {
+		t.Errorf("Aliases 数量 = %d", len(aliases))
+	}
+	aliases[0].IDs["openai"] = "changed"
+	if c.Aliases()[0].IDs["openai"] == "changed" {
+		t.Error("Aliases 应返回副本")
+	}
+}
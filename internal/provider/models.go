@@ -0,0 +1,143 @@
+package provider
+
+import "pingai/internal/protocol"
+
+// 模态组合
+var (
+	textOnly   = []string{"text"}
+	textImage  = []string{"text", "image"}
+	multimodal = []string{"text", "image", "audio", "video"}
+)
+
+// modelInfo 构造预设模型元数据，价格为每百万 token，currency 为空表示价格未知
+func modelInfo(id string, contextWindow, maxOutput int, in, out float64, currency string, tools bool, input []string) protocol.ModelInfo {
+	return protocol.ModelInfo{
+		ID:               id,
+		ContextWindow:    contextWindow,
+		MaxOutput:        maxOutput,
+		InputModalities:  input,
+		OutputModalities: textOnly,
+		Tools:            &tools,
+		InputPrice:       in,
+		OutputPrice:      out,
+		Currency:         currency,
+		Source:           "preset",
+	}
+}
+
+// presetModelInfo 预设模型的元数据，价格为官方列表价，仅供参考
+var presetModelInfo = map[string][]protocol.ModelInfo{
+	"openai": {
+		modelInfo("gpt-4o", 128000, 16384, 2.5, 10, "USD", true, textImage),
+		modelInfo("gpt-4o-mini", 128000, 16384, 0.15, 0.6, "USD", true, textImage),
+		modelInfo("gpt-4-turbo", 128000, 4096, 10, 30, "USD", true, textImage),
+		modelInfo("gpt-4", 8192, 8192, 30, 60, "USD", true, textOnly),
+		modelInfo("gpt-3.5-turbo", 16385, 4096, 0.5, 1.5, "USD", true, textOnly),
+		modelInfo("o1", 200000, 100000, 15, 60, "USD", true, textImage),
+		modelInfo("o1-mini", 128000, 65536, 1.1, 4.4, "USD", false, textOnly),
+		modelInfo("o3-mini", 200000, 100000, 1.1, 4.4, "USD", true, textOnly),
+	},
+	"anthropic": {
+		modelInfo("claude-sonnet-4-20250514", 200000, 64000, 3, 15, "USD", true, textImage),
+		modelInfo("claude-haiku-4-20250414", 200000, 8192, 0, 0, "", true, textImage),
+		modelInfo("claude-3-5-sonnet-20241022", 200000, 8192, 3, 15, "USD", true, textImage),
+		modelInfo("claude-3-5-haiku-20241022", 200000, 8192, 0.8, 4, "USD", true, textOnly),
+		modelInfo("claude-3-opus-20240229", 200000, 4096, 15, 75, "USD", true, textImage),
+	},
+	"gemini": {
+		modelInfo("gemini-2.0-flash", 1048576, 8192, 0.1, 0.4, "USD", true, multimodal),
+		modelInfo("gemini-2.0-flash-lite", 1048576, 8192, 0.075, 0.3, "USD", false, multimodal),
+		modelInfo("gemini-1.5-pro", 2097152, 8192, 1.25, 5, "USD", true, multimodal),
+		modelInfo("gemini-1.5-flash", 1048576, 8192, 0.075, 0.3, "USD", true, multimodal),
+	},
+	"deepseek": {
+		modelInfo("deepseek-chat", 64000, 8192, 2, 8, "CNY", true, textOnly),
+		modelInfo("deepseek-reasoner", 64000, 8192, 4, 16, "CNY", false, textOnly),
+	},
+	"qwen": {
+		modelInfo("qwen-max", 32768, 8192, 2.4, 9.6, "CNY", true, textOnly),
+		modelInfo("qwen-plus", 131072, 8192, 0.8, 2, "CNY", true, textOnly),
+		modelInfo("qwen-turbo", 1000000, 8192, 0.3, 0.6, "CNY", true, textOnly),
+		modelInfo("qwen-long", 10000000, 8192, 0.5, 2, "CNY", false, textOnly),
+		modelInfo("qwen-vl-max", 32768, 2048, 3, 9, "CNY", false, textImage),
+	},
+	"doubao": {
+		modelInfo("doubao-1.5-pro-32k", 32768, 12288, 0.8, 2, "CNY", true, textOnly),
+		modelInfo("doubao-1.5-lite-32k", 32768, 12288, 0.3, 0.6, "CNY", true, textOnly),
+		modelInfo("doubao-pro-32k", 32768, 4096, 0.8, 2, "CNY", true, textOnly),
+		modelInfo("doubao-lite-32k", 32768, 4096, 0.3, 0.6, "CNY", true, textOnly),
+	},
+	"zhipu": {
+		modelInfo("glm-4-plus", 128000, 4096, 50, 50, "CNY", true, textOnly),
+		modelInfo("glm-4", 128000, 4096, 100, 100, "CNY", true, textOnly),
+		modelInfo("glm-4-flash", 128000, 4096, 0, 0, "CNY", true, textOnly),
+		modelInfo("glm-4-long", 1000000, 4096, 1, 1, "CNY", true, textOnly),
+	},
+	"moonshot": {
+		modelInfo("moonshot-v1-8k", 8192, 0, 12, 12, "CNY", true, textOnly),
+		modelInfo("moonshot-v1-32k", 32768, 0, 24, 24, "CNY", true, textOnly),
+		modelInfo("moonshot-v1-128k", 131072, 0, 60, 60, "CNY", true, textOnly),
+	},
+	"baichuan": {
+		modelInfo("Baichuan4", 32768, 0, 100, 100, "CNY", true, textOnly),
+		modelInfo("Baichuan3-Turbo", 32768, 0, 12, 12, "CNY", true, textOnly),
+		modelInfo("Baichuan3-Turbo-128k", 131072, 0, 24, 24, "CNY", true, textOnly),
+	},
+	"siliconflow": {
+		modelInfo("deepseek-ai/DeepSeek-V3", 64000, 8192, 2, 8, "CNY", true, textOnly),
+		modelInfo("deepseek-ai/DeepSeek-R1", 64000, 16384, 4, 16, "CNY", false, textOnly),
+		modelInfo("Qwen/Qwen2.5-72B-Instruct", 32768, 4096, 4.13, 4.13, "CNY", true, textOnly),
+		modelInfo("meta-llama/Llama-3.3-70B-Instruct", 32768, 4096, 4.13, 4.13, "CNY", true, textOnly),
+	},
+	"lingyiwanwu": {
+		modelInfo("yi-large", 32768, 0, 20, 20, "CNY", false, textOnly),
+		modelInfo("yi-medium", 16384, 0, 2.5, 2.5, "CNY", false, textOnly),
+		modelInfo("yi-spark", 16384, 0, 1, 1, "CNY", false, textOnly),
+	},
+	"groq": {
+		modelInfo("llama-3.3-70b-versatile", 131072, 32768, 0.59, 0.79, "USD", true, textOnly),
+		modelInfo("llama-3.1-8b-instant", 131072, 8192, 0.05, 0.08, "USD", true, textOnly),
+		modelInfo("mixtral-8x7b-32768", 32768, 32768, 0.24, 0.24, "USD", true, textOnly),
+		modelInfo("gemma2-9b-it", 8192, 8192, 0.2, 0.2, "USD", true, textOnly),
+	},
+	"mistral": {
+		modelInfo("mistral-large-latest", 131072, 0, 2, 6, "USD", true, textOnly),
+		modelInfo("mistral-medium-latest", 131072, 0, 0.4, 2, "USD", true, textImage),
+		modelInfo("mistral-small-latest", 32768, 0, 0.1, 0.3, "USD", true, textImage),
+		modelInfo("open-mistral-nemo", 131072, 0, 0.15, 0.15, "USD", true, textOnly),
+	},
+	"openrouter": {
+		modelInfo("openai/gpt-4o", 128000, 16384, 2.5, 10, "USD", true, textImage),
+		modelInfo("anthropic/claude-3.5-sonnet", 200000, 8192, 3, 15, "USD", true, textImage),
+		modelInfo("google/gemini-2.0-flash-exp:free", 1048576, 8192, 0, 0, "USD", true, multimodal),
+		modelInfo("meta-llama/llama-3.3-70b-instruct", 131072, 0, 0.12, 0.3, "USD", true, textOnly),
+	},
+	"antigravity": {
+		modelInfo("gemini-3-flash", 1048576, 65536, 0, 0, "", true, multimodal),
+		modelInfo("gemini-3-pro-high", 1048576, 65536, 0, 0, "", true, multimodal),
+		modelInfo("claude-sonnet-4-5", 200000, 64000, 0, 0, "", true, textImage),
+	},
+	// 本地模型，不计费
+	"ollama": {
+		modelInfo("llama3.3", 131072, 0, 0, 0, "USD", true, textOnly),
+		modelInfo("qwen2.5", 32768, 0, 0, 0, "USD", true, textOnly),
+		modelInfo("deepseek-r1", 131072, 0, 0, 0, "USD", false, textOnly),
+		modelInfo("gemma2", 8192, 0, 0, 0, "USD", false, textOnly),
+		modelInfo("phi4", 16384, 0, 0, 0, "USD", false, textOnly),
+	},
+}
+
+// PresetModelInfo 返回预设供应商的模型元数据
+func PresetModelInfo(providerID string) []protocol.ModelInfo {
+	return append([]protocol.ModelInfo(nil), presetModelInfo[providerID]...)
+}
+
+// LookupPresetModelInfo 查找预设模型的元数据
+func LookupPresetModelInfo(providerID, model string) (protocol.ModelInfo, bool) {
+	for _, m := range presetModelInfo[providerID] {
+		if m.ID == model {
+			return m, true
+		}
+	}
+	return protocol.ModelInfo{}, false
+}
@@ -72,3 +72,24 @@ func TestPresetsProtocolDistribution(t *testing.T) {
 		t.Error("缺少 gemini 协议供应商")
 	}
 }
+
+func TestPresetModelInfo(t *testing.T) {
+	for _, p := range GetPresets() {
+		for _, m := range p.Models {
+			info, ok := LookupPresetModelInfo(p.ID, m)
+			if !ok {
+				t.Errorf("预设 %q 模型 %q 缺少元数据", p.ID, m)
+				continue
+			}
+			if info.ContextWindow <= 0 || info.Tools == nil || !info.OutputsText() || info.Source != "preset" {
+				t.Errorf("预设 %q 模型 %q 元数据无效: %+v", p.ID, m, info)
+			}
+			if info.Currency != "" && info.Currency != "USD" && info.Currency != "CNY" {
+				t.Errorf("预设 %q 模型 %q 币种无效: %q", p.ID, m, info.Currency)
+			}
+		}
+		if n := len(PresetModelInfo(p.ID)); n != len(p.Models) {
+			t.Errorf("预设 %q 元数据 %d 条, 模型 %d 个", p.ID, n, len(p.Models))
+		}
+	}
+}
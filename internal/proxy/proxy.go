@@ -0,0 +1,354 @@
+// Package proxy 提供 OpenAI 兼容的本地代理，按最近检测结论选择健康的上游并自动故障转移
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"pingai/internal/protocol"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Target 可转发的上游：一个供应商配置加一个 API Key
+type Target struct {
+	ProviderID string   `json:"providerID"`
+	Name       string   `json:"name"`
+	BaseURL    string   `json:"baseURL"`
+	APIKey     string   `json:"-"`
+	Protocol   string   `json:"protocol"`
+	Models     []string `json:"models"`  // 可服务的模型，为空表示不限
+	Status     string   `json:"status"`  // 最近一次检测结论 success / warning / failed，空表示未检测
+	Latency    int64    `json:"latency"` // 最近一次检测耗时 (ms)，0 表示未知
+}
+
+// Source 返回当前的上游池，每个请求调用一次
+type Source func() ([]Target, error)
+
+// DefaultCooldown 上游返回 429/5xx 或网络错误后暂时降级的时长
+const DefaultCooldown = 30 * time.Second
+
+// Proxy 代理服务
+type Proxy struct {
+	source   Source
+	cooldown time.Duration
+	client   *http.Client
+	now      func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	penalties map[string]time.Time // 上游 -> 降级截止时间
+
+	srv *http.Server
+	ln  net.Listener
+}
+
+// New 创建代理，token 为空时拒绝所有请求
+func New(source Source, token string) *Proxy {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 60 * time.Second
+	return &Proxy{
+		source:    source,
+		cooldown:  DefaultCooldown,
+		client:    &http.Client{Transport: transport},
+		now:       time.Now,
+		token:     token,
+		penalties: make(map[string]time.Time),
+	}
+}
+
+// SetToken 更换访问令牌，旧令牌立即失效
+func (p *Proxy) SetToken(token string) {
+	p.mu.Lock()
+	p.token = token
+	p.mu.Unlock()
+}
+
+// Handler 返回路由
+func (p *Proxy) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("POST /v1/chat/completions", p.auth(p.handleChat))
+	mux.Handle("GET /v1/models", p.auth(p.handleModels))
+	return mux
+}
+
+// Listen 在 addr 上启动代理
+func (p *Proxy) Listen(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	p.ln = ln
+	p.srv = &http.Server{Handler: p.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go p.srv.Serve(ln)
+	return nil
+}
+
+// Addr 实际监听地址
+func (p *Proxy) Addr() string {
+	if p.ln == nil {
+		return ""
+	}
+	return p.ln.Addr().String()
+}
+
+// Close 停止代理
+func (p *Proxy) Close() error {
+	if p.srv == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return p.srv.Shutdown(ctx)
+}
+
+// auth 校验 Authorization: Bearer <token>
+func (p *Proxy) auth(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		token := p.token
+		p.mu.Unlock()
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid_api_key", "unauthorized")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// Candidates 返回可服务 model 的上游，按检测结论、是否降级和延迟排序
+func (p *Proxy) Candidates(model string) ([]Target, error) {
+	targets, err := p.source()
+	if err != nil {
+		return nil, err
+	}
+	var out []Target
+	for _, t := range targets {
+		if t.BaseURL == "" || t.APIKey == "" {
+			continue
+		}
+		if len(t.Models) > 0 && !contains(t.Models, model) {
+			continue
+		}
+		out = append(out, t)
+	}
+
+	now := p.now()
+	p.mu.Lock()
+	cooling := make(map[string]bool, len(out))
+	for _, t := range out {
+		if until, ok := p.penalties[t.key()]; ok && now.Before(until) {
+			cooling[t.key()] = true
+		}
+	}
+	p.mu.Unlock()
+
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if cooling[a.key()] != cooling[b.key()] {
+			return !cooling[a.key()]
+		}
+		if ra, rb := statusRank(a.Status), statusRank(b.Status); ra != rb {
+			return ra < rb
+		}
+		// 延迟未知的排在已知之后
+		if (a.Latency == 0) != (b.Latency == 0) {
+			return a.Latency != 0
+		}
+		return a.Latency < b.Latency
+	})
+	return out, nil
+}
+
+// penalize 上游失败后在冷却期内降级
+func (p *Proxy) penalize(t Target) {
+	p.mu.Lock()
+	p.penalties[t.key()] = p.now().Add(p.cooldown)
+	p.mu.Unlock()
+}
+
+func (t Target) key() string {
+	return t.ProviderID + "\x00" + t.BaseURL + "\x00" + t.APIKey
+}
+
+func statusRank(status string) int {
+	switch status {
+	case "success":
+		return 0
+	case "warning":
+		return 1
+	case "failed":
+		return 3
+	}
+	return 2
+}
+
+// retryable 429 和 5xx 换下一个上游重试
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+func (p *Proxy) handleChat(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 32<<20))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	var req chatRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "invalid JSON body: "+err.Error())
+		return
+	}
+	if req.Model == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "model is required")
+		return
+	}
+
+	candidates, err := p.Candidates(req.Model)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	if len(candidates) == 0 {
+		writeError(w, http.StatusNotFound, "model_not_found", fmt.Sprintf("no upstream serves model %q", req.Model))
+		return
+	}
+
+	var failures []string
+	for _, t := range candidates {
+		var done bool
+		var err error
+		if protocol.Protocol(t.Protocol) == protocol.ProtocolAnthropic || protocol.Protocol(t.Protocol) == protocol.ProtocolGemini {
+			done, err = p.forwardAdapter(w, r, t, req)
+		} else {
+			done, err = p.forwardOpenAI(w, r, t, body)
+		}
+		if done {
+			return
+		}
+		p.penalize(t)
+		failures = append(failures, fmt.Sprintf("%s: %v", t.ProviderID, err))
+		if r.Context().Err() != nil {
+			return
+		}
+	}
+	writeError(w, http.StatusBadGateway, "upstream_error", "all upstreams failed: "+strings.Join(failures, "; "))
+}
+
+// forwardOpenAI 原样转发给 OpenAI 兼容上游，done 为 false 表示可以换下一个上游
+func (p *Proxy) forwardOpenAI(w http.ResponseWriter, r *http.Request, t Target, body []byte) (done bool, err error) {
+	url := strings.TrimSuffix(t.BaseURL, "/") + "/chat/completions"
+	upReq, err := http.NewRequestWithContext(r.Context(), "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	upReq.Header.Set("Content-Type", "application/json")
+	upReq.Header.Set("Authorization", "Bearer "+t.APIKey)
+	if accept := r.Header.Get("Accept"); accept != "" {
+		upReq.Header.Set("Accept", accept)
+	}
+
+	resp, err := p.client.Do(upReq)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if retryable(resp.StatusCode) {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return false, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.Header().Set(headerProvider, t.ProviderID)
+	w.WriteHeader(resp.StatusCode)
+	copyFlush(w, resp.Body)
+	return true, nil
+}
+
+// headerProvider 响应头中标明实际处理请求的供应商
+const headerProvider = "X-PingAI-Provider"
+
+// copyFlush 边读边写，流式响应逐块刷新
+func copyFlush(w http.ResponseWriter, src io.Reader) {
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 4096)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (p *Proxy) handleModels(w http.ResponseWriter, r *http.Request) {
+	targets, err := p.source()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	owners := make(map[string]string)
+	for _, t := range targets {
+		if t.APIKey == "" {
+			continue
+		}
+		for _, m := range t.Models {
+			if _, ok := owners[m]; !ok {
+				owners[m] = t.ProviderID
+			}
+		}
+	}
+	ids := make([]string, 0, len(owners))
+	for id := range owners {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	type model struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		OwnedBy string `json:"owned_by"`
+	}
+	data := make([]model, 0, len(ids))
+	for _, id := range ids {
+		data = append(data, model{ID: id, Object: "model", OwnedBy: owners[id]})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": data})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError 按 OpenAI 错误格式返回
+func writeError(w http.ResponseWriter, status int, kind, message string) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]string{"message": message, "type": kind},
+	})
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,255 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testToken = "proxy-token"
+
+// openAIUpstream 返回固定状态码的 OpenAI 兼容上游，status 为 200 时回复 reply
+func openAIUpstream(t *testing.T, status int, reply string, hits *int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		if r.Header.Get("Authorization") != "Bearer sk-up" {
+			t.Errorf("上游 Authorization = %q", r.Header.Get("Authorization"))
+		}
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			io.WriteString(w, `{"error":{"message":"busy"}}`)
+			return
+		}
+		var req chatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\ndata: [DONE]\n\n", reply)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"model":%q,"choices":[{"message":{"role":"assistant","content":%q}}]}`, req.Model, reply)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func request(t *testing.T, h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCandidatesOrder(t *testing.T) {
+	targets := []Target{
+		{ProviderID: "failed", BaseURL: "u", APIKey: "k", Status: "failed", Latency: 100},
+		{ProviderID: "slow", BaseURL: "u", APIKey: "k", Status: "success", Latency: 900},
+		{ProviderID: "unknown", BaseURL: "u", APIKey: "k"},
+		{ProviderID: "fast", BaseURL: "u", APIKey: "k", Status: "success", Latency: 200},
+		{ProviderID: "warn", BaseURL: "u", APIKey: "k", Status: "warning", Latency: 50},
+		{ProviderID: "other-model", BaseURL: "u", APIKey: "k", Status: "success", Models: []string{"x"}},
+		{ProviderID: "no-key", BaseURL: "u", Status: "success"},
+	}
+	p := New(func() ([]Target, error) { return targets, nil }, testToken)
+
+	ids := func() string {
+		got, _ := p.Candidates("gpt-4o")
+		var out []string
+		for _, c := range got {
+			out = append(out, c.ProviderID)
+		}
+		return strings.Join(out, ",")
+	}
+	if got := ids(); got != "fast,slow,warn,unknown,failed" {
+		t.Errorf("排序 = %s", got)
+	}
+
+	// 降级的上游在冷却期内排到最后
+	now := time.Now()
+	p.now = func() time.Time { return now }
+	p.penalize(targets[3])
+	if got := ids(); got != "slow,warn,unknown,failed,fast" {
+		t.Errorf("降级后排序 = %s", got)
+	}
+	now = now.Add(DefaultCooldown)
+	if got := ids(); !strings.HasPrefix(got, "fast,") {
+		t.Errorf("冷却结束后排序 = %s", got)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	var busyHits, okHits int32
+	busy := openAIUpstream(t, http.StatusTooManyRequests, "", &busyHits)
+	ok := openAIUpstream(t, http.StatusOK, "pong", &okHits)
+
+	p := New(func() ([]Target, error) {
+		return []Target{
+			{ProviderID: "busy", BaseURL: busy.URL, APIKey: "sk-up", Protocol: "openai", Status: "success", Latency: 100},
+			{ProviderID: "ok", BaseURL: ok.URL, APIKey: "sk-up", Protocol: "openai", Status: "success", Latency: 500},
+		}, nil
+	}, testToken)
+	h := p.Handler()
+
+	rec := request(t, h, "POST", "/v1/chat/completions", `{"model":"gpt-4o","messages":[{"role":"user","content":"ping"}]}`)
+	if rec.Code != http.StatusOK || rec.Header().Get(headerProvider) != "ok" || !strings.Contains(rec.Body.String(), "pong") {
+		t.Fatalf("故障转移响应 = %d %s %s", rec.Code, rec.Header().Get(headerProvider), rec.Body.String())
+	}
+
+	// 第二次请求直接跳过冷却中的上游
+	request(t, h, "POST", "/v1/chat/completions", `{"model":"gpt-4o","messages":[{"role":"user","content":"ping"}]}`)
+	if busyHits != 1 || okHits != 2 {
+		t.Errorf("请求次数 busy=%d ok=%d", busyHits, okHits)
+	}
+
+	// 流式原样透传
+	rec = request(t, h, "POST", "/v1/chat/completions", `{"model":"gpt-4o","stream":true,"messages":[{"role":"user","content":"ping"}]}`)
+	if !strings.Contains(rec.Body.String(), "data: [DONE]") || !strings.Contains(rec.Body.String(), "pong") {
+		t.Errorf("流式响应 = %s", rec.Body.String())
+	}
+}
+
+func TestAllUpstreamsFail(t *testing.T) {
+	var hits int32
+	down := openAIUpstream(t, http.StatusBadGateway, "", &hits)
+	p := New(func() ([]Target, error) {
+		return []Target{{ProviderID: "down", BaseURL: down.URL, APIKey: "sk-up"}}, nil
+	}, testToken)
+	h := p.Handler()
+
+	rec := request(t, h, "POST", "/v1/chat/completions", `{"model":"m","messages":[]}`)
+	if rec.Code != http.StatusBadGateway || !strings.Contains(rec.Body.String(), "upstream_error") {
+		t.Errorf("全部失败 = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := request(t, h, "POST", "/v1/chat/completions", `{"messages":[]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("缺少 model 应返回 400, 得到 %d", rec.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/v1/models", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("缺少令牌应返回 401, 得到 %d", rec.Code)
+	}
+}
+
+func TestAnthropicTranslation(t *testing.T) {
+	var gotBody map[string]any
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/messages" || r.Header.Get("x-api-key") != "sk-ant" {
+			t.Errorf("上游请求 %s x-api-key=%q", r.URL.Path, r.Header.Get("x-api-key"))
+		}
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		if gotBody["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n")
+			io.WriteString(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"lo\"}}\n\n")
+			return
+		}
+		io.WriteString(w, `{"content":[{"type":"text","text":"Hello"}],"usage":{"input_tokens":7,"output_tokens":2}}`)
+	}))
+	defer upstream.Close()
+
+	p := New(func() ([]Target, error) {
+		return []Target{{ProviderID: "anthropic", BaseURL: upstream.URL, APIKey: "sk-ant", Protocol: "anthropic", Models: []string{"claude-3-5-haiku"}}}, nil
+	}, testToken)
+	h := p.Handler()
+
+	body := `{"model":"claude-3-5-haiku","messages":[{"role":"system","content":"Be terse."},{"role":"user","content":[{"type":"text","text":"hi"}]}]}`
+	rec := request(t, h, "POST", "/v1/chat/completions", body)
+	var resp struct {
+		Object  string `json:"object"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage struct {
+			TotalTokens int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Object != "chat.completion" || resp.Choices[0].Message.Content != "Hello" || resp.Usage.TotalTokens != 9 {
+		t.Errorf("转换后响应 = %s", rec.Body.String())
+	}
+	msgs := gotBody["messages"].([]any)
+	if first := msgs[0].(map[string]any); len(msgs) != 1 || first["role"] != "user" || first["content"] != "hi" || gotBody["system"] != "Be terse." {
+		t.Errorf("上游收到的消息 = %v, system = %v", msgs, gotBody["system"])
+	}
+
+	// 客户端未指定时不应沿用探测请求的 256
+	request(t, h, "POST", "/v1/chat/completions", `{"model":"claude","messages":[{"role":"user","content":"hi"}]}`)
+	if gotBody["max_tokens"] != float64(anthropicMaxTokens) {
+		t.Errorf("未指定 max_tokens 时上游收到 %v, 期望 %d", gotBody["max_tokens"], anthropicMaxTokens)
+	}
+
+	rec = request(t, h, "POST", "/v1/chat/completions", strings.Replace(body, `"model"`, `"stream":true,"model"`, 1))
+	out := rec.Body.String()
+	if rec.Header().Get("Content-Type") != "text/event-stream" || !strings.Contains(out, `"content":"Hel"`) ||
+		!strings.Contains(out, `"finish_reason":"stop"`) || !strings.HasSuffix(out, "data: [DONE]\n\n") {
+		t.Errorf("流式转换 = %s", out)
+	}
+
+	rec = request(t, h, "GET", "/v1/models", "")
+	if !strings.Contains(rec.Body.String(), `"id":"claude-3-5-haiku"`) {
+		t.Errorf("模型列表 = %s", rec.Body.String())
+	}
+	if rec := request(t, h, "POST", "/v1/chat/completions", `{"model":"gpt-4o","messages":[]}`); rec.Code != http.StatusNotFound {
+		t.Errorf("无上游的模型应返回 404, 得到 %d", rec.Code)
+	}
+}
+
+func TestTranslationLimitsAndStreamErrors(t *testing.T) {
+	var gotBody map[string]any
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		if gotBody["stream"] != true {
+			io.WriteString(w, `{"content":[{"type":"text","text":"Hel"}],"stop_reason":"max_tokens"}`)
+			return
+		}
+		// 输出一个分片后断开连接，模拟流式中途失败
+		conn, buf, _ := w.(http.Hijacker).Hijack()
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 200 OK\r\nContent-Type: text/event-stream\r\nTransfer-Encoding: chunked\r\n\r\n")
+		chunk := "data: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n"
+		fmt.Fprintf(buf, "%x\r\n%s\r\n", len(chunk), chunk)
+		buf.Flush()
+	}))
+	defer upstream.Close()
+
+	p := New(func() ([]Target, error) {
+		return []Target{{ProviderID: "anthropic", BaseURL: upstream.URL, APIKey: "sk-ant", Protocol: "anthropic"}}, nil
+	}, testToken)
+	h := p.Handler()
+
+	body := `{"model":"claude","max_completion_tokens":1000,"max_tokens":10,"temperature":0.2,"messages":[{"role":"user","content":"hi"}]}`
+	rec := request(t, h, "POST", "/v1/chat/completions", body)
+	if gotBody["max_tokens"] != float64(1000) || gotBody["temperature"] != 0.2 {
+		t.Errorf("上游应收到客户端的 max_tokens / temperature: %v", gotBody)
+	}
+	if !strings.Contains(rec.Body.String(), `"finish_reason":"length"`) {
+		t.Errorf("截断的响应应返回 length: %s", rec.Body.String())
+	}
+
+	// 客户端未指定时不应沿用探测请求的 256
+	request(t, h, "POST", "/v1/chat/completions", `{"model":"claude","messages":[{"role":"user","content":"hi"}]}`)
+	if gotBody["max_tokens"] != float64(anthropicMaxTokens) {
+		t.Errorf("未指定 max_tokens 时上游收到 %v, 期望 %d", gotBody["max_tokens"], anthropicMaxTokens)
+	}
+
+	rec = request(t, h, "POST", "/v1/chat/completions", strings.Replace(body, `"model"`, `"stream":true,"model"`, 1))
+	out := rec.Body.String()
+	if !strings.Contains(out, `"content":"Hel"`) || !strings.Contains(out, `"error"`) ||
+		strings.Contains(out, "[DONE]") || strings.Contains(out, `"finish_reason":"stop"`) {
+		t.Errorf("流式中途失败 = %s", out)
+	}
+}
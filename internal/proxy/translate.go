@@ -0,0 +1,218 @@
+package proxy
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"pingai/internal/protocol"
+	"strings"
+)
+
+// chatRequest OpenAI /v1/chat/completions 请求中代理关心的字段
+type chatRequest struct {
+	Model               string        `json:"model"`
+	Messages            []chatMessage `json:"messages"`
+	Stream              bool          `json:"stream"`
+	MaxTokens           int           `json:"max_tokens"`
+	MaxCompletionTokens int           `json:"max_completion_tokens"` // 新版 OpenAI 客户端使用，优先于 max_tokens
+	Temperature         *float64      `json:"temperature"`
+}
+
+// anthropicMaxTokens 客户端未指定输出上限时转发给 Anthropic 的值 (该协议必填)，
+// 取全部 Claude 模型都支持的上限，避免沿用适配器为探测请求设置的 256
+const anthropicMaxTokens = 4096
+
+// maxTokens 客户端请求的输出上限，未指定时 Anthropic 使用 anthropicMaxTokens，其他协议为 0 (由上游决定)
+func (r chatRequest) maxTokens(proto string) int {
+	if r.MaxCompletionTokens > 0 {
+		return r.MaxCompletionTokens
+	}
+	if r.MaxTokens > 0 {
+		return r.MaxTokens
+	}
+	if protocol.Protocol(proto) == protocol.ProtocolAnthropic {
+		return anthropicMaxTokens
+	}
+	return 0
+}
+
+// finishReason 上游因输出上限截断时为 "length"
+func finishReason(resp *protocol.ChatResponse) string {
+	if resp != nil && resp.Truncated {
+		return "length"
+	}
+	return "stop"
+}
+
+type chatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// text 提取消息文本，content 为数组时拼接其中的 text 部分
+func (m chatMessage) text() string {
+	var s string
+	if json.Unmarshal(m.Content, &s) == nil {
+		return s
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	json.Unmarshal(m.Content, &parts)
+	var b strings.Builder
+	for _, p := range parts {
+		if p.Type == "text" {
+			b.WriteString(p.Text)
+		}
+	}
+	return b.String()
+}
+
+// toMessages 转为适配器消息，system / developer 消息合并为系统提示词
+func toMessages(msgs []chatMessage) (system string, out []protocol.Message) {
+	var parts []string
+	for _, m := range msgs {
+		if m.Role == "system" || m.Role == "developer" {
+			parts = append(parts, m.text())
+			continue
+		}
+		out = append(out, protocol.Message{Role: m.Role, Content: m.text()})
+	}
+	return strings.Join(parts, "\n\n"), out
+}
+
+var errNoContent = errors.New("upstream returned no content")
+
+// forwardAdapter 通过协议适配器转发给 Anthropic / Gemini 上游，并转换为 OpenAI 响应格式
+func (p *Proxy) forwardAdapter(w http.ResponseWriter, r *http.Request, t Target, req chatRequest) (done bool, err error) {
+	adapter := protocol.GetAdapter(protocol.Protocol(t.Protocol))
+	system, msgs := toMessages(req.Messages)
+	preq := protocol.ChatRequest{
+		BaseURL:     t.BaseURL,
+		APIKey:      t.APIKey,
+		Model:       req.Model,
+		Messages:    msgs,
+		Stream:      req.Stream,
+		System:      system,
+		Temperature: req.Temperature,
+		MaxTokens:   req.maxTokens(t.Protocol),
+	}
+	id := completionID()
+	created := p.now().Unix()
+	// 使用代理自己的客户端：没有总超时，长回复和长时间的流式输出由客户端连接控制
+	ctx := protocol.WithClient(r.Context(), p.client)
+
+	if !req.Stream {
+		resp, err := adapter.Chat(ctx, preq)
+		if err != nil {
+			return false, err
+		}
+		if retryable(resp.StatusCode) {
+			return false, fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		if resp.Error != "" {
+			writeUpstreamError(w, t, resp)
+			return true, nil
+		}
+		w.Header().Set(headerProvider, t.ProviderID)
+		writeJSON(w, http.StatusOK, map[string]any{
+			"id":      id,
+			"object":  "chat.completion",
+			"created": created,
+			"model":   req.Model,
+			"choices": []map[string]any{{
+				"index":         0,
+				"message":       map[string]string{"role": "assistant", "content": resp.Content},
+				"finish_reason": finishReason(resp),
+			}},
+			"usage": map[string]int{
+				"prompt_tokens":     resp.PromptTokens,
+				"completion_tokens": resp.CompTokens,
+				"total_tokens":      resp.PromptTokens + resp.CompTokens,
+			},
+		})
+		return true, nil
+	}
+
+	// 流式：收到第一个分片才写响应头，此前的失败仍可换上游
+	started := false
+	flusher, _ := w.(http.Flusher)
+	writeChunk := func(delta map[string]string, finish any) {
+		data, _ := json.Marshal(map[string]any{
+			"id":      id,
+			"object":  "chat.completion.chunk",
+			"created": created,
+			"model":   req.Model,
+			"choices": []map[string]any{{"index": 0, "delta": delta, "finish_reason": finish}},
+		})
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	resp, err := adapter.ChatStream(ctx, preq, func(chunk string, isFirst bool) {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set(headerProvider, t.ProviderID)
+			w.WriteHeader(http.StatusOK)
+			writeChunk(map[string]string{"role": "assistant", "content": ""}, nil)
+		}
+		writeChunk(map[string]string{"content": chunk}, nil)
+	})
+
+	if !started {
+		switch {
+		case err != nil:
+			return false, err
+		case retryable(resp.StatusCode):
+			return false, fmt.Errorf("HTTP %d", resp.StatusCode)
+		case resp.Error != "":
+			writeUpstreamError(w, t, resp)
+			return true, nil
+		}
+		return false, errNoContent
+	}
+	if err != nil {
+		// 已开始输出后无法换上游，发送错误分片并且不发送 [DONE]，让客户端知道响应不完整
+		data, _ := json.Marshal(map[string]any{
+			"error": map[string]string{"message": err.Error(), "type": "upstream_error"},
+		})
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return true, nil
+	}
+	writeChunk(map[string]string{}, finishReason(resp))
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+	return true, nil
+}
+
+// writeUpstreamError 透传上游的非重试类错误 (如 400/401/404)
+func writeUpstreamError(w http.ResponseWriter, t Target, resp *protocol.ChatResponse) {
+	status := resp.StatusCode
+	if status < 400 {
+		status = http.StatusBadGateway
+	}
+	message := resp.Error
+	if resp.RawBody != "" {
+		message += ": " + resp.RawBody
+	}
+	w.Header().Set(headerProvider, t.ProviderID)
+	writeError(w, status, "upstream_error", message)
+}
+
+func completionID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "chatcmpl-" + hex.EncodeToString(b)
+}
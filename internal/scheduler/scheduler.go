@@ -0,0 +1,236 @@
+// Package scheduler 按固定间隔或 cron 表达式定时检测供应商
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MinInterval 最小检测间隔
+const MinInterval = 30 * time.Second
+
+// DefaultConcurrency 默认同时运行的检测数
+const DefaultConcurrency = 4
+
+// Schedule 一个供应商的定时检测计划，Cron 非空时优先于 Interval
+type Schedule struct {
+	ID         int64
+	ProviderID string
+	Interval   time.Duration
+	Cron       string
+	Jitter     time.Duration // 每次触发随机延后 [0, Jitter)
+	Enabled    bool
+}
+
+// Validate 检查计划配置
+func (s Schedule) Validate() error {
+	if s.ProviderID == "" {
+		return errors.New("缺少供应商")
+	}
+	if s.Cron != "" {
+		_, err := ParseCron(s.Cron)
+		return err
+	}
+	if s.Interval < MinInterval {
+		return errors.New("检测间隔不能小于 30 秒")
+	}
+	return nil
+}
+
+// RunFunc 执行一次检测
+type RunFunc func(ctx context.Context, s Schedule) error
+
+// Status 计划运行状态
+type Status struct {
+	ID         int64     `json:"id"`
+	ProviderID string    `json:"providerID"`
+	NextRun    time.Time `json:"nextRun"`
+	Running    bool      `json:"running"`
+}
+
+type entry struct {
+	sched   Schedule
+	cron    *Cron
+	next    time.Time
+	running bool
+}
+
+// Scheduler 定时调度器，全局限制同时运行的检测数
+type Scheduler struct {
+	run RunFunc
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[int64]*entry
+	limit   int
+	active  int
+	wake    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// New 创建调度器，concurrency <= 0 时使用默认值
+func New(run RunFunc, concurrency int) *Scheduler {
+	s := &Scheduler{
+		run:     run,
+		now:     time.Now,
+		entries: make(map[int64]*entry),
+		wake:    make(chan struct{}, 1),
+	}
+	s.SetConcurrency(concurrency)
+	return s
+}
+
+// SetConcurrency 修改全局并发上限
+func (s *Scheduler) SetConcurrency(n int) {
+	if n <= 0 {
+		n = DefaultConcurrency
+	}
+	s.mu.Lock()
+	s.limit = n
+	s.mu.Unlock()
+	s.notify()
+}
+
+// Set 替换全部计划，未变化的计划保留下次运行时间
+func (s *Scheduler) Set(list []Schedule) {
+	s.mu.Lock()
+	now := s.now()
+	entries := make(map[int64]*entry, len(list))
+	for _, sched := range list {
+		if !sched.Enabled || sched.Validate() != nil {
+			continue
+		}
+		e := &entry{sched: sched}
+		if sched.Cron != "" {
+			e.cron, _ = ParseCron(sched.Cron)
+		}
+		if old, ok := s.entries[sched.ID]; ok {
+			e.running = old.running
+			if old.sched == sched {
+				e.next = old.next
+			}
+		}
+		if e.next.IsZero() {
+			e.next = s.nextRun(e, now)
+		}
+		entries[sched.ID] = e
+	}
+	s.entries = entries
+	s.mu.Unlock()
+	s.notify()
+}
+
+// Statuses 返回各计划的下次运行时间
+func (s *Scheduler) Statuses() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Status, 0, len(s.entries))
+	for id, e := range s.entries {
+		list = append(list, Status{ID: id, ProviderID: e.sched.ProviderID, NextRun: e.next, Running: e.running})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Run 运行调度循环直到 ctx 取消，返回前等待进行中的检测结束
+func (s *Scheduler) Run(ctx context.Context) {
+	defer s.wg.Wait()
+	for {
+		wait := s.dispatch(ctx)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// dispatch 启动到期的计划，返回距下一次需要检查的时间
+func (s *Scheduler) dispatch(ctx context.Context) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	due := make([]*entry, 0)
+	for _, e := range s.entries {
+		if !e.next.After(now) {
+			due = append(due, e)
+		}
+	}
+	// 最早到期的优先获得并发名额
+	sort.Slice(due, func(i, j int) bool { return due[i].next.Before(due[j].next) })
+
+	for _, e := range due {
+		if e.running {
+			// 上一次尚未结束，跳过本轮
+			e.next = s.nextRun(e, now)
+			continue
+		}
+		if s.active >= s.limit {
+			break // 等待名额释放后再唤醒
+		}
+		e.running = true
+		e.next = s.nextRun(e, now)
+		s.active++
+		s.wg.Add(1)
+		go s.execute(ctx, e.sched)
+	}
+
+	wait := time.Hour
+	for _, e := range s.entries {
+		if d := e.next.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	if wait < 0 {
+		// 仍有到期计划在等待名额，由检测结束时唤醒
+		wait = time.Hour
+	}
+	return wait
+}
+
+func (s *Scheduler) execute(ctx context.Context, sched Schedule) {
+	defer s.wg.Done()
+	s.run(ctx, sched)
+
+	s.mu.Lock()
+	s.active--
+	if e, ok := s.entries[sched.ID]; ok {
+		e.running = false
+	}
+	s.mu.Unlock()
+	s.notify()
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// nextRun 计算 from 之后的下一次运行时间，包含随机抖动
+func (s *Scheduler) nextRun(e *entry, from time.Time) time.Time {
+	var next time.Time
+	if e.cron != nil {
+		next = e.cron.Next(from)
+		if next.IsZero() {
+			next = from.AddDate(100, 0, 0)
+		}
+	} else {
+		next = from.Add(e.sched.Interval)
+	}
+	if e.sched.Jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(e.sched.Jitter))))
+	}
+	return next
+}
@@ -0,0 +1,150 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseCronNext(t *testing.T) {
+	base := time.Date(2026, 10, 18, 10, 7, 30, 0, time.UTC) // 周日
+
+	cases := []struct {
+		expr string
+		want string
+	}{
+		{"*/15 * * * *", "2026-10-18 10:15"},
+		{"0 * * * *", "2026-10-18 11:00"},
+		{"@daily", "2026-10-19 00:00"},
+		{"30 9 * * 1-5", "2026-10-19 09:30"},
+		{"0 12 1 * *", "2026-11-01 12:00"},
+		{"5/20 10 * * *", "2026-10-18 10:25"},
+		{"0 8 * * 7", "2026-10-25 08:00"},
+		{"0 0 13 * 5", "2026-10-23 00:00"}, // 日期或星期满足其一
+		{"0 0 29 2 *", "2028-02-29 00:00"},
+	}
+	for _, c := range cases {
+		cron, err := ParseCron(c.expr)
+		if err != nil {
+			t.Errorf("%s: 解析失败: %v", c.expr, err)
+			continue
+		}
+		if got := cron.Next(base).Format("2006-01-02 15:04"); got != c.want {
+			t.Errorf("%s: Next = %s, 期望 %s", c.expr, got, c.want)
+		}
+	}
+
+	for _, bad := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseCron(bad); err == nil {
+			t.Errorf("%q 应解析失败", bad)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (Schedule{ProviderID: "p", Interval: time.Minute}).Validate(); err != nil {
+		t.Errorf("合法间隔: %v", err)
+	}
+	if err := (Schedule{ProviderID: "p", Interval: time.Second}).Validate(); err == nil {
+		t.Error("过短的间隔应报错")
+	}
+	if err := (Schedule{ProviderID: "p", Cron: "bad"}).Validate(); err == nil {
+		t.Error("无效 cron 应报错")
+	}
+}
+
+func TestDispatchRespectsConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	started := make(map[int64]int)
+	release := make(chan struct{})
+	done := make(chan int64, 10)
+
+	s := New(func(ctx context.Context, sched Schedule) error {
+		mu.Lock()
+		started[sched.ID]++
+		mu.Unlock()
+		<-release
+		done <- sched.ID
+		return nil
+	}, 2)
+
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	s.Set([]Schedule{
+		{ID: 1, ProviderID: "a", Interval: time.Minute, Enabled: true},
+		{ID: 2, ProviderID: "b", Interval: 2 * time.Minute, Enabled: true},
+		{ID: 3, ProviderID: "c", Interval: 3 * time.Minute, Enabled: true},
+		{ID: 4, ProviderID: "d", Interval: time.Minute, Enabled: false},
+	})
+	if n := len(s.Statuses()); n != 3 {
+		t.Fatalf("已启用计划数 = %d, 期望 3", n)
+	}
+
+	// 全部到期，但只能同时运行 2 个
+	now = now.Add(3 * time.Minute)
+	ctx := context.Background()
+	s.dispatch(ctx)
+	waitFor := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			mu.Lock()
+			total := 0
+			for _, c := range started {
+				total += c
+			}
+			mu.Unlock()
+			if total == n {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("启动数未达到 %d: %v", n, started)
+	}
+	waitFor(2)
+	mu.Lock()
+	if started[1] != 1 || started[2] != 1 || started[3] != 0 {
+		t.Errorf("应先运行最早到期的计划: %v", started)
+	}
+	mu.Unlock()
+
+	// 释放一个名额后第三个计划运行
+	release <- struct{}{}
+	<-done
+	time.Sleep(10 * time.Millisecond)
+	s.dispatch(ctx)
+	waitFor(3)
+
+	close(release)
+	s.wg.Wait()
+	for _, st := range s.Statuses() {
+		if st.Running || !st.NextRun.After(now) {
+			t.Errorf("计划状态 = %+v", st)
+		}
+	}
+}
+
+func TestSetKeepsNextRunAndJitter(t *testing.T) {
+	s := New(func(context.Context, Schedule) error { return nil }, 1)
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	sched := Schedule{ID: 1, ProviderID: "a", Interval: time.Hour, Jitter: 10 * time.Minute, Enabled: true}
+	s.Set([]Schedule{sched})
+	first := s.Statuses()[0].NextRun
+	if first.Before(now.Add(time.Hour)) || !first.Before(now.Add(70*time.Minute)) {
+		t.Errorf("带抖动的下次运行 = %v", first)
+	}
+
+	now = now.Add(time.Minute)
+	s.Set([]Schedule{sched})
+	if got := s.Statuses()[0].NextRun; !got.Equal(first) {
+		t.Errorf("未变化的计划不应重置下次运行时间: %v -> %v", first, got)
+	}
+	sched.Interval = 2 * time.Hour
+	s.Set([]Schedule{sched})
+	if got := s.Statuses()[0].NextRun; got.Before(now.Add(2 * time.Hour)) {
+		t.Errorf("修改后的计划应重新计算: %v", got)
+	}
+}
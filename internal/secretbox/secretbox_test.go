@@ -0,0 +1,50 @@
+package secretbox
+
+import "testing"
+
+func TestSealOpen(t *testing.T) {
+	key, err := NewKey()
+	if err != nil {
+		t.Fatalf("NewKey 失败: %v", err)
+	}
+
+	sealed, err := Seal(key, "sk-secret")
+	if err != nil {
+		t.Fatalf("Seal 失败: %v", err)
+	}
+	if !IsSealed(sealed) || sealed == "sk-secret" {
+		t.Fatalf("密文格式错误: %q", sealed)
+	}
+
+	plain, err := Open(key, sealed)
+	if err != nil || plain != "sk-secret" {
+		t.Fatalf("Open = %q, %v", plain, err)
+	}
+
+	other, _ := NewKey()
+	if _, err := Open(other, sealed); err != ErrDecrypt {
+		t.Errorf("错误密钥解密应返回 ErrDecrypt, 得到 %v", err)
+	}
+	if _, err := Open(key, "sk-plain"); err != ErrDecrypt {
+		t.Errorf("明文解密应返回 ErrDecrypt, 得到 %v", err)
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	salt, _ := NewSalt()
+	k1, err := DeriveKey("passphrase", salt)
+	if err != nil {
+		t.Fatalf("DeriveKey 失败: %v", err)
+	}
+	k2, _ := DeriveKey("passphrase", salt)
+	k3, _ := DeriveKey("other", salt)
+	if string(k1) != string(k2) {
+		t.Error("相同口令和盐应派生相同密钥")
+	}
+	if string(k1) == string(k3) {
+		t.Error("不同口令应派生不同密钥")
+	}
+	if len(k1) != KeySize {
+		t.Errorf("密钥长度 = %d, 期望 %d", len(k1), KeySize)
+	}
+}
@@ -0,0 +1,384 @@
+package stats
+
+import (
+	"math"
+	"pingai/internal/checker"
+	"pingai/internal/store"
+	"sort"
+	"time"
+)
+
+// Query 统计范围
+type Query struct {
+	ProviderID string `json:"providerID"`
+	Model      string `json:"model"` // 为空时统计该供应商全部模型
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Bucket     string `json:"bucket"` // "hour" | "day"，为空时按时间跨度自动选择
+}
+
+// Percentiles 分位数统计 (ms)
+type Percentiles struct {
+	Count int     `json:"count"`
+	Min   int64   `json:"min"`
+	Max   int64   `json:"max"`
+	Avg   float64 `json:"avg"`
+	P50   int64   `json:"p50"`
+	P90   int64   `json:"p90"`
+	P95   int64   `json:"p95"`
+	P99   int64   `json:"p99"`
+}
+
+// ItemStats 单个检测项的可用率
+type ItemStats struct {
+	Item         string      `json:"item"`
+	Total        int         `json:"total"`
+	Success      int         `json:"success"`
+	Warning      int         `json:"warning"`
+	Failed       int         `json:"failed"`
+	Availability float64     `json:"availability"` // 非失败占比 (%)
+	Latency      Percentiles `json:"latency"`
+}
+
+// Bucket 时间分桶，供图表使用
+type Bucket struct {
+	Start        string  `json:"start"`
+	Total        int     `json:"total"`
+	Failed       int     `json:"failed"`
+	Availability float64 `json:"availability"`
+	P50Latency   int64   `json:"p50Latency"`
+}
+
+// ProviderStats 供应商/模型统计结果
+type ProviderStats struct {
+	ProviderID    string         `json:"providerID"`
+	Model         string         `json:"model"`
+	Runs          int            `json:"runs"`
+	Availability  float64        `json:"availability"` // 整体检测非失败占比 (%)
+	Items         []ItemStats    `json:"items"`
+	Latency       Percentiles    `json:"latency"` // 整次检测总耗时
+	TTFT          Percentiles    `json:"ttft"`    // 流式首字延迟
+	FailureCauses map[string]int `json:"failureCauses"`
+	BucketSize    string         `json:"bucketSize"`
+	Buckets       []Bucket       `json:"buckets"`
+}
+
+// itemOrder 检测项展示顺序
+var itemOrder = []checker.CheckItem{
+	checker.CheckConnectivity, checker.CheckChat, checker.CheckStream, checker.CheckModels, checker.CheckMultiTurn,
+}
+
+// historyQuery 统计范围对应的历史查询条件
+func historyQuery(providerIDs []string, model, from, to string) store.HistoryQuery {
+	return store.HistoryQuery{
+		ProviderIDs: providerIDs,
+		Model:       model,
+		From:        from,
+		To:          to,
+		SortAsc:     true,
+		Limit:       -1,
+	}
+}
+
+// GetProviderStats 计算供应商/模型在时间窗口内的统计
+func GetProviderStats(q Query) (ProviderStats, error) {
+	var ids []string
+	if q.ProviderID != "" {
+		ids = []string{q.ProviderID}
+	}
+	hq := historyQuery(ids, q.Model, q.From, q.To)
+	rows, _, err := store.QueryHistory(hq)
+	if err != nil {
+		return ProviderStats{}, err
+	}
+	results, err := store.QueryCheckResults(hq)
+	if err != nil {
+		return ProviderStats{}, err
+	}
+	return compute(q, rows, results), nil
+}
+
+func compute(q Query, rows []store.HistoryRow, results []store.CheckResultRow) ProviderStats {
+	s := ProviderStats{
+		ProviderID:    q.ProviderID,
+		Model:         q.Model,
+		Runs:          len(rows),
+		FailureCauses: make(map[string]int),
+		Items:         []ItemStats{},
+		Buckets:       []Bucket{},
+	}
+
+	items := make(map[checker.CheckItem]*ItemStats)
+	itemLatency := make(map[checker.CheckItem][]int64)
+	var totals, ttfts []int64
+	okRuns := 0
+
+	for _, r := range results {
+		// 跳过的检测项不计入可用率
+		if checker.CheckStatus(r.Status) == checker.StatusSkipped {
+			continue
+		}
+		item := checker.CheckItem(r.Item)
+		st, ok := items[item]
+		if !ok {
+			st = &ItemStats{Item: r.Item}
+			items[item] = st
+		}
+		st.Total++
+		switch checker.CheckStatus(r.Status) {
+		case checker.StatusSuccess:
+			st.Success++
+		case checker.StatusWarning:
+			st.Warning++
+		case checker.StatusFailed:
+			st.Failed++
+			cause := r.ErrorKind
+			if cause == "" {
+				cause = string(checker.KindUnknown)
+			}
+			s.FailureCauses[cause]++
+		}
+		if checker.CheckStatus(r.Status) != checker.StatusFailed {
+			itemLatency[item] = append(itemLatency[item], r.Latency)
+		}
+		if item == checker.CheckStream && checker.CheckStatus(r.Status) == checker.StatusSuccess && r.TTFT > 0 {
+			ttfts = append(ttfts, r.TTFT)
+		}
+	}
+
+	s.BucketSize = bucketSize(q, rows)
+	bucketIdx := make(map[string]int)
+	var bucketLatency [][]int64
+
+	for _, row := range rows {
+		if row.Status != string(checker.StatusFailed) {
+			okRuns++
+		}
+		totals = append(totals, row.TotalLatency)
+
+		// 时间分桶
+		key := bucketKey(row.CreatedAt, s.BucketSize)
+		idx, ok := bucketIdx[key]
+		if !ok {
+			idx = len(s.Buckets)
+			bucketIdx[key] = idx
+			s.Buckets = append(s.Buckets, Bucket{Start: key})
+			bucketLatency = append(bucketLatency, nil)
+		}
+		b := &s.Buckets[idx]
+		b.Total++
+		if row.Status == string(checker.StatusFailed) {
+			b.Failed++
+		}
+		bucketLatency[idx] = append(bucketLatency[idx], row.TotalLatency)
+	}
+
+	s.Availability = percent(okRuns, len(rows))
+	s.Latency = ComputePercentiles(totals)
+	s.TTFT = ComputePercentiles(ttfts)
+
+	for i := range s.Buckets {
+		b := &s.Buckets[i]
+		b.Availability = percent(b.Total-b.Failed, b.Total)
+		b.P50Latency = ComputePercentiles(bucketLatency[i]).P50
+	}
+	sort.Slice(s.Buckets, func(i, j int) bool { return s.Buckets[i].Start < s.Buckets[j].Start })
+
+	// 固定检测项在前，其余 (如自定义用例) 按名称排序
+	seen := make(map[checker.CheckItem]bool)
+	for _, item := range itemOrder {
+		if st, ok := items[item]; ok {
+			s.Items = append(s.Items, finishItem(st, itemLatency[item]))
+			seen[item] = true
+		}
+	}
+	var extra []checker.CheckItem
+	for item := range items {
+		if !seen[item] {
+			extra = append(extra, item)
+		}
+	}
+	sort.Slice(extra, func(i, j int) bool { return extra[i] < extra[j] })
+	for _, item := range extra {
+		s.Items = append(s.Items, finishItem(items[item], itemLatency[item]))
+	}
+	return s
+}
+
+func finishItem(st *ItemStats, latencies []int64) ItemStats {
+	st.Availability = percent(st.Total-st.Failed, st.Total)
+	st.Latency = ComputePercentiles(latencies)
+	return *st
+}
+
+// ComputePercentiles 计算分位数 (最近秩法)
+func ComputePercentiles(values []int64) Percentiles {
+	if len(values) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum int64
+	for _, v := range sorted {
+		sum += v
+	}
+	rank := func(p float64) int64 {
+		idx := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		return sorted[idx]
+	}
+	return Percentiles{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+		Avg:   math.Round(float64(sum)/float64(len(sorted))*10) / 10,
+		P50:   rank(50),
+		P90:   rank(90),
+		P95:   rank(95),
+		P99:   rank(99),
+	}
+}
+
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*10000) / 100
+}
+
+// parseTime 解析数据库中的 UTC 时间 (驱动可能返回 RFC3339 或 SQLite 默认格式)
+func parseTime(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// bucketSize 未指定时，跨度超过 3 天按天分桶，否则按小时
+func bucketSize(q Query, rows []store.HistoryRow) string {
+	if q.Bucket == "hour" || q.Bucket == "day" {
+		return q.Bucket
+	}
+	if len(rows) < 2 {
+		return "hour"
+	}
+	first, ok1 := parseTime(rows[0].CreatedAt)
+	last, ok2 := parseTime(rows[len(rows)-1].CreatedAt)
+	if ok1 && ok2 && last.Sub(first) > 72*time.Hour {
+		return "day"
+	}
+	return "hour"
+}
+
+// bucketKey 按本地时间分桶，与界面选择的日期范围一致
+func bucketKey(createdAt, size string) string {
+	t, ok := parseTime(createdAt)
+	if !ok {
+		return createdAt
+	}
+	t = t.Local()
+	if size == "day" {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:00")
+}
+
+// ProviderRank 供应商对比排名
+type ProviderRank struct {
+	Rank         int     `json:"rank"`
+	ProviderID   string  `json:"providerID"`
+	ProviderName string  `json:"providerName"`
+	Runs         int     `json:"runs"`
+	SuccessRate  float64 `json:"successRate"` // 非失败检测占比 (%)
+	P50Latency   int64   `json:"p50Latency"`
+	P95Latency   int64   `json:"p95Latency"`
+}
+
+// CompareProviders 按成功率降序、中位延迟升序对全部供应商排名
+func CompareProviders(from, to string) ([]ProviderRank, error) {
+	summaries, err := store.SummarizeRuns(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return rank(summaries), nil
+}
+
+// CompareModel 对同一模型在各供应商的表现排名，modelID 返回该模型在供应商处的模型 ID
+func CompareModel(from, to string, modelID func(providerID string) string) ([]ProviderRank, error) {
+	summaries, err := store.SummarizeRuns(from, to)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]string)
+	var matched []store.RunSummary
+	for _, s := range summaries {
+		id, ok := ids[s.ProviderID]
+		if !ok {
+			id = modelID(s.ProviderID)
+			ids[s.ProviderID] = id
+		}
+		if s.Model == id {
+			matched = append(matched, s)
+		}
+	}
+	return rank(matched), nil
+}
+
+func rank(summaries []store.RunSummary) []ProviderRank {
+	type acc struct {
+		name      string
+		lastID    int64
+		runs, ok  int
+		latencies []int64
+	}
+	byProvider := make(map[string]*acc)
+	for _, s := range summaries {
+		a, ok := byProvider[s.ProviderID]
+		if !ok {
+			a = &acc{}
+			byProvider[s.ProviderID] = a
+		}
+		if s.LastID > a.lastID { // 取最新名称
+			a.name, a.lastID = s.ProviderName, s.LastID
+		}
+		a.runs += s.Runs
+		a.ok += s.OK
+		a.latencies = append(a.latencies, s.Latencies...)
+	}
+
+	ranks := make([]ProviderRank, 0, len(byProvider))
+	for id, a := range byProvider {
+		p := ComputePercentiles(a.latencies)
+		ranks = append(ranks, ProviderRank{
+			ProviderID:   id,
+			ProviderName: a.name,
+			Runs:         a.runs,
+			SuccessRate:  percent(a.ok, a.runs),
+			P50Latency:   p.P50,
+			P95Latency:   p.P95,
+		})
+	}
+	sort.Slice(ranks, func(i, j int) bool {
+		a, b := ranks[i], ranks[j]
+		if a.SuccessRate != b.SuccessRate {
+			return a.SuccessRate > b.SuccessRate
+		}
+		// 没有成功样本时延迟为 0，排在有样本的供应商之后
+		if (a.P50Latency == 0) != (b.P50Latency == 0) {
+			return b.P50Latency == 0
+		}
+		if a.P50Latency != b.P50Latency {
+			return a.P50Latency < b.P50Latency
+		}
+		return a.ProviderID < b.ProviderID
+	})
+	for i := range ranks {
+		ranks[i].Rank = i + 1
+	}
+	return ranks
+}
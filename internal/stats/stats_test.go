@@ -0,0 +1,149 @@
+package stats
+
+import (
+	"path/filepath"
+	"pingai/internal/store"
+	"testing"
+	"time"
+)
+
+func TestComputePercentiles(t *testing.T) {
+	var values []int64
+	for i := int64(100); i >= 1; i-- {
+		values = append(values, i)
+	}
+	p := ComputePercentiles(values)
+	if p.Count != 100 || p.Min != 1 || p.Max != 100 || p.P50 != 50 || p.P95 != 95 || p.P99 != 99 || p.Avg != 50.5 {
+		t.Errorf("分位数 = %+v", p)
+	}
+	if ComputePercentiles(nil) != (Percentiles{}) {
+		t.Error("空样本应返回零值")
+	}
+}
+
+func TestCompute(t *testing.T) {
+	defer func(loc *time.Location) { time.Local = loc }(time.Local)
+	time.Local = time.UTC
+
+	rows := []store.HistoryRow{
+		{ID: 1, ProviderID: "groq", CreatedAt: "2026-03-10T10:05:00Z", TotalLatency: 800, Status: "success"},
+		{ID: 2, ProviderID: "groq", CreatedAt: "2026-03-10 10:40:00", TotalLatency: 400, Status: "failed"},
+		{ID: 3, ProviderID: "groq", CreatedAt: "2026-03-10T12:00:00Z", TotalLatency: 600, Status: "warning"},
+	}
+	results := []store.CheckResultRow{
+		{HistoryID: 1, Item: "chat", Status: "success", Latency: 300},
+		{HistoryID: 1, Item: "stream", Status: "success", Latency: 500, TTFT: 120},
+		{HistoryID: 2, Item: "chat", Status: "failed", ErrorKind: "rate_limited"},
+		{HistoryID: 2, Item: "stream", Status: "success", Latency: 400, TTFT: 80},
+		{HistoryID: 3, Item: "chat", Status: "warning", Latency: 600},
+		{HistoryID: 3, Item: "stream", Status: "failed"},
+	}
+	s := compute(Query{ProviderID: "groq"}, rows, results)
+
+	if s.Runs != 3 || s.Availability != 66.67 {
+		t.Errorf("Runs = %d, Availability = %v", s.Runs, s.Availability)
+	}
+	if len(s.Items) != 2 || s.Items[0].Item != "chat" || s.Items[1].Item != "stream" {
+		t.Fatalf("Items = %+v", s.Items)
+	}
+	chat := s.Items[0]
+	if chat.Success != 1 || chat.Warning != 1 || chat.Failed != 1 || chat.Availability != 66.67 || chat.Latency.Count != 2 {
+		t.Errorf("chat = %+v", chat)
+	}
+	if s.TTFT.Count != 2 || s.TTFT.Min != 80 {
+		t.Errorf("TTFT = %+v", s.TTFT)
+	}
+	if s.FailureCauses["rate_limited"] != 1 || s.FailureCauses["unknown"] != 1 {
+		t.Errorf("FailureCauses = %v", s.FailureCauses)
+	}
+	if s.BucketSize != "hour" || len(s.Buckets) != 2 {
+		t.Fatalf("Buckets = %s %+v", s.BucketSize, s.Buckets)
+	}
+	if b := s.Buckets[0]; b.Start != "2026-03-10 10:00" || b.Total != 2 || b.Failed != 1 || b.Availability != 50 {
+		t.Errorf("第一个分桶 = %+v", b)
+	}
+
+	// 跨度超过 3 天自动按天分桶
+	rows[2].CreatedAt = "2026-03-20T12:00:00Z"
+	s = compute(Query{}, rows, results)
+	if s.BucketSize != "day" || s.Buckets[0].Start != "2026-03-10" {
+		t.Errorf("按天分桶 = %s %+v", s.BucketSize, s.Buckets)
+	}
+
+	// 分桶使用本地时间: UTC 10:05 在 UTC+14 已是次日
+	time.Local = time.FixedZone("UTC+14", 14*3600)
+	s = compute(Query{Bucket: "day"}, rows, results)
+	if s.Buckets[0].Start != "2026-03-11" {
+		t.Errorf("本地时间分桶 = %+v", s.Buckets)
+	}
+}
+
+func TestCompareProviders(t *testing.T) {
+	if err := store.InitWithPath(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("初始化失败: %v", err)
+	}
+	defer store.Close()
+
+	seed := []store.HistoryRow{
+		{ProviderID: "slow", ProviderName: "Slow", TotalLatency: 3000, Status: "success"},
+		{ProviderID: "fast", ProviderName: "Fast", TotalLatency: 500, Status: "success"},
+		{ProviderID: "flaky", ProviderName: "Flaky", TotalLatency: 100, Status: "success"},
+		{ProviderID: "flaky", ProviderName: "Flaky", TotalLatency: 100, Status: "failed"},
+	}
+	for _, h := range seed {
+		h.ResultsJSON = `[{"item":"chat","status":"` + h.Status + `","errorKind":"timeout"}]`
+		if _, err := store.SaveHistory(h); err != nil {
+			t.Fatalf("SaveHistory 失败: %v", err)
+		}
+	}
+
+	ranks, err := CompareProviders("", "")
+	if err != nil {
+		t.Fatalf("CompareProviders 失败: %v", err)
+	}
+	var order []string
+	for _, r := range ranks {
+		order = append(order, r.ProviderID)
+	}
+	if len(order) != 3 || order[0] != "fast" || order[1] != "slow" || order[2] != "flaky" {
+		t.Errorf("排名 = %v, 期望 [fast slow flaky]", order)
+	}
+	if ranks[2].SuccessRate != 50 || ranks[0].Rank != 1 {
+		t.Errorf("排名详情 = %+v", ranks)
+	}
+
+	// 按供应商统计
+	s, err := GetProviderStats(Query{ProviderID: "flaky"})
+	if err != nil || s.Runs != 2 || s.Availability != 50 || s.FailureCauses["timeout"] != 1 || len(s.Items) != 1 {
+		t.Errorf("GetProviderStats = %+v, err = %v", s, err)
+	}
+}
+
+func TestCompareModel(t *testing.T) {
+	if err := store.InitWithPath(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("初始化失败: %v", err)
+	}
+	defer store.Close()
+
+	seed := []store.HistoryRow{
+		{ProviderID: "deepseek", Model: "deepseek-chat", TotalLatency: 800, Status: "success"},
+		{ProviderID: "deepseek", Model: "deepseek-reasoner", TotalLatency: 100, Status: "success"},
+		{ProviderID: "siliconflow", Model: "deepseek-ai/DeepSeek-V3", TotalLatency: 400, Status: "success"},
+		{ProviderID: "openrouter", Model: "openai/gpt-4o", TotalLatency: 200, Status: "success"},
+	}
+	for _, h := range seed {
+		h.ResultsJSON = "[]"
+		if _, err := store.SaveHistory(h); err != nil {
+			t.Fatalf("SaveHistory 失败: %v", err)
+		}
+	}
+
+	ids := map[string]string{"deepseek": "deepseek-chat", "siliconflow": "deepseek-ai/DeepSeek-V3", "openrouter": "deepseek/deepseek-chat"}
+	ranks, err := CompareModel("", "", func(providerID string) string { return ids[providerID] })
+	if err != nil {
+		t.Fatalf("CompareModel 失败: %v", err)
+	}
+	if len(ranks) != 2 || ranks[0].ProviderID != "siliconflow" || ranks[1].ProviderID != "deepseek" || ranks[1].Runs != 1 {
+		t.Errorf("排名 = %+v", ranks)
+	}
+}
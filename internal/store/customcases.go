@@ -0,0 +1,92 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// CustomCaseRow 用户自定义检测用例
+type CustomCaseRow struct {
+	ID           int64  `db:"id" json:"id"`
+	Name         string `db:"name" json:"name"`
+	SystemPrompt string `db:"system_prompt" json:"systemPrompt"`
+	Messages     string `db:"messages" json:"messages"`     // JSON 数组 [{role, content}]
+	Params       string `db:"params" json:"params"`         // JSON 对象 {stream, temperature, maxTokens, timeoutSeconds}
+	Assertions   string `db:"assertions" json:"assertions"` // JSON 数组 [{kind, path, value}]
+	Enabled      int    `db:"enabled" json:"enabled"`
+	CreatedAt    string `db:"created_at" json:"createdAt"`
+}
+
+var (
+	// ErrCustomCaseNotFound 用例不存在
+	ErrCustomCaseNotFound = errors.New("检测用例不存在")
+	// ErrCustomCaseExists 用例名称重复
+	ErrCustomCaseExists = errors.New("检测用例名称已存在")
+)
+
+// SaveCustomCase 新建 (ID 为 0) 或更新用例，返回 ID
+func SaveCustomCase(c CustomCaseRow) (int64, error) {
+	if c.Messages == "" {
+		c.Messages = "[]"
+	}
+	if c.Params == "" {
+		c.Params = "{}"
+	}
+	if c.Assertions == "" {
+		c.Assertions = "[]"
+	}
+
+	var n int
+	if err := DB.Get(&n, "SELECT COUNT(*) FROM custom_cases WHERE name = ? AND id != ?", c.Name, c.ID); err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		return 0, ErrCustomCaseExists
+	}
+
+	if c.ID == 0 {
+		res, err := DB.Exec(`
+			INSERT INTO custom_cases (name, system_prompt, messages, params, assertions, enabled)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, c.Name, c.SystemPrompt, c.Messages, c.Params, c.Assertions, c.Enabled)
+		if err != nil {
+			return 0, err
+		}
+		return res.LastInsertId()
+	}
+
+	res, err := DB.Exec(`
+		UPDATE custom_cases SET name = ?, system_prompt = ?, messages = ?, params = ?, assertions = ?, enabled = ?
+		WHERE id = ?
+	`, c.Name, c.SystemPrompt, c.Messages, c.Params, c.Assertions, c.Enabled, c.ID)
+	if err != nil {
+		return 0, err
+	}
+	if affected(res) == 0 {
+		return 0, ErrCustomCaseNotFound
+	}
+	return c.ID, nil
+}
+
+// GetCustomCases 获取全部用例
+func GetCustomCases() ([]CustomCaseRow, error) {
+	var rows []CustomCaseRow
+	err := DB.Select(&rows, "SELECT * FROM custom_cases ORDER BY id")
+	return rows, err
+}
+
+// GetCustomCase 获取单个用例
+func GetCustomCase(id int64) (*CustomCaseRow, error) {
+	var row CustomCaseRow
+	err := DB.Get(&row, "SELECT * FROM custom_cases WHERE id = ?", id)
+	if err == sql.ErrNoRows {
+		return nil, ErrCustomCaseNotFound
+	}
+	return &row, err
+}
+
+// DeleteCustomCase 删除用例
+func DeleteCustomCase(id int64) error {
+	_, err := DB.Exec("DELETE FROM custom_cases WHERE id = ?", id)
+	return err
+}
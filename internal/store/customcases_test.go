@@ -0,0 +1,36 @@
+package store
+
+import "testing"
+
+func TestCustomCasesCRUD(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	id, err := SaveCustomCase(CustomCaseRow{Name: "json", Messages: `[{"role":"user","content":"hi"}]`, Enabled: 1})
+	if err != nil {
+		t.Fatalf("SaveCustomCase 失败: %v", err)
+	}
+	c, _ := GetCustomCase(id)
+	if c.Params != "{}" || c.Assertions != "[]" {
+		t.Errorf("默认值 = %+v", c)
+	}
+
+	if _, err := SaveCustomCase(CustomCaseRow{Name: "json"}); err != ErrCustomCaseExists {
+		t.Errorf("重名应返回 ErrCustomCaseExists, 得到 %v", err)
+	}
+	c.SystemPrompt = "Be terse."
+	if _, err := SaveCustomCase(*c); err != nil {
+		t.Fatalf("更新失败: %v", err)
+	}
+	if rows, _ := GetCustomCases(); len(rows) != 1 || rows[0].SystemPrompt != "Be terse." {
+		t.Errorf("更新后 = %+v", rows)
+	}
+	if _, err := SaveCustomCase(CustomCaseRow{ID: 999, Name: "x"}); err != ErrCustomCaseNotFound {
+		t.Errorf("更新不存在的用例应返回 ErrCustomCaseNotFound, 得到 %v", err)
+	}
+
+	DeleteCustomCase(id)
+	if _, err := GetCustomCase(id); err != ErrCustomCaseNotFound {
+		t.Errorf("删除后应返回 ErrCustomCaseNotFound, 得到 %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package store
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HistoryQuery 历史记录查询条件，零值字段表示不过滤
+type HistoryQuery struct {
+	ProviderIDs []string `json:"providerIDs"`
+	Model       string   `json:"model"`
+	Protocol    string   `json:"protocol"`
+	Statuses    []string `json:"statuses"`   // success / warning / failed
+	From        string   `json:"from"`       // 本地时间 "2006-01-02" 或 "2006-01-02 15:04:05"，带时区的 RFC3339 按其时区解析
+	To          string   `json:"to"`         // 仅日期时包含当天
+	MinLatency  int64    `json:"minLatency"` // 总耗时下限 (ms)
+	MaxLatency  int64    `json:"maxLatency"` // 总耗时上限 (ms)
+	FailedItem  string   `json:"failedItem"` // 指定检测项失败，例如 "stream"
+	Text        string   `json:"text"`       // 在供应商名称、模型、各项消息和详情中搜索
+	SortBy      string   `json:"sortBy"`     // "date" (默认) | "latency"
+	SortAsc     bool     `json:"sortAsc"`
+	Limit       int      `json:"limit"` // 0 使用默认分页，负数表示不分页
+	Offset      int      `json:"offset"`
+}
+
+// 查询结果默认分页大小
+const defaultHistoryLimit = 50
+
+// where 生成 WHERE 子句和参数
+func (q HistoryQuery) where() (string, []any) {
+	var conds []string
+	var args []any
+
+	if len(q.ProviderIDs) > 0 {
+		conds = append(conds, "provider_id IN ("+placeholders(len(q.ProviderIDs))+")")
+		for _, id := range q.ProviderIDs {
+			args = append(args, id)
+		}
+	}
+	if q.Model != "" {
+		conds = append(conds, "model = ?")
+		args = append(args, q.Model)
+	}
+	if q.Protocol != "" {
+		conds = append(conds, "protocol = ?")
+		args = append(args, q.Protocol)
+	}
+	if len(q.Statuses) > 0 {
+		conds = append(conds, "status IN ("+placeholders(len(q.Statuses))+")")
+		for _, s := range q.Statuses {
+			args = append(args, s)
+		}
+	}
+	if q.From != "" {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, utcTime(q.From, false))
+	}
+	if q.To != "" {
+		conds = append(conds, "created_at <= ?")
+		args = append(args, utcTime(q.To, true))
+	}
+	if q.MinLatency > 0 {
+		conds = append(conds, "total_latency >= ?")
+		args = append(args, q.MinLatency)
+	}
+	if q.MaxLatency > 0 {
+		conds = append(conds, "total_latency <= ?")
+		args = append(args, q.MaxLatency)
+	}
+	if q.FailedItem != "" {
+		conds = append(conds, `EXISTS (
+			SELECT 1 FROM check_results r
+			WHERE r.history_id = check_history.id AND r.item = ? AND r.status = 'failed'
+		)`)
+		args = append(args, q.FailedItem)
+	}
+	if q.Text != "" {
+		like := "%" + escapeLike(q.Text) + "%"
+		conds = append(conds, `(
+			provider_name LIKE ? ESCAPE '\' OR model LIKE ? ESCAPE '\' OR EXISTS (
+				SELECT 1 FROM json_each(check_history.results_json) j
+				WHERE json_extract(j.value, '$.message') LIKE ? ESCAPE '\'
+				   OR json_extract(j.value, '$.detail') LIKE ? ESCAPE '\'
+			)
+		)`)
+		args = append(args, like, like, like, like)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// orderBy 生成排序子句
+func (q HistoryQuery) orderBy() string {
+	dir := " DESC"
+	if q.SortAsc {
+		dir = " ASC"
+	}
+	if q.SortBy == "latency" {
+		return " ORDER BY total_latency" + dir + ", id" + dir
+	}
+	return " ORDER BY created_at" + dir + ", id" + dir
+}
+
+// QueryHistory 按条件查询历史记录，返回当前页和过滤后的总数
+func QueryHistory(q HistoryQuery) ([]HistoryRow, int, error) {
+	where, args := q.where()
+
+	var total int
+	if err := DB.Get(&total, "SELECT COUNT(*) FROM check_history"+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	limit := q.Limit
+	if limit == 0 {
+		limit = defaultHistoryLimit
+	}
+	var rows []HistoryRow
+	query := "SELECT * FROM check_history" + where + q.orderBy() + " LIMIT ? OFFSET ?"
+	err := DB.Select(&rows, query, append(args, limit, q.Offset)...)
+	return rows, total, err
+}
+
+// LatestHistory 每个供应商最近一次检测记录
+func LatestHistory() ([]HistoryRow, error) {
+	return latestBy("provider_id")
+}
+
+// LatestHistoryByKey 每个供应商下每个 Key (按 masked_key 区分) 最近一次检测记录
+func LatestHistoryByKey() ([]HistoryRow, error) {
+	return latestBy("provider_id, masked_key")
+}
+
+// latestBy 按 partition 分组取最近一次检测记录
+func latestBy(partition string) ([]HistoryRow, error) {
+	var rows []HistoryRow
+	err := DB.Select(&rows, `
+		SELECT * FROM check_history WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY `+partition+` ORDER BY created_at DESC, id DESC) AS rn
+				FROM check_history
+			) WHERE rn = 1
+		) ORDER BY `+partition)
+	return rows, err
+}
+
+// RunSummary 供应商+模型在时间范围内的检测汇总
+type RunSummary struct {
+	ProviderID   string
+	ProviderName string // 最近一次检测时的名称
+	Model        string
+	LastID       int64 // 最近一次检测的历史 ID
+	Runs         int
+	OK           int     // 非失败次数
+	Latencies    []int64 // 非失败检测的总耗时
+}
+
+// SummarizeRuns 按供应商+模型汇总 [from, to] 内的检测次数和耗时，只读取索引列，不加载 results_json
+func SummarizeRuns(from, to string) ([]RunSummary, error) {
+	where, args := HistoryQuery{From: from, To: to}.where()
+	// 与 MAX(id) 同时查询的裸列取自 id 最大的行 (SQLite 的聚合语义)
+	var rows []struct {
+		ProviderID   string `db:"provider_id"`
+		ProviderName string `db:"provider_name"`
+		Model        string `db:"model"`
+		LastID       int64  `db:"last_id"`
+		Runs         int    `db:"runs"`
+		OK           int    `db:"ok"`
+		Latencies    string `db:"latencies"`
+	}
+	if err := DB.Select(&rows, `
+		SELECT provider_id, model, provider_name, MAX(id) AS last_id, COUNT(*) AS runs,
+			SUM(status != 'failed') AS ok,
+			COALESCE(GROUP_CONCAT(CASE WHEN status != 'failed' THEN total_latency END), '') AS latencies
+		FROM check_history`+where+`
+		GROUP BY provider_id, model
+		ORDER BY provider_id, model
+	`, args...); err != nil {
+		return nil, err
+	}
+
+	summaries := make([]RunSummary, 0, len(rows))
+	for _, r := range rows {
+		s := RunSummary{
+			ProviderID:   r.ProviderID,
+			ProviderName: r.ProviderName,
+			Model:        r.Model,
+			LastID:       r.LastID,
+			Runs:         r.Runs,
+			OK:           r.OK,
+		}
+		for _, v := range strings.Split(r.Latencies, ",") {
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				s.Latencies = append(s.Latencies, n)
+			}
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// escapeLike 转义 LIKE 通配符
+func escapeLike(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s)
+}
+
+// sqliteTimeFmt 与 CURRENT_TIMESTAMP 一致的时间格式 (UTC)
+const sqliteTimeFmt = "2006-01-02 15:04:05"
+
+// utcTime 将界面传入的本地时间转换为与 created_at 一致的 UTC 时间
+func utcTime(s string, endOfDay bool) string {
+	if t, err := time.Parse(time.RFC3339, strings.TrimSpace(s)); err == nil {
+		return t.UTC().Format(sqliteTimeFmt)
+	}
+	local := normalizeTime(s, endOfDay)
+	t, err := time.ParseInLocation(sqliteTimeFmt, local, time.Local)
+	if err != nil {
+		return local
+	}
+	return t.UTC().Format(sqliteTimeFmt)
+}
+
+// normalizeTime 补全仅含日期的时间，endOfDay 为 true 时取当天最后一秒
+func normalizeTime(s string, endOfDay bool) string {
+	s = strings.TrimSuffix(strings.TrimSpace(strings.Replace(s, "T", " ", 1)), "Z")
+	if len(s) == len("2006-01-02") {
+		if endOfDay {
+			return s + " 23:59:59"
+		}
+		return s + " 00:00:00"
+	}
+	return s
+}
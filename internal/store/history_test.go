@@ -0,0 +1,254 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+// seedHistory 写入带指定时间的历史记录
+func seedHistory(t *testing.T, h HistoryRow, createdAt string) int64 {
+	t.Helper()
+	id, err := SaveHistory(h)
+	if err != nil {
+		t.Fatalf("SaveHistory 失败: %v", err)
+	}
+	DB.Exec("UPDATE check_history SET created_at = ? WHERE id = ?", createdAt, id)
+	return id
+}
+
+func TestQueryHistory(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	groqStreamFail := seedHistory(t, HistoryRow{
+		ProviderID: "groq", ProviderName: "Groq", Model: "llama-3.1-8b-instant", Protocol: "openai",
+		ResultsJSON:  `[{"item":"chat","status":"success"},{"item":"stream","status":"failed","message":"HTTP 429","detail":"rate_limit_exceeded"}]`,
+		TotalLatency: 900, Status: "failed",
+	}, "2026-03-10 10:00:00")
+	seedHistory(t, HistoryRow{
+		ProviderID: "groq", ProviderName: "Groq", Model: "llama-3.1-8b-instant", Protocol: "openai",
+		ResultsJSON:  `[{"item":"chat","status":"failed","message":"HTTP 401"},{"item":"stream","status":"success"}]`,
+		TotalLatency: 300, Status: "failed",
+	}, "2026-03-11 10:00:00")
+	seedHistory(t, HistoryRow{
+		ProviderID: "anthropic", ProviderName: "Anthropic", Model: "claude-3-5-haiku-20241022", Protocol: "anthropic",
+		ResultsJSON:  `[{"item":"chat","status":"success","message":"对话正常"}]`,
+		TotalLatency: 2000, Status: "success",
+	}, "2026-02-01 10:00:00")
+
+	cases := []struct {
+		name  string
+		q     HistoryQuery
+		total int
+	}{
+		{"all", HistoryQuery{}, 3},
+		{"provider", HistoryQuery{ProviderIDs: []string{"groq"}}, 2},
+		{"protocol", HistoryQuery{Protocol: "anthropic"}, 1},
+		{"model", HistoryQuery{Model: "claude-3-5-haiku-20241022"}, 1},
+		{"status", HistoryQuery{Statuses: []string{"failed"}}, 2},
+		{"date range", HistoryQuery{From: "2026-03-01", To: "2026-03-10"}, 1},
+		{"latency range", HistoryQuery{MinLatency: 500, MaxLatency: 1000}, 1},
+		{"failed item", HistoryQuery{ProviderIDs: []string{"groq"}, FailedItem: "stream", From: "2026-03-04"}, 1},
+		{"text in detail", HistoryQuery{Text: "rate_limit"}, 1},
+		{"text in message", HistoryQuery{Text: "对话"}, 1},
+		{"text escapes wildcard", HistoryQuery{Text: "%"}, 0},
+	}
+	for _, c := range cases {
+		rows, total, err := QueryHistory(c.q)
+		if err != nil {
+			t.Fatalf("%s: QueryHistory 失败: %v", c.name, err)
+		}
+		if total != c.total || len(rows) != c.total {
+			t.Errorf("%s: total = %d, rows = %d, 期望 %d", c.name, total, len(rows), c.total)
+		}
+	}
+
+	// 界面传入的是本地日期，created_at 为 UTC
+	local := time.Local
+	time.Local = time.FixedZone("UTC+8", 8*3600)
+	if _, total, _ := QueryHistory(HistoryQuery{From: "2026-03-10", To: "2026-03-10"}); total != 1 {
+		t.Errorf("本地日期 2026-03-10 应包含 UTC 10:00 的记录, 得到 %d", total)
+	}
+	if _, total, _ := QueryHistory(HistoryQuery{From: "2026-03-10 18:01:00"}); total != 1 {
+		t.Errorf("本地 18:01 之后应只剩 1 条, 得到 %d", total)
+	}
+	time.Local = local
+
+	rows, _, _ := QueryHistory(HistoryQuery{FailedItem: "stream"})
+	if len(rows) != 1 || rows[0].ID != groqStreamFail {
+		t.Errorf("stream 失败记录 = %+v", rows)
+	}
+
+	// 排序与分页
+	rows, total, _ := QueryHistory(HistoryQuery{SortBy: "latency", Limit: 2})
+	if total != 3 || len(rows) != 2 || rows[0].TotalLatency != 2000 || rows[1].TotalLatency != 900 {
+		t.Errorf("按耗时降序分页结果不符: total=%d %+v", total, rows)
+	}
+	rows, _, _ = QueryHistory(HistoryQuery{SortAsc: true, Offset: 2})
+	if len(rows) != 1 || rows[0].ProviderID != "groq" {
+		t.Errorf("按时间升序偏移结果不符: %+v", rows)
+	}
+}
+
+func TestCheckResultsCascade(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	first := seedHistory(t, HistoryRow{
+		ProviderID: "groq", ProviderName: "Groq", Model: "m", Protocol: "openai",
+		ResultsJSON: `[{"item":"chat","status":"success","latency":300},{"item":"stream","status":"success","ttft":150,"errorKind":""}]`,
+		Status:      "success",
+	}, "2026-03-10 10:00:00")
+	seedHistory(t, HistoryRow{
+		ProviderID: "groq", ProviderName: "Groq", Model: "m", Protocol: "openai",
+		ResultsJSON: `[{"item":"chat","status":"failed","errorKind":"auth_invalid"}]`,
+		Status:      "failed",
+	}, "2026-03-11 10:00:00")
+	// 无效 JSON 不影响保存
+	seedHistory(t, HistoryRow{ProviderID: "groq", ResultsJSON: "not json"}, "2026-03-12 10:00:00")
+
+	var avgTTFT float64
+	DB.Get(&avgTTFT, "SELECT AVG(ttft) FROM check_results WHERE item = 'stream'")
+	if avgTTFT != 150 {
+		t.Errorf("stream 平均 TTFT = %v, 期望 150", avgTTFT)
+	}
+	results, _ := QueryCheckResults(HistoryQuery{Statuses: []string{"failed"}})
+	if len(results) != 1 || results[0].ErrorKind != "auth_invalid" {
+		t.Errorf("失败记录的检测项 = %+v", results)
+	}
+
+	countResults := func() int {
+		var n int
+		DB.Get(&n, "SELECT COUNT(*) FROM check_results")
+		return n
+	}
+	if err := DeleteHistoryByID(first); err != nil {
+		t.Fatalf("DeleteHistoryByID 失败: %v", err)
+	}
+	if n := countResults(); n != 1 {
+		t.Errorf("删除单条后检测项数 = %d, 期望 1", n)
+	}
+	DeleteAllHistory()
+	if n := countResults(); n != 0 {
+		t.Errorf("清空后检测项数 = %d, 期望 0", n)
+	}
+}
+
+func TestPruneHistory(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now, _ := time.Parse("2006-01-02 15:04:05", "2026-10-18 12:00:00")
+	seed := func(provider, status, createdAt string) int64 {
+		return seedHistory(t, HistoryRow{
+			ProviderID: provider, ProviderName: provider, Model: "m",
+			ResultsJSON: `[{"item":"chat","status":"` + status + `"}]`, Status: status,
+		}, createdAt)
+	}
+	remaining := func() []int64 {
+		var ids []int64
+		DB.Select(&ids, "SELECT id FROM check_history ORDER BY id")
+		return ids
+	}
+
+	oldOpenAI := seed("openai", "success", "2026-01-01 00:00:00")
+	seed("groq", "success", "2026-01-01 00:00:00")
+	seed("groq", "success", "2026-09-01 00:00:00")
+	flip := seed("groq", "failed", "2026-09-02 00:00:00")
+	seed("groq", "failed", "2026-09-03 00:00:00")
+	recent := seed("groq", "success", "2026-10-17 00:00:00")
+
+	// groq 单独保留 365 天，其余供应商 90 天；30 天前的记录只保留状态变化
+	report, err := pruneHistory(RetentionPolicy{
+		MaxAgeDays:       90,
+		Providers:        map[string]ProviderRetention{"groq": {MaxAgeDays: 365}},
+		CompactMode:      CompactChanges,
+		CompactAfterDays: 30,
+	}, now)
+	if err != nil {
+		t.Fatalf("pruneHistory 失败: %v", err)
+	}
+	ids := remaining()
+	if report.Deleted != 3 || len(ids) != 3 {
+		t.Fatalf("删除 %d 条, 剩余 %v", report.Deleted, ids)
+	}
+	for _, id := range ids {
+		if id == oldOpenAI {
+			t.Error("超过全局保留期的记录未删除")
+		}
+	}
+	if ids[1] != flip || ids[2] != recent {
+		t.Errorf("剩余记录 = %v, 期望包含状态变化 %d 和最近记录 %d", ids, flip, recent)
+	}
+	if report.BytesBefore == 0 || report.BytesAfter == 0 {
+		t.Errorf("空间统计 = %+v", report)
+	}
+
+	// 只保留失败 + 行数上限
+	pruneHistory(RetentionPolicy{CompactMode: CompactFailures, CompactAfterDays: 30}, now)
+	if ids := remaining(); len(ids) != 2 || ids[0] != flip {
+		t.Errorf("只保留失败后剩余 = %v", ids)
+	}
+	pruneHistory(RetentionPolicy{MaxRows: 1}, now)
+	if ids := remaining(); len(ids) != 1 || ids[0] != recent {
+		t.Errorf("行数上限后剩余 = %v", ids)
+	}
+
+	// 策略读写
+	if p, _ := GetRetentionPolicy(); p.MaxAgeDays != 0 || p.MaxRows != 0 {
+		t.Errorf("默认策略应不清理: %+v", p)
+	}
+	SetSetting(settingRetention, "{broken")
+	if _, err := GetRetentionPolicy(); err == nil {
+		t.Error("策略无法解析时应返回错误")
+	}
+	SetRetentionPolicy(RetentionPolicy{MaxRows: 10, Providers: map[string]ProviderRetention{"groq": {MaxRows: 5}}})
+	if p, _ := GetRetentionPolicy(); p.MaxRows != 10 || p.Providers["groq"].MaxRows != 5 {
+		t.Errorf("保存后策略 = %+v", p)
+	}
+}
+
+func TestLatestHistory(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	seedHistory(t, HistoryRow{ProviderID: "groq", ResultsJSON: "[]", Status: "failed"}, "2026-03-12 10:00:00")
+	seedHistory(t, HistoryRow{ProviderID: "groq", ResultsJSON: "[]", Status: "success", TotalLatency: 300}, "2026-03-11 10:00:00")
+	seedHistory(t, HistoryRow{ProviderID: "openai", ResultsJSON: "[]", Status: "warning"}, "2026-03-01 10:00:00")
+
+	rows, err := LatestHistory()
+	if err != nil {
+		t.Fatalf("LatestHistory 失败: %v", err)
+	}
+	if len(rows) != 2 || rows[0].ProviderID != "groq" || rows[0].Status != "failed" || rows[1].Status != "warning" {
+		t.Errorf("LatestHistory = %+v", rows)
+	}
+
+	seedHistory(t, HistoryRow{ProviderID: "groq", MaskedKey: "gsk_...2222", ResultsJSON: "[]", Status: "success"}, "2026-03-10 10:00:00")
+	rows, err = LatestHistoryByKey()
+	if err != nil || len(rows) != 3 || rows[0].MaskedKey != "" || rows[0].Status != "failed" || rows[1].MaskedKey != "gsk_...2222" {
+		t.Errorf("LatestHistoryByKey = %+v (%v)", rows, err)
+	}
+}
+
+func TestSummarizeRuns(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	seedHistory(t, HistoryRow{ProviderID: "groq", ProviderName: "Groq", Model: "m", ResultsJSON: "[]", TotalLatency: 300, Status: "success"}, "2026-03-10 10:00:00")
+	seedHistory(t, HistoryRow{ProviderID: "groq", ProviderName: "Groq Cloud", Model: "m", ResultsJSON: "[]", TotalLatency: 900, Status: "failed"}, "2026-03-11 10:00:00")
+	seedHistory(t, HistoryRow{ProviderID: "groq", ProviderName: "Groq Cloud", Model: "m", ResultsJSON: "[]", TotalLatency: 100, Status: "warning"}, "2026-03-12 10:00:00")
+	seedHistory(t, HistoryRow{ProviderID: "groq", ProviderName: "Groq Cloud", Model: "other", ResultsJSON: "[]", TotalLatency: 50, Status: "success"}, "2026-04-01 10:00:00")
+
+	got, err := SummarizeRuns("2026-03-01", "2026-03-31")
+	if err != nil || len(got) != 1 {
+		t.Fatalf("SummarizeRuns = %+v (%v)", got, err)
+	}
+	s := got[0]
+	if s.ProviderName != "Groq Cloud" || s.Runs != 3 || s.OK != 2 || len(s.Latencies) != 2 || s.Latencies[0]+s.Latencies[1] != 400 {
+		t.Errorf("汇总 = %+v", s)
+	}
+	if all, _ := SummarizeRuns("", ""); len(all) != 2 {
+		t.Errorf("不限时间应按模型分组: %+v", all)
+	}
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ProviderKeyRow 供应商已保存的 API Key
+type ProviderKeyRow struct {
+	ID            int64  `db:"id" json:"id"`
+	ProviderID    string `db:"provider_id" json:"providerID"`
+	Label         string `db:"label" json:"label"`
+	APIKey        string `db:"api_key" json:"apiKey"`
+	Notes         string `db:"notes" json:"notes"`
+	IsActive      int    `db:"is_active" json:"isActive"`
+	LastVerdict   string `db:"last_verdict" json:"lastVerdict"`
+	LastCheckedAt string `db:"last_checked_at" json:"lastCheckedAt"`
+	CreatedAt     string `db:"created_at" json:"createdAt"`
+}
+
+// ErrKeyNotFound Key 不存在
+var ErrKeyNotFound = errors.New("key 不存在")
+
+// AddProviderKey 保存一个 Key，供应商的第一个 Key 自动设为当前使用
+func AddProviderKey(k ProviderKeyRow) (int64, error) {
+	apiKey, err := encryptSecret(k.APIKey)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := DB.Exec(`
+		INSERT INTO provider_keys (provider_id, label, api_key, notes)
+		VALUES (?, ?, ?, ?)
+	`, k.ProviderID, k.Label, apiKey, k.Notes)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	var active int
+	DB.Get(&active, "SELECT COUNT(*) FROM provider_keys WHERE provider_id = ? AND is_active = 1", k.ProviderID)
+	if active == 0 {
+		return id, ActivateProviderKey(id)
+	}
+	return id, nil
+}
+
+// GetProviderKeys 获取供应商的全部 Key
+func GetProviderKeys(providerID string) ([]ProviderKeyRow, error) {
+	var rows []ProviderKeyRow
+	if err := DB.Select(&rows, "SELECT * FROM provider_keys WHERE provider_id = ? ORDER BY id", providerID); err != nil {
+		return nil, err
+	}
+	return rows, decryptKeyRows(rows)
+}
+
+// GetAllProviderKeys 获取全部供应商的 Key
+func GetAllProviderKeys() ([]ProviderKeyRow, error) {
+	var rows []ProviderKeyRow
+	if err := DB.Select(&rows, "SELECT * FROM provider_keys ORDER BY provider_id, id"); err != nil {
+		return nil, err
+	}
+	return rows, decryptKeyRows(rows)
+}
+
+// GetProviderKey 获取单个 Key
+func GetProviderKey(id int64) (*ProviderKeyRow, error) {
+	var row ProviderKeyRow
+	err := DB.Get(&row, "SELECT * FROM provider_keys WHERE id = ?", id)
+	if err == sql.ErrNoRows {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	row.APIKey, err = decryptSecret(row.APIKey)
+	return &row, err
+}
+
+func decryptKeyRows(rows []ProviderKeyRow) error {
+	var firstErr error
+	for i := range rows {
+		key, err := decryptSecret(rows[i].APIKey)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		rows[i].APIKey = key
+	}
+	return firstErr
+}
+
+// ActivateProviderKey 设为供应商当前使用的 Key，并同步到供应商配置
+func ActivateProviderKey(id int64) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var row ProviderKeyRow
+	err = tx.Get(&row, "SELECT * FROM provider_keys WHERE id = ?", id)
+	if err == sql.ErrNoRows {
+		return ErrKeyNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE provider_keys SET is_active = (id = ?) WHERE provider_id = ?", id, row.ProviderID); err != nil {
+		return err
+	}
+	// 密文直接复制，两张表使用同一主密钥
+	if _, err := tx.Exec(`
+		INSERT INTO provider_configs (provider_id, api_key, updated_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(provider_id) DO UPDATE SET
+			api_key = excluded.api_key,
+			updated_at = CURRENT_TIMESTAMP
+	`, row.ProviderID, row.APIKey); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteProviderKey 删除 Key，删除当前使用的 Key 时自动启用最新的另一个 Key
+func DeleteProviderKey(id int64) error {
+	var row ProviderKeyRow
+	err := DB.Get(&row, "SELECT * FROM provider_keys WHERE id = ?", id)
+	if err == sql.ErrNoRows {
+		return ErrKeyNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := DB.Exec("DELETE FROM provider_keys WHERE id = ?", id); err != nil {
+		return err
+	}
+	if row.IsActive == 0 {
+		return nil
+	}
+
+	var next int64
+	err = DB.Get(&next, "SELECT id FROM provider_keys WHERE provider_id = ? ORDER BY id DESC LIMIT 1", row.ProviderID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return ActivateProviderKey(next)
+}
+
+// UpdateProviderKeyCheck 记录 Key 最近一次检测结论
+func UpdateProviderKeyCheck(id int64, verdict string) error {
+	_, err := DB.Exec(`
+		UPDATE provider_keys SET last_verdict = ?, last_checked_at = datetime('now')
+		WHERE id = ?
+	`, verdict, id)
+	return err
+}
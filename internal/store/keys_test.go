@@ -0,0 +1,73 @@
+package store
+
+import "testing"
+
+func TestProviderKeysCRUD(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	SaveProviderConfig(ProviderConfigRow{
+		ProviderID: "openai", APIKey: "", BaseURL: "https://api.openai.com/v1", Model: "gpt-4o", Protocol: "openai",
+	})
+
+	// 第一个 Key 自动启用并同步到配置
+	team, err := AddProviderKey(ProviderKeyRow{ProviderID: "openai", Label: "team", APIKey: "sk-team", Notes: "shared"})
+	if err != nil {
+		t.Fatalf("AddProviderKey 失败: %v", err)
+	}
+	personal, _ := AddProviderKey(ProviderKeyRow{ProviderID: "openai", Label: "personal", APIKey: "sk-personal"})
+
+	cfg, _ := GetProviderConfig("openai")
+	if cfg.APIKey != "sk-team" {
+		t.Errorf("配置 APIKey = %q, 期望 sk-team", cfg.APIKey)
+	}
+	if cfg.BaseURL != "https://api.openai.com/v1" {
+		t.Errorf("启用 Key 不应覆盖 BaseURL, 得到 %q", cfg.BaseURL)
+	}
+
+	keys, err := GetProviderKeys("openai")
+	if err != nil {
+		t.Fatalf("GetProviderKeys 失败: %v", err)
+	}
+	if len(keys) != 2 || keys[0].IsActive != 1 || keys[1].IsActive != 0 {
+		t.Fatalf("Key 列表 = %+v", keys)
+	}
+	if keys[1].APIKey != "sk-personal" || keys[0].Notes != "shared" {
+		t.Errorf("Key 内容不符: %+v", keys)
+	}
+
+	// 切换当前 Key
+	if err := ActivateProviderKey(personal); err != nil {
+		t.Fatalf("ActivateProviderKey 失败: %v", err)
+	}
+	cfg, _ = GetProviderConfig("openai")
+	if cfg.APIKey != "sk-personal" {
+		t.Errorf("切换后配置 APIKey = %q", cfg.APIKey)
+	}
+
+	// 记录检测结论
+	UpdateProviderKeyCheck(team, "invalid")
+	k, _ := GetProviderKey(team)
+	if k.LastVerdict != "invalid" || k.LastCheckedAt == "" {
+		t.Errorf("检测结论未记录: %+v", k)
+	}
+
+	// 删除当前 Key 后自动启用剩余 Key
+	if err := DeleteProviderKey(personal); err != nil {
+		t.Fatalf("DeleteProviderKey 失败: %v", err)
+	}
+	cfg, _ = GetProviderConfig("openai")
+	if cfg.APIKey != "sk-team" {
+		t.Errorf("删除后配置 APIKey = %q, 期望 sk-team", cfg.APIKey)
+	}
+	if _, err := GetProviderKey(personal); err != ErrKeyNotFound {
+		t.Errorf("已删除 Key 应返回 ErrKeyNotFound, 得到 %v", err)
+	}
+
+	// 已保存的 Key 计入 GetKeyOwners
+	AddProviderKey(ProviderKeyRow{ProviderID: "deepseek", APIKey: "sk-team"})
+	owners, _ := GetKeyOwners()
+	if len(owners["sk-team"]) != 2 {
+		t.Errorf("owners[sk-team] = %v, 期望 2 个供应商", owners["sk-team"])
+	}
+}
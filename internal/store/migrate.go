@@ -0,0 +1,290 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// migration 一次数据库结构变更，按 version 顺序执行，每个迁移在独立事务中完成
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sqlx.Tx) error
+}
+
+// execSQL 执行一段 SQL 的迁移
+func execSQL(schema string) func(tx *sqlx.Tx) error {
+	return func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(schema)
+		return err
+	}
+}
+
+// migrations 全部迁移，只能追加，不能修改已发布的迁移
+// 1-3 使用 IF NOT EXISTS，兼容引入版本号之前创建的数据库
+var migrations = []migration{
+	{1, "initial schema", execSQL(`
+	CREATE TABLE IF NOT EXISTS providers (
+		id          TEXT PRIMARY KEY,
+		name        TEXT NOT NULL,
+		base_url    TEXT NOT NULL,
+		protocol    TEXT NOT NULL DEFAULT 'openai',
+		models      TEXT NOT NULL DEFAULT '[]',
+		is_builtin  INTEGER NOT NULL DEFAULT 0,
+		created_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS provider_configs (
+		provider_id TEXT PRIMARY KEY,
+		api_key     TEXT NOT NULL DEFAULT '',
+		base_url    TEXT NOT NULL DEFAULT '',
+		model       TEXT NOT NULL DEFAULT '',
+		protocol    TEXT NOT NULL DEFAULT 'openai',
+		updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS check_history (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		provider_id   TEXT NOT NULL,
+		provider_name TEXT NOT NULL,
+		base_url      TEXT NOT NULL,
+		model         TEXT NOT NULL,
+		protocol      TEXT NOT NULL DEFAULT 'openai',
+		results_json  TEXT NOT NULL,
+		model_list    TEXT NOT NULL DEFAULT '[]',
+		total_latency INTEGER NOT NULL DEFAULT 0,
+		status        TEXT NOT NULL DEFAULT 'mixed',
+		created_at    DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_history_provider ON check_history(provider_id);
+	CREATE INDEX IF NOT EXISTS idx_history_created ON check_history(created_at);
+
+	CREATE TABLE IF NOT EXISTS provider_visibility (
+		provider_id TEXT PRIMARY KEY,
+		visible     INTEGER NOT NULL DEFAULT 1
+	);
+	`)},
+	{2, "settings", execSQL(`
+	CREATE TABLE IF NOT EXISTS settings (
+		key   TEXT PRIMARY KEY,
+		value TEXT NOT NULL DEFAULT ''
+	);
+	`)},
+	{3, "provider keys", execSQL(`
+	CREATE TABLE IF NOT EXISTS provider_keys (
+		id              INTEGER PRIMARY KEY AUTOINCREMENT,
+		provider_id     TEXT NOT NULL,
+		label           TEXT NOT NULL DEFAULT '',
+		api_key         TEXT NOT NULL,
+		notes           TEXT NOT NULL DEFAULT '',
+		is_active       INTEGER NOT NULL DEFAULT 0,
+		last_verdict    TEXT NOT NULL DEFAULT '',
+		last_checked_at TEXT NOT NULL DEFAULT '',
+		created_at      DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_provider_keys_provider ON provider_keys(provider_id);
+	`)},
+	{4, "history query indexes", execSQL(`
+	CREATE INDEX idx_history_provider_created ON check_history(provider_id, created_at);
+	CREATE INDEX idx_history_model ON check_history(model);
+	CREATE INDEX idx_history_status_created ON check_history(status, created_at);
+	CREATE INDEX idx_history_latency ON check_history(total_latency);
+	`)},
+	{5, "check results", execSQL(`
+	CREATE TABLE check_results (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		history_id INTEGER NOT NULL REFERENCES check_history(id) ON DELETE CASCADE,
+		item       TEXT NOT NULL,
+		status     TEXT NOT NULL,
+		latency    INTEGER NOT NULL DEFAULT 0,
+		ttft       INTEGER NOT NULL DEFAULT 0,
+		token_in   INTEGER NOT NULL DEFAULT 0,
+		token_out  INTEGER NOT NULL DEFAULT 0,
+		error_kind TEXT NOT NULL DEFAULT '',
+		message    TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE INDEX idx_check_results_history ON check_results(history_id);
+	CREATE INDEX idx_check_results_item_status ON check_results(item, status);
+
+	INSERT INTO check_results (history_id, item, status, latency, ttft, token_in, token_out, error_kind, message)
+	SELECT h.id,
+		COALESCE(json_extract(j.value, '$.item'), ''),
+		COALESCE(json_extract(j.value, '$.status'), ''),
+		COALESCE(json_extract(j.value, '$.latency'), 0),
+		COALESCE(json_extract(j.value, '$.ttft'), 0),
+		COALESCE(json_extract(j.value, '$.tokenIn'), 0),
+		COALESCE(json_extract(j.value, '$.tokenOut'), 0),
+		COALESCE(json_extract(j.value, '$.errorKind'), ''),
+		COALESCE(json_extract(j.value, '$.message'), '')
+	FROM check_history h, json_each(h.results_json) j
+	WHERE json_valid(h.results_json)
+	ORDER BY h.id, j.key;
+	`)},
+	{6, "schedules", execSQL(`
+	CREATE TABLE schedules (
+		id               INTEGER PRIMARY KEY AUTOINCREMENT,
+		provider_id      TEXT NOT NULL UNIQUE,
+		interval_seconds INTEGER NOT NULL DEFAULT 0,
+		cron             TEXT NOT NULL DEFAULT '',
+		jitter_seconds   INTEGER NOT NULL DEFAULT 0,
+		enabled          INTEGER NOT NULL DEFAULT 1,
+		last_run_at      TEXT NOT NULL DEFAULT '',
+		last_status      TEXT NOT NULL DEFAULT '',
+		created_at       DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`)},
+	{7, "webhooks", execSQL(`
+	CREATE TABLE webhooks (
+		id                INTEGER PRIMARY KEY AUTOINCREMENT,
+		name              TEXT NOT NULL DEFAULT '',
+		kind              TEXT NOT NULL DEFAULT 'generic',
+		url               TEXT NOT NULL,
+		enabled           INTEGER NOT NULL DEFAULT 1,
+		provider_ids      TEXT NOT NULL DEFAULT '[]',
+		fail_threshold    INTEGER NOT NULL DEFAULT 1,
+		quiet_start       TEXT NOT NULL DEFAULT '',
+		quiet_end         TEXT NOT NULL DEFAULT '',
+		created_at        DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`)},
+	{8, "check traffic", execSQL(`
+	CREATE TABLE check_traffic (
+		history_id INTEGER PRIMARY KEY REFERENCES check_history(id) ON DELETE CASCADE,
+		cassette   TEXT NOT NULL
+	);
+	`)},
+	{9, "custom cases", execSQL(`
+	CREATE TABLE custom_cases (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		name          TEXT NOT NULL UNIQUE,
+		system_prompt TEXT NOT NULL DEFAULT '',
+		messages      TEXT NOT NULL DEFAULT '[]',
+		params        TEXT NOT NULL DEFAULT '{}',
+		assertions    TEXT NOT NULL DEFAULT '[]',
+		enabled       INTEGER NOT NULL DEFAULT 1,
+		created_at    DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`)},
+	{10, "model profiles", execSQL(`
+	CREATE TABLE model_profiles (
+		model      TEXT PRIMARY KEY,
+		profile    TEXT NOT NULL,
+		source     TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`)},
+	{11, "model aliases", execSQL(`
+	CREATE TABLE model_aliases (
+		canonical   TEXT NOT NULL,
+		provider_id TEXT NOT NULL,
+		model_id    TEXT NOT NULL,
+		PRIMARY KEY (canonical, provider_id)
+	);
+	`)},
+	{12, "model info", execSQL(`
+	CREATE TABLE model_info (
+		provider_id TEXT NOT NULL,
+		model       TEXT NOT NULL,
+		info        TEXT NOT NULL,
+		updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (provider_id, model)
+	);
+	`)},
+	{13, "check cost", execSQL(`
+	ALTER TABLE check_history ADD COLUMN cost REAL NOT NULL DEFAULT 0;
+	ALTER TABLE check_history ADD COLUMN currency TEXT NOT NULL DEFAULT '';
+	ALTER TABLE check_history ADD COLUMN masked_key TEXT NOT NULL DEFAULT '';
+	`)},
+	{14, "spend records", execSQL(`
+	CREATE TABLE spend_records (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		provider_id TEXT NOT NULL,
+		masked_key  TEXT NOT NULL DEFAULT '',
+		model       TEXT NOT NULL DEFAULT '',
+		source      TEXT NOT NULL,
+		cost        REAL NOT NULL DEFAULT 0,
+		currency    TEXT NOT NULL,
+		created_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX idx_spend_records_created ON spend_records(created_at);
+	`)},
+	{15, "check spend", execSQL(`
+	INSERT INTO spend_records (provider_id, masked_key, model, source, cost, currency, created_at)
+	SELECT provider_id, masked_key, model, 'check', cost, currency, created_at
+	FROM check_history WHERE currency != '';
+	`)},
+}
+
+// SchemaVersion 当前程序支持的最新结构版本
+func SchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// ErrSchemaTooNew 数据库由更新版本的程序创建
+type ErrSchemaTooNew struct {
+	DBVersion     int
+	BinaryVersion int
+}
+
+func (e *ErrSchemaTooNew) Error() string {
+	return fmt.Sprintf("数据库结构版本 %d 高于当前程序支持的版本 %d，请升级 PingAI", e.DBVersion, e.BinaryVersion)
+}
+
+// currentVersion 读取数据库已应用的最高版本
+func currentVersion() (int, error) {
+	var version int
+	err := DB.Get(&version, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations")
+	return version, err
+}
+
+// migrate 依次执行未应用的迁移
+func migrate() error {
+	if _, err := DB.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`); err != nil {
+		return err
+	}
+
+	version, err := currentVersion()
+	if err != nil {
+		return err
+	}
+	// 拒绝打开更新版本的数据库，避免旧程序写坏新结构
+	if version > SchemaVersion() {
+		return &ErrSchemaTooNew{DBVersion: version, BinaryVersion: SchemaVersion()}
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		if err := applyMigration(m); err != nil {
+			return fmt.Errorf("迁移 %d (%s) 失败: %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(m migration) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.up(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.version, m.name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// createFixtureDB 用 testdata 中的旧结构创建数据库文件
+func createFixtureDB(t *testing.T, fixture string) string {
+	t.Helper()
+	schema, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatalf("读取 fixture 失败: %v", err)
+	}
+	dbPath := filepath.Join(t.TempDir(), "fixture.db")
+	db, err := sqlx.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("创建 fixture 数据库失败: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(string(schema)); err != nil {
+		t.Fatalf("写入 fixture 失败: %v", err)
+	}
+	return dbPath
+}
+
+func TestMigrateFromV0Fixture(t *testing.T) {
+	dbPath := createFixtureDB(t, "schema_v0.sql")
+	if err := InitWithPath(dbPath); err != nil {
+		t.Fatalf("升级 v0 数据库失败: %v", err)
+	}
+	defer Close()
+
+	version, err := currentVersion()
+	if err != nil || version != SchemaVersion() {
+		t.Fatalf("升级后版本 = %d (%v), 期望 %d", version, err, SchemaVersion())
+	}
+
+	// 原有数据保留
+	customs, _ := GetCustomProviders()
+	if len(customs) != 1 || customs[0].ID != "relay" {
+		t.Errorf("自定义供应商 = %+v", customs)
+	}
+	cfg, err := GetProviderConfig("relay")
+	if err != nil || cfg.APIKey != "sk-fixture-key" {
+		t.Errorf("配置 Key = %q (%v)", cfg.APIKey, err)
+	}
+	if count, _ := GetHistoryCount(); count != 2 {
+		t.Errorf("历史数 = %d, 期望 2", count)
+	}
+	hidden, _ := GetHiddenProviderIDs()
+	if len(hidden) != 1 || hidden[0] != "ollama" {
+		t.Errorf("隐藏供应商 = %v", hidden)
+	}
+
+	// 检测项从 results_json 回填
+	results, err := QueryCheckResults(HistoryQuery{ProviderIDs: []string{"relay"}})
+	if err != nil || len(results) != 3 {
+		t.Fatalf("回填检测项 = %+v (%v)", results, err)
+	}
+	if r := results[1]; r.Item != "chat" || r.Latency != 800 || r.TokenIn != 12 || r.TokenOut != 3 {
+		t.Errorf("chat 检测项 = %+v", r)
+	}
+	if r := results[2]; r.Status != "failed" || r.Message != "HTTP 429" {
+		t.Errorf("stream 检测项 = %+v", r)
+	}
+
+	// 新表可用
+	if _, err := AddProviderKey(ProviderKeyRow{ProviderID: "relay", APIKey: "sk-new"}); err != nil {
+		t.Errorf("升级后新增 Key 失败: %v", err)
+	}
+
+	// 再次打开不重复执行迁移
+	Close()
+	if err := InitWithPath(dbPath); err != nil {
+		t.Fatalf("重新打开失败: %v", err)
+	}
+	var applied int
+	DB.Get(&applied, "SELECT COUNT(*) FROM schema_migrations")
+	if applied != len(migrations) {
+		t.Errorf("迁移记录数 = %d, 期望 %d", applied, len(migrations))
+	}
+}
+
+func TestRefuseNewerSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	if err := InitWithPath(dbPath); err != nil {
+		t.Fatalf("初始化失败: %v", err)
+	}
+	DB.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, 'from the future')", SchemaVersion()+1)
+	Close()
+
+	err := InitWithPath(dbPath)
+	var tooNew *ErrSchemaTooNew
+	if !errors.As(err, &tooNew) {
+		t.Fatalf("期望 ErrSchemaTooNew, 得到 %v", err)
+	}
+	if tooNew.DBVersion != SchemaVersion()+1 {
+		t.Errorf("DBVersion = %d", tooNew.DBVersion)
+	}
+	if IsOpen() {
+		t.Error("拒绝打开后 IsOpen 应为 false")
+	}
+	if _, err := GetCustomProviders(); !errors.Is(err, ErrNotOpen) {
+		t.Errorf("未打开时应返回 ErrNotOpen, 得到 %v", err)
+	}
+}
+
+func TestFailedMigrationRollsBack(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	if err := InitWithPath(dbPath); err != nil {
+		t.Fatalf("初始化失败: %v", err)
+	}
+	Close()
+
+	saved := migrations
+	defer func() { migrations = saved }()
+	migrations = append(append([]migration{}, saved...), migration{
+		version: SchemaVersion() + 1,
+		name:    "broken",
+		up:      execSQL("CREATE TABLE half_done (id INTEGER); SELECT * FROM no_such_table;"),
+	})
+
+	if err := InitWithPath(dbPath); err == nil {
+		t.Fatal("失败的迁移应返回错误")
+	}
+
+	migrations = saved
+	if err := InitWithPath(dbPath); err != nil {
+		t.Fatalf("重新打开失败: %v", err)
+	}
+	defer Close()
+	var n int
+	DB.Get(&n, "SELECT COUNT(*) FROM sqlite_master WHERE name = 'half_done'")
+	if n != 0 {
+		t.Error("失败迁移的部分变更未回滚")
+	}
+	if v, _ := currentVersion(); v != SchemaVersion() {
+		t.Errorf("版本 = %d, 期望 %d", v, SchemaVersion())
+	}
+}
@@ -0,0 +1,41 @@
+package store
+
+import "errors"
+
+// ModelAliasRow 用户添加的模型别名：规范名在某个供应商处的模型 ID
+type ModelAliasRow struct {
+	Canonical  string `db:"canonical" json:"canonical"`
+	ProviderID string `db:"provider_id" json:"providerID"`
+	ModelID    string `db:"model_id" json:"modelID"`
+}
+
+// ErrModelAliasNotFound 别名不存在
+var ErrModelAliasNotFound = errors.New("模型别名不存在")
+
+// SaveModelAlias 保存模型别名，同一规范名和供应商已存在时覆盖
+func SaveModelAlias(a ModelAliasRow) error {
+	_, err := DB.Exec(`
+		INSERT INTO model_aliases (canonical, provider_id, model_id) VALUES (?, ?, ?)
+		ON CONFLICT(canonical, provider_id) DO UPDATE SET model_id = excluded.model_id
+	`, a.Canonical, a.ProviderID, a.ModelID)
+	return err
+}
+
+// GetModelAliases 列出用户添加的全部模型别名
+func GetModelAliases() ([]ModelAliasRow, error) {
+	var rows []ModelAliasRow
+	err := DB.Select(&rows, "SELECT canonical, provider_id, model_id FROM model_aliases ORDER BY canonical, provider_id")
+	return rows, err
+}
+
+// DeleteModelAlias 删除用户添加的模型别名
+func DeleteModelAlias(canonical, providerID string) error {
+	res, err := DB.Exec("DELETE FROM model_aliases WHERE canonical = ? AND provider_id = ?", canonical, providerID)
+	if err != nil {
+		return err
+	}
+	if affected(res) == 0 {
+		return ErrModelAliasNotFound
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package store
+
+import "testing"
+
+func TestModelAliases(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	SaveModelAlias(ModelAliasRow{Canonical: "deepseek-v3", ProviderID: "myrelay", ModelID: "ds-v3"})
+	if err := SaveModelAlias(ModelAliasRow{Canonical: "deepseek-v3", ProviderID: "myrelay", ModelID: "deepseek-v3-0324"}); err != nil {
+		t.Fatalf("SaveModelAlias 失败: %v", err)
+	}
+	SaveModelAlias(ModelAliasRow{Canonical: "deepseek-r1", ProviderID: "myrelay", ModelID: "ds-r1"})
+
+	rows, err := GetModelAliases()
+	if err != nil || len(rows) != 2 || rows[1].ModelID != "deepseek-v3-0324" {
+		t.Errorf("GetModelAliases = %+v, %v", rows, err)
+	}
+
+	if err := DeleteModelAlias("deepseek-v3", "myrelay"); err != nil {
+		t.Fatalf("DeleteModelAlias 失败: %v", err)
+	}
+	if err := DeleteModelAlias("deepseek-v3", "myrelay"); err != ErrModelAliasNotFound {
+		t.Errorf("重复删除应返回 ErrModelAliasNotFound, 得到 %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ModelInfoRow 从模型列表接口获取的模型元数据
+type ModelInfoRow struct {
+	ProviderID string `db:"provider_id" json:"providerID"`
+	Model      string `db:"model" json:"model"`
+	Info       string `db:"info" json:"info"` // JSON，见 protocol.ModelInfo
+	UpdatedAt  string `db:"updated_at" json:"updatedAt"`
+}
+
+// ErrModelInfoNotFound 没有该模型的元数据
+var ErrModelInfoNotFound = errors.New("没有该模型的元数据")
+
+// ReplaceModelInfo 替换供应商的全部模型元数据
+func ReplaceModelInfo(providerID string, rows []ModelInfoRow) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM model_info WHERE provider_id = ?", providerID); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO model_info (provider_id, model, info) VALUES (?, ?, ?)", providerID, r.Model, r.Info); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetModelInfo 读取供应商的全部模型元数据
+func GetModelInfo(providerID string) ([]ModelInfoRow, error) {
+	var rows []ModelInfoRow
+	err := DB.Select(&rows, "SELECT provider_id, model, info, updated_at FROM model_info WHERE provider_id = ? ORDER BY model", providerID)
+	return rows, err
+}
+
+// GetModelInfoFor 读取单个模型的元数据
+func GetModelInfoFor(providerID, model string) (*ModelInfoRow, error) {
+	var row ModelInfoRow
+	err := DB.Get(&row, "SELECT provider_id, model, info, updated_at FROM model_info WHERE provider_id = ? AND model = ?", providerID, model)
+	if err == sql.ErrNoRows {
+		return nil, ErrModelInfoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
@@ -0,0 +1,25 @@
+package store
+
+import "testing"
+
+func TestModelInfo(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if _, err := GetModelInfoFor("openrouter", "a"); err != ErrModelInfoNotFound {
+		t.Errorf("不存在时应返回 ErrModelInfoNotFound, 得到 %v", err)
+	}
+	ReplaceModelInfo("openrouter", []ModelInfoRow{{Model: "a", Info: `{"id":"a"}`}, {Model: "b", Info: `{"id":"b"}`}})
+	ReplaceModelInfo("groq", []ModelInfoRow{{Model: "a", Info: `{"id":"groq-a"}`}})
+	if err := ReplaceModelInfo("openrouter", []ModelInfoRow{{Model: "c", Info: `{"id":"c"}`}}); err != nil {
+		t.Fatalf("ReplaceModelInfo 失败: %v", err)
+	}
+
+	rows, err := GetModelInfo("openrouter")
+	if err != nil || len(rows) != 1 || rows[0].Model != "c" || rows[0].UpdatedAt == "" {
+		t.Errorf("替换后 = %+v, %v", rows, err)
+	}
+	if row, err := GetModelInfoFor("groq", "a"); err != nil || row.Info != `{"id":"groq-a"}` {
+		t.Errorf("GetModelInfoFor = %+v, %v", row, err)
+	}
+}
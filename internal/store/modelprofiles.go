@@ -0,0 +1,58 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ModelProfileRow 模型真实性检测的参考档案，每个模型一份
+type ModelProfileRow struct {
+	Model     string `db:"model" json:"model"`
+	Profile   string `db:"profile" json:"profile"` // JSON，见 fingerprint.Profile
+	Source    string `db:"source" json:"source"`
+	UpdatedAt string `db:"updated_at" json:"updatedAt"`
+}
+
+// ErrModelProfileNotFound 该模型没有参考档案
+var ErrModelProfileNotFound = errors.New("该模型没有参考档案")
+
+// SaveModelProfile 保存模型参考档案，已存在时覆盖
+func SaveModelProfile(p ModelProfileRow) error {
+	_, err := DB.Exec(`
+		INSERT INTO model_profiles (model, profile, source) VALUES (?, ?, ?)
+		ON CONFLICT(model) DO UPDATE SET profile = excluded.profile, source = excluded.source, updated_at = CURRENT_TIMESTAMP
+	`, p.Model, p.Profile, p.Source)
+	return err
+}
+
+// GetModelProfile 读取单个模型的参考档案
+func GetModelProfile(model string) (*ModelProfileRow, error) {
+	var p ModelProfileRow
+	err := DB.Get(&p, "SELECT model, profile, source, updated_at FROM model_profiles WHERE model = ?", model)
+	if err == sql.ErrNoRows {
+		return nil, ErrModelProfileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetModelProfiles 列出全部参考档案
+func GetModelProfiles() ([]ModelProfileRow, error) {
+	var rows []ModelProfileRow
+	err := DB.Select(&rows, "SELECT model, profile, source, updated_at FROM model_profiles ORDER BY model")
+	return rows, err
+}
+
+// DeleteModelProfile 删除模型参考档案
+func DeleteModelProfile(model string) error {
+	res, err := DB.Exec("DELETE FROM model_profiles WHERE model = ?", model)
+	if err != nil {
+		return err
+	}
+	if affected(res) == 0 {
+		return ErrModelProfileNotFound
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package store
+
+import "testing"
+
+func TestModelProfiles(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if _, err := GetModelProfile("gpt-4o"); err != ErrModelProfileNotFound {
+		t.Errorf("不存在时应返回 ErrModelProfileNotFound, 得到 %v", err)
+	}
+	SaveModelProfile(ModelProfileRow{Model: "gpt-4o", Profile: `{"cutoff":"2023-10"}`, Source: "openai"})
+	if err := SaveModelProfile(ModelProfileRow{Model: "gpt-4o", Profile: `{"cutoff":"2024-06"}`, Source: "azure"}); err != nil {
+		t.Fatalf("SaveModelProfile 失败: %v", err)
+	}
+	p, err := GetModelProfile("gpt-4o")
+	if err != nil || p.Profile != `{"cutoff":"2024-06"}` || p.Source != "azure" || p.UpdatedAt == "" {
+		t.Errorf("GetModelProfile = %+v, %v", p, err)
+	}
+	SaveModelProfile(ModelProfileRow{Model: "claude-3-haiku", Profile: "{}"})
+	if rows, _ := GetModelProfiles(); len(rows) != 2 || rows[0].Model != "claude-3-haiku" {
+		t.Errorf("GetModelProfiles = %+v", rows)
+	}
+
+	if err := DeleteModelProfile("gpt-4o"); err != nil {
+		t.Fatalf("DeleteModelProfile 失败: %v", err)
+	}
+	if err := DeleteModelProfile("gpt-4o"); err != ErrModelProfileNotFound {
+		t.Errorf("重复删除应返回 ErrModelProfileNotFound, 得到 %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+// CheckResultRow 单个检测项结果，由 SaveHistory 从 results_json 拆分写入
+type CheckResultRow struct {
+	ID        int64  `db:"id" json:"id"`
+	HistoryID int64  `db:"history_id" json:"historyID"`
+	Item      string `db:"item" json:"item"`
+	Status    string `db:"status" json:"status"`
+	Latency   int64  `db:"latency" json:"latency"`
+	TTFT      int64  `db:"ttft" json:"ttft"`
+	TokenIn   int    `db:"token_in" json:"tokenIn"`
+	TokenOut  int    `db:"token_out" json:"tokenOut"`
+	ErrorKind string `db:"error_kind" json:"errorKind"`
+	Message   string `db:"message" json:"message"`
+}
+
+// insertCheckResults 将一条历史的 results_json 展开写入 check_results
+func insertCheckResults(tx *sqlx.Tx, historyID int64, resultsJSON string) error {
+	_, err := tx.Exec(`
+		INSERT INTO check_results (history_id, item, status, latency, ttft, token_in, token_out, error_kind, message)
+		SELECT ?,
+			COALESCE(json_extract(j.value, '$.item'), ''),
+			COALESCE(json_extract(j.value, '$.status'), ''),
+			COALESCE(json_extract(j.value, '$.latency'), 0),
+			COALESCE(json_extract(j.value, '$.ttft'), 0),
+			COALESCE(json_extract(j.value, '$.tokenIn'), 0),
+			COALESCE(json_extract(j.value, '$.tokenOut'), 0),
+			COALESCE(json_extract(j.value, '$.errorKind'), ''),
+			COALESCE(json_extract(j.value, '$.message'), '')
+		FROM json_each(CASE WHEN json_valid(?) THEN ? ELSE '[]' END) j
+		ORDER BY j.key
+	`, historyID, resultsJSON, resultsJSON)
+	return err
+}
+
+// QueryCheckResults 查询符合历史过滤条件的全部检测项结果，忽略分页和排序
+func QueryCheckResults(q HistoryQuery) ([]CheckResultRow, error) {
+	where, args := q.where()
+	var rows []CheckResultRow
+	err := DB.Select(&rows, `
+		SELECT r.* FROM check_results r
+		WHERE r.history_id IN (SELECT id FROM check_history`+where+`)
+		ORDER BY r.history_id, r.id
+	`, args...)
+	return rows, err
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 历史精简模式
+const (
+	CompactNone     = ""         // 不精简
+	CompactFailures = "failures" // 只保留失败记录
+	CompactChanges  = "changes"  // 只保留状态发生变化的记录
+)
+
+const settingRetention = "history.retention"
+
+// ProviderRetention 单个供应商的保留规则，零值表示沿用全局规则
+type ProviderRetention struct {
+	MaxAgeDays int `json:"maxAgeDays"`
+	MaxRows    int `json:"maxRows"`
+}
+
+// RetentionPolicy 历史保留策略，数值为 0 表示不限制
+type RetentionPolicy struct {
+	MaxAgeDays       int                          `json:"maxAgeDays"`
+	MaxRows          int                          `json:"maxRows"`
+	Providers        map[string]ProviderRetention `json:"providers"`
+	CompactMode      string                       `json:"compactMode"`      // CompactNone / CompactFailures / CompactChanges
+	CompactAfterDays int                          `json:"compactAfterDays"` // 超过该天数的记录才精简
+}
+
+// DefaultRetentionPolicy 未配置时使用的策略：不清理任何历史，需用户主动开启
+var DefaultRetentionPolicy = RetentionPolicy{}
+
+// PruneReport 一次清理的结果
+type PruneReport struct {
+	Deleted     int64 `json:"deleted"`
+	BytesBefore int64 `json:"bytesBefore"`
+	BytesAfter  int64 `json:"bytesAfter"`
+	FreedBytes  int64 `json:"freedBytes"`
+}
+
+// GetRetentionPolicy 读取历史保留策略
+func GetRetentionPolicy() (RetentionPolicy, error) {
+	raw, err := GetSetting(settingRetention)
+	if err != nil || raw == "" {
+		return DefaultRetentionPolicy, err
+	}
+	var p RetentionPolicy
+	if err := json.Unmarshal([]byte(raw), &p); err != nil {
+		return DefaultRetentionPolicy, err
+	}
+	return p, nil
+}
+
+// SetRetentionPolicy 保存历史保留策略
+func SetRetentionPolicy(p RetentionPolicy) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return SetSetting(settingRetention, string(data))
+}
+
+// PruneHistory 按策略删除历史记录并回收空间
+func PruneHistory(p RetentionPolicy) (PruneReport, error) {
+	return pruneHistory(p, time.Now())
+}
+
+func pruneHistory(p RetentionPolicy, now time.Time) (PruneReport, error) {
+	var report PruneReport
+	report.BytesBefore = dbSize()
+
+	tx, err := DB.Beginx()
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback()
+
+	del := func(query string, args ...any) error {
+		res, err := tx.Exec(query, args...)
+		if err != nil {
+			return err
+		}
+		n, _ := res.RowsAffected()
+		report.Deleted += n
+		return nil
+	}
+
+	// 供应商规则优先，未单独配置的供应商使用全局规则
+	for id, rule := range p.Providers {
+		if rule.MaxAgeDays > 0 {
+			if err := del("DELETE FROM check_history WHERE provider_id = ? AND created_at < ?", id, cutoff(now, rule.MaxAgeDays)); err != nil {
+				return report, err
+			}
+		}
+		if rule.MaxRows > 0 {
+			if err := del(`
+				DELETE FROM check_history WHERE provider_id = ? AND id NOT IN (
+					SELECT id FROM check_history WHERE provider_id = ? ORDER BY created_at DESC, id DESC LIMIT ?
+				)`, id, id, rule.MaxRows); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	if p.MaxAgeDays > 0 {
+		query := "DELETE FROM check_history WHERE created_at < ?"
+		args := []any{cutoff(now, p.MaxAgeDays)}
+		for id, rule := range p.Providers {
+			if rule.MaxAgeDays > 0 {
+				query += " AND provider_id != ?"
+				args = append(args, id)
+			}
+		}
+		if err := del(query, args...); err != nil {
+			return report, err
+		}
+	}
+
+	if p.CompactAfterDays > 0 {
+		before := cutoff(now, p.CompactAfterDays)
+		switch p.CompactMode {
+		case CompactFailures:
+			if err := del("DELETE FROM check_history WHERE created_at < ? AND status != 'failed'", before); err != nil {
+				return report, err
+			}
+		case CompactChanges:
+			// 同一供应商+模型下，状态与上一次相同的记录视为无变化
+			if err := del(`
+				DELETE FROM check_history WHERE id IN (
+					SELECT id FROM (
+						SELECT id, created_at, status,
+							LAG(status) OVER (PARTITION BY provider_id, model ORDER BY created_at, id) AS prev
+						FROM check_history
+					) WHERE created_at < ? AND prev = status
+				)`, before); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	// 全局行数上限最后执行，删除最旧的记录
+	if p.MaxRows > 0 {
+		if err := del(`
+			DELETE FROM check_history WHERE id NOT IN (
+				SELECT id FROM check_history ORDER BY created_at DESC, id DESC LIMIT ?
+			)`, p.MaxRows); err != nil {
+			return report, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return report, err
+	}
+
+	if report.Deleted > 0 {
+		if _, err := DB.Exec("VACUUM"); err != nil {
+			return report, err
+		}
+	}
+	report.BytesAfter = dbSize()
+	report.FreedBytes = max(report.BytesBefore-report.BytesAfter, 0)
+	return report, nil
+}
+
+// cutoff 返回 days 天前的时间，格式与 CURRENT_TIMESTAMP 一致
+func cutoff(now time.Time, days int) string {
+	return now.UTC().AddDate(0, 0, -days).Format("2006-01-02 15:04:05")
+}
+
+// dbSize 数据库占用的字节数 (页数 × 页大小)
+func dbSize() int64 {
+	var pages, size int64
+	DB.Get(&pages, "PRAGMA page_count")
+	DB.Get(&size, "PRAGMA page_size")
+	return pages * size
+}
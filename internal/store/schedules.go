@@ -0,0 +1,75 @@
+package store
+
+import (
+	"strconv"
+)
+
+// ScheduleRow 供应商定时检测计划
+type ScheduleRow struct {
+	ID              int64  `db:"id" json:"id"`
+	ProviderID      string `db:"provider_id" json:"providerID"`
+	IntervalSeconds int64  `db:"interval_seconds" json:"intervalSeconds"`
+	Cron            string `db:"cron" json:"cron"`
+	JitterSeconds   int64  `db:"jitter_seconds" json:"jitterSeconds"`
+	Enabled         int    `db:"enabled" json:"enabled"`
+	LastRunAt       string `db:"last_run_at" json:"lastRunAt"`
+	LastStatus      string `db:"last_status" json:"lastStatus"`
+	CreatedAt       string `db:"created_at" json:"createdAt"`
+}
+
+const settingSchedulerConcurrency = "scheduler.concurrency"
+
+// SaveSchedule 保存计划，每个供应商只有一个计划，返回计划 ID
+func SaveSchedule(s ScheduleRow) (int64, error) {
+	_, err := DB.Exec(`
+		INSERT INTO schedules (provider_id, interval_seconds, cron, jitter_seconds, enabled)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(provider_id) DO UPDATE SET
+			interval_seconds = excluded.interval_seconds,
+			cron = excluded.cron,
+			jitter_seconds = excluded.jitter_seconds,
+			enabled = excluded.enabled
+	`, s.ProviderID, s.IntervalSeconds, s.Cron, s.JitterSeconds, s.Enabled)
+	if err != nil {
+		return 0, err
+	}
+	var id int64
+	err = DB.Get(&id, "SELECT id FROM schedules WHERE provider_id = ?", s.ProviderID)
+	return id, err
+}
+
+// GetSchedules 获取全部计划
+func GetSchedules() ([]ScheduleRow, error) {
+	var rows []ScheduleRow
+	err := DB.Select(&rows, "SELECT * FROM schedules ORDER BY id")
+	return rows, err
+}
+
+// DeleteSchedule 删除计划
+func DeleteSchedule(id int64) error {
+	_, err := DB.Exec("DELETE FROM schedules WHERE id = ?", id)
+	return err
+}
+
+// UpdateScheduleRun 记录计划最近一次运行结果
+func UpdateScheduleRun(id int64, status string) error {
+	_, err := DB.Exec(`
+		UPDATE schedules SET last_run_at = datetime('now'), last_status = ?
+		WHERE id = ?
+	`, status, id)
+	return err
+}
+
+// GetSchedulerConcurrency 读取定时检测的全局并发上限，未设置时返回 0
+func GetSchedulerConcurrency() (int, error) {
+	v, err := GetSetting(settingSchedulerConcurrency)
+	if err != nil || v == "" {
+		return 0, err
+	}
+	return strconv.Atoi(v)
+}
+
+// SetSchedulerConcurrency 保存定时检测的全局并发上限
+func SetSchedulerConcurrency(n int) error {
+	return SetSetting(settingSchedulerConcurrency, strconv.Itoa(n))
+}
@@ -0,0 +1,43 @@
+package store
+
+import "testing"
+
+func TestSchedulesCRUD(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	id, err := SaveSchedule(ScheduleRow{ProviderID: "relay", IntervalSeconds: 300, JitterSeconds: 30, Enabled: 1})
+	if err != nil {
+		t.Fatalf("SaveSchedule 失败: %v", err)
+	}
+	// 同一供应商再次保存为更新
+	again, _ := SaveSchedule(ScheduleRow{ProviderID: "relay", Cron: "*/5 * * * *", Enabled: 1})
+	if again != id {
+		t.Errorf("更新后 ID = %d, 期望 %d", again, id)
+	}
+	SaveSchedule(ScheduleRow{ProviderID: "groq", IntervalSeconds: 60, Enabled: 0})
+
+	UpdateScheduleRun(id, "failed")
+	rows, err := GetSchedules()
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("GetSchedules = %+v (%v)", rows, err)
+	}
+	if r := rows[0]; r.Cron != "*/5 * * * *" || r.IntervalSeconds != 0 || r.LastStatus != "failed" || r.LastRunAt == "" {
+		t.Errorf("计划 = %+v", r)
+	}
+
+	DeleteSchedule(id)
+	AddCustomProvider(ProviderRow{ID: "groq", Name: "Groq", BaseURL: "x", Protocol: "openai", Models: "[]"})
+	DeleteCustomProvider("groq")
+	if rows, _ := GetSchedules(); len(rows) != 0 {
+		t.Errorf("删除后剩余计划 = %+v", rows)
+	}
+
+	if n, _ := GetSchedulerConcurrency(); n != 0 {
+		t.Errorf("默认并发 = %d", n)
+	}
+	SetSchedulerConcurrency(8)
+	if n, _ := GetSchedulerConcurrency(); n != 8 {
+		t.Errorf("并发 = %d, 期望 8", n)
+	}
+}
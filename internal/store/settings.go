@@ -0,0 +1,130 @@
+package store
+
+import (
+	"database/sql"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetSetting 读取设置项，不存在时返回空字符串
+func GetSetting(key string) (string, error) {
+	var value string
+	err := DB.Get(&value, "SELECT value FROM settings WHERE key = ?", key)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value, err
+}
+
+// SetSetting 写入设置项
+func SetSetting(key, value string) error {
+	return setSetting(DB, key, value)
+}
+
+func setSetting(ex sqlx.Execer, key, value string) error {
+	_, err := ex.Exec(`
+		INSERT INTO settings (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, key, value)
+	return err
+}
+
+const settingMetricsAddr = "metrics.addr"
+
+// GetMetricsAddr 读取 Prometheus 指标监听地址，为空表示不启用
+func GetMetricsAddr() (string, error) {
+	return GetSetting(settingMetricsAddr)
+}
+
+// SetMetricsAddr 保存 Prometheus 指标监听地址
+func SetMetricsAddr(addr string) error {
+	return SetSetting(settingMetricsAddr, addr)
+}
+
+const (
+	settingAPIAddr  = "api.addr"
+	settingAPIToken = "api.token"
+)
+
+// GetAPIAddr 读取 REST API 监听地址，为空表示不启用
+func GetAPIAddr() (string, error) {
+	return GetSetting(settingAPIAddr)
+}
+
+// SetAPIAddr 保存 REST API 监听地址
+func SetAPIAddr(addr string) error {
+	return SetSetting(settingAPIAddr, addr)
+}
+
+// GetAPIToken 读取 REST API 访问令牌
+func GetAPIToken() (string, error) {
+	return GetSetting(settingAPIToken)
+}
+
+// SetAPIToken 保存 REST API 访问令牌
+func SetAPIToken(token string) error {
+	return SetSetting(settingAPIToken, token)
+}
+
+const settingProxyAddr = "proxy.addr"
+
+// GetProxyAddr 读取 OpenAI 兼容代理的监听地址，为空表示不启用
+func GetProxyAddr() (string, error) {
+	return GetSetting(settingProxyAddr)
+}
+
+// SetProxyAddr 保存代理监听地址
+func SetProxyAddr(addr string) error {
+	return SetSetting(settingProxyAddr, addr)
+}
+
+const settingTrafficRecord = "traffic.record"
+
+// GetTrafficRecording 是否录制检测时的原始请求和响应
+func GetTrafficRecording() (bool, error) {
+	v, err := GetSetting(settingTrafficRecord)
+	return v == "1", err
+}
+
+// SetTrafficRecording 开关流量录制
+func SetTrafficRecording(on bool) error {
+	v := "0"
+	if on {
+		v = "1"
+	}
+	return SetSetting(settingTrafficRecord, v)
+}
+
+const settingAuthenticity = "authenticity.enabled"
+
+// GetAuthenticityEnabled 完整检测时是否执行模型真实性检测
+func GetAuthenticityEnabled() (bool, error) {
+	v, err := GetSetting(settingAuthenticity)
+	return v == "1", err
+}
+
+// SetAuthenticityEnabled 开关模型真实性检测
+func SetAuthenticityEnabled(on bool) error {
+	v := "0"
+	if on {
+		v = "1"
+	}
+	return SetSetting(settingAuthenticity, v)
+}
+
+const settingBatchConcurrency = "batch.concurrency"
+
+// GetBatchConcurrency 读取批量检测的并发上限，未设置时返回 0
+func GetBatchConcurrency() (int, error) {
+	v, err := GetSetting(settingBatchConcurrency)
+	if err != nil || v == "" {
+		return 0, err
+	}
+	return strconv.Atoi(v)
+}
+
+// SetBatchConcurrency 保存批量检测的并发上限
+func SetBatchConcurrency(n int) error {
+	return SetSetting(settingBatchConcurrency, strconv.Itoa(n))
+}
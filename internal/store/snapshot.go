@@ -0,0 +1,282 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// VisibilityRow 供应商可见性设置
+type VisibilityRow struct {
+	ProviderID string `db:"provider_id" json:"providerID"`
+	Visible    int    `db:"visible" json:"visible"`
+}
+
+// Snapshot 可迁移的全部用户数据，Key 为明文
+type Snapshot struct {
+	Providers  []ProviderRow       `json:"providers"`
+	Configs    []ProviderConfigRow `json:"configs"`
+	Keys       []ProviderKeyRow    `json:"keys"`
+	Visibility []VisibilityRow     `json:"visibility"`
+	History    []HistoryRow        `json:"history"` // nil 表示快照不含历史
+}
+
+// 恢复模式
+const (
+	RestoreMerge   = "merge"   // 保留本地数据，冲突项跳过
+	RestoreReplace = "replace" // 先清空本地配置，再写入快照
+)
+
+// RestoreConflict 按供应商 ID 记录的冲突
+type RestoreConflict struct {
+	ProviderID string `json:"providerID"`
+	Kind       string `json:"kind"`       // provider / config / visibility
+	Resolution string `json:"resolution"` // skipped / replaced
+}
+
+// RestoreResult 恢复结果统计
+type RestoreResult struct {
+	Providers  int               `json:"providers"`
+	Configs    int               `json:"configs"`
+	Keys       int               `json:"keys"`
+	Visibility int               `json:"visibility"`
+	History    int               `json:"history"`
+	Conflicts  []RestoreConflict `json:"conflicts"`
+}
+
+// TakeSnapshot 导出当前数据，includeKeys 为 false 时清空全部 Key
+func TakeSnapshot(includeKeys, includeHistory bool) (Snapshot, error) {
+	var s Snapshot
+	var err error
+	if s.Providers, err = GetCustomProviders(); err != nil {
+		return s, err
+	}
+	if err = DB.Select(&s.Configs, "SELECT * FROM provider_configs ORDER BY provider_id"); err != nil {
+		return s, err
+	}
+	if err = DB.Select(&s.Visibility, "SELECT * FROM provider_visibility ORDER BY provider_id"); err != nil {
+		return s, err
+	}
+
+	if includeKeys {
+		for i := range s.Configs {
+			if s.Configs[i].APIKey, err = decryptSecret(s.Configs[i].APIKey); err != nil {
+				return s, err
+			}
+		}
+		if s.Keys, err = GetAllProviderKeys(); err != nil {
+			return s, err
+		}
+	} else {
+		for i := range s.Configs {
+			s.Configs[i].APIKey = ""
+		}
+	}
+
+	if includeHistory {
+		s.History = []HistoryRow{}
+		if err = DB.Select(&s.History, "SELECT * FROM check_history ORDER BY id"); err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+// RestoreSnapshot 在一个事务中写入快照
+func RestoreSnapshot(s Snapshot, mode string) (RestoreResult, error) {
+	result := RestoreResult{Conflicts: []RestoreConflict{}}
+	replace := mode == RestoreReplace
+
+	tx, err := DB.Beginx()
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	exists := func(query, id string) (bool, error) {
+		var n int
+		err := tx.Get(&n, query, id)
+		return n > 0, err
+	}
+	conflict := func(id, kind string) {
+		resolution := "skipped"
+		if replace {
+			resolution = "replaced"
+		}
+		result.Conflicts = append(result.Conflicts, RestoreConflict{ProviderID: id, Kind: kind, Resolution: resolution})
+	}
+
+	// 冲突在清空前统计，替换模式下也能看到被覆盖的项
+	for _, p := range s.Providers {
+		found, err := exists("SELECT COUNT(*) FROM providers WHERE id = ?", p.ID)
+		if err != nil {
+			return result, err
+		}
+		if found {
+			conflict(p.ID, "provider")
+		}
+	}
+	for _, c := range s.Configs {
+		found, err := exists("SELECT COUNT(*) FROM provider_configs WHERE provider_id = ?", c.ProviderID)
+		if err != nil {
+			return result, err
+		}
+		if found {
+			conflict(c.ProviderID, "config")
+		}
+	}
+	for _, v := range s.Visibility {
+		found, err := exists("SELECT COUNT(*) FROM provider_visibility WHERE provider_id = ?", v.ProviderID)
+		if err != nil {
+			return result, err
+		}
+		if found {
+			conflict(v.ProviderID, "visibility")
+		}
+	}
+
+	if replace {
+		for _, q := range []string{
+			"DELETE FROM providers WHERE is_builtin = 0",
+			"DELETE FROM provider_configs",
+			"DELETE FROM provider_keys",
+			"DELETE FROM provider_visibility",
+		} {
+			if _, err := tx.Exec(q); err != nil {
+				return result, err
+			}
+		}
+		if s.History != nil {
+			if _, err := tx.Exec("DELETE FROM check_history"); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	// 合并模式下 DO NOTHING 跳过本地已有的项
+	for _, p := range s.Providers {
+		res, err := tx.Exec(`
+			INSERT INTO providers (id, name, base_url, protocol, models, is_builtin)
+			VALUES (?, ?, ?, ?, ?, 0)
+			ON CONFLICT(id) DO NOTHING
+		`, p.ID, p.Name, p.BaseURL, p.Protocol, p.Models)
+		if err != nil {
+			return result, err
+		}
+		result.Providers += affected(res)
+	}
+
+	// 本次写入了配置的供应商，只有这些供应商才沿用备份中的启用 Key
+	insertedConfigs := make(map[string]bool)
+	for _, c := range s.Configs {
+		apiKey, err := encryptSecret(c.APIKey)
+		if err != nil {
+			return result, err
+		}
+		res, err := tx.Exec(`
+			INSERT INTO provider_configs (provider_id, api_key, base_url, model, protocol, updated_at)
+			VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT(provider_id) DO NOTHING
+		`, c.ProviderID, apiKey, c.BaseURL, c.Model, c.Protocol)
+		if err != nil {
+			return result, err
+		}
+		if n := affected(res); n > 0 {
+			insertedConfigs[c.ProviderID] = true
+			result.Configs += n
+		}
+	}
+
+	for _, k := range s.Keys {
+		// 同一供应商下已有相同 Key 时跳过
+		var dup bool
+		local, err := providerKeysTx(tx, k.ProviderID)
+		if err != nil {
+			return result, err
+		}
+		hasActive := false
+		for _, l := range local {
+			if l.APIKey == k.APIKey {
+				dup = true
+			}
+			if l.IsActive == 1 {
+				hasActive = true
+			}
+		}
+		if dup {
+			continue
+		}
+		apiKey, err := encryptSecret(k.APIKey)
+		if err != nil {
+			return result, err
+		}
+		// 保留本地配置时启用导入的 Key 会与 provider_configs.api_key 不一致
+		active := 0
+		if k.IsActive == 1 && !hasActive && insertedConfigs[k.ProviderID] {
+			active = 1
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO provider_keys (provider_id, label, api_key, notes, is_active, last_verdict, last_checked_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, k.ProviderID, k.Label, apiKey, k.Notes, active, k.LastVerdict, k.LastCheckedAt); err != nil {
+			return result, err
+		}
+		result.Keys++
+	}
+
+	for _, v := range s.Visibility {
+		res, err := tx.Exec(`
+			INSERT INTO provider_visibility (provider_id, visible) VALUES (?, ?)
+			ON CONFLICT(provider_id) DO NOTHING
+		`, v.ProviderID, v.Visible)
+		if err != nil {
+			return result, err
+		}
+		result.Visibility += affected(res)
+	}
+
+	for _, h := range s.History {
+		// 重复导入同一备份时不产生重复历史
+		var n int
+		if err := tx.Get(&n, `
+			SELECT COUNT(*) FROM check_history
+			WHERE provider_id = ? AND model = ? AND created_at = ? AND results_json = ?
+		`, h.ProviderID, h.Model, normalizeTime(h.CreatedAt, false), h.ResultsJSON); err != nil {
+			return result, err
+		}
+		if n > 0 {
+			continue
+		}
+		res, err := tx.Exec(`
+			INSERT INTO check_history (provider_id, provider_name, base_url, model, protocol, results_json, model_list, total_latency, status, cost, currency, masked_key, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(NULLIF(?, ''), CURRENT_TIMESTAMP))
+		`, h.ProviderID, h.ProviderName, h.BaseURL, h.Model, h.Protocol, h.ResultsJSON, h.ModelList, h.TotalLatency, h.Status, h.Cost, h.Currency, h.MaskedKey, normalizeTime(h.CreatedAt, false))
+		if err != nil {
+			return result, err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return result, err
+		}
+		if err := insertCheckResults(tx, id, h.ResultsJSON); err != nil {
+			return result, err
+		}
+		result.History++
+	}
+
+	return result, tx.Commit()
+}
+
+// providerKeysTx 在事务中读取供应商已保存的 Key (已解密)
+func providerKeysTx(tx *sqlx.Tx, providerID string) ([]ProviderKeyRow, error) {
+	var rows []ProviderKeyRow
+	if err := tx.Select(&rows, "SELECT * FROM provider_keys WHERE provider_id = ?", providerID); err != nil {
+		return nil, err
+	}
+	return rows, decryptKeyRows(rows)
+}
+
+func affected(res sql.Result) int {
+	n, _ := res.RowsAffected()
+	return int(n)
+}
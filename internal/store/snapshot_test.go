@@ -0,0 +1,79 @@
+package store
+
+import "testing"
+
+func TestSnapshotRestore(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	AddCustomProvider(ProviderRow{ID: "relay", Name: "Relay", BaseURL: "https://relay.example.com/v1", Protocol: "openai", Models: "[]"})
+	SaveProviderConfig(ProviderConfigRow{ProviderID: "relay", APIKey: "sk-relay", Model: "gpt-4o", Protocol: "openai"})
+	AddProviderKey(ProviderKeyRow{ProviderID: "relay", Label: "team", APIKey: "sk-relay"})
+	SetProviderVisibility("ollama", false)
+	seedHistory(t, HistoryRow{ProviderID: "relay", ProviderName: "Relay", Model: "gpt-4o",
+		ResultsJSON: `[{"item":"chat","status":"success"}]`, Status: "success"}, "2026-03-10 10:00:00")
+
+	snap, err := TakeSnapshot(true, true)
+	if err != nil {
+		t.Fatalf("TakeSnapshot 失败: %v", err)
+	}
+	if snap.Configs[0].APIKey != "sk-relay" || len(snap.Keys) != 1 || len(snap.History) != 1 {
+		t.Fatalf("快照 = %+v", snap)
+	}
+	if noKeys, _ := TakeSnapshot(false, false); noKeys.Configs[0].APIKey != "" || noKeys.Keys != nil || noKeys.History != nil {
+		t.Errorf("不含 Key 的快照 = %+v", noKeys)
+	}
+
+	// 合并：本地已有的供应商和配置跳过并报告冲突，重复的 Key 和历史不重复写入
+	SaveProviderConfig(ProviderConfigRow{ProviderID: "relay", APIKey: "sk-local", Model: "gpt-4o-mini"})
+	res, err := RestoreSnapshot(snap, RestoreMerge)
+	if err != nil {
+		t.Fatalf("合并恢复失败: %v", err)
+	}
+	if res.Providers != 0 || res.Configs != 0 || res.Keys != 0 || res.History != 0 || len(res.Conflicts) != 3 {
+		t.Errorf("合并结果 = %+v", res)
+	}
+	if res.Conflicts[0].ProviderID != "relay" || res.Conflicts[0].Resolution != "skipped" {
+		t.Errorf("冲突 = %+v", res.Conflicts)
+	}
+	if cfg, _ := GetProviderConfig("relay"); cfg.APIKey != "sk-local" {
+		t.Errorf("合并不应覆盖本地配置, APIKey = %q", cfg.APIKey)
+	}
+
+	// 替换：清空后写入快照
+	res, err = RestoreSnapshot(snap, RestoreReplace)
+	if err != nil {
+		t.Fatalf("替换恢复失败: %v", err)
+	}
+	if res.Providers != 1 || res.Configs != 1 || res.Keys != 1 || res.Visibility != 1 || res.History != 1 {
+		t.Errorf("替换结果 = %+v", res)
+	}
+	if res.Conflicts[0].Resolution != "replaced" {
+		t.Errorf("替换冲突 = %+v", res.Conflicts)
+	}
+	if cfg, _ := GetProviderConfig("relay"); cfg.APIKey != "sk-relay" || cfg.Model != "gpt-4o" {
+		t.Errorf("替换后配置 = %+v", cfg)
+	}
+	rows, total, _ := QueryHistory(HistoryQuery{})
+	if total != 1 || rows[0].CreatedAt[:10] != "2026-03-10" {
+		t.Errorf("替换后历史 = %+v", rows)
+	}
+	if results, _ := QueryCheckResults(HistoryQuery{}); len(results) != 1 {
+		t.Errorf("恢复的历史未写入检测项: %+v", results)
+	}
+	keys, _ := GetProviderKeys("relay")
+	if len(keys) != 1 || keys[0].IsActive != 1 {
+		t.Errorf("恢复的 Key = %+v", keys)
+	}
+
+	// 合并时保留了本地配置，导入的 Key 不应被启用
+	DeleteProviderKey(keys[0].ID)
+	SaveProviderConfig(ProviderConfigRow{ProviderID: "relay", APIKey: "sk-local", Model: "gpt-4o"})
+	if _, err := RestoreSnapshot(snap, RestoreMerge); err != nil {
+		t.Fatalf("合并恢复失败: %v", err)
+	}
+	keys, _ = GetProviderKeys("relay")
+	if len(keys) != 1 || keys[0].IsActive != 0 {
+		t.Errorf("保留本地配置时导入的 Key = %+v", keys)
+	}
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SpendRow 按供应商、Key、日期汇总的检测费用
+type SpendRow struct {
+	ProviderID string  `db:"provider_id" json:"providerID"`
+	MaskedKey  string  `db:"masked_key" json:"maskedKey"`
+	Day        string  `db:"day" json:"day"` // 本地日期 YYYY-MM-DD
+	Currency   string  `db:"currency" json:"currency"`
+	Cost       float64 `db:"cost" json:"cost"`
+	Runs       int     `db:"runs" json:"runs"`
+}
+
+// 费用来源
+const (
+	SpendCheck        = "check"        // 保存历史的检测
+	SpendSweep        = "sweep"        // 模型扫描
+	SpendCustomCase   = "custom_case"  // 用例试运行
+	SpendAuthenticity = "authenticity" // 单独运行的真实性检测
+	SpendProfile      = "profile"      // 采集参考档案
+)
+
+// SpendRecord 一次操作的费用。费用单独记账，清理或删除检测历史不影响费用统计和月度预算
+type SpendRecord struct {
+	ProviderID string  `db:"provider_id"`
+	MaskedKey  string  `db:"masked_key"`
+	Model      string  `db:"model"`
+	Source     string  `db:"source"`
+	Cost       float64 `db:"cost"`
+	Currency   string  `db:"currency"`
+}
+
+// RecordSpend 在一个事务中保存费用记录，价格未知 (Currency 为空) 的记录跳过
+func RecordSpend(records ...SpendRecord) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, r := range records {
+		if err := insertSpend(tx, r); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// insertSpend 在事务中写入一条费用记录，价格未知的记录跳过
+func insertSpend(tx *sqlx.Tx, r SpendRecord) error {
+	if r.Currency == "" {
+		return nil
+	}
+	_, err := tx.NamedExec(`
+		INSERT INTO spend_records (provider_id, masked_key, model, source, cost, currency)
+		VALUES (:provider_id, :masked_key, :model, :source, :cost, :currency)
+	`, r)
+	return err
+}
+
+// QuerySpend 按本地日期汇总 [from, to] 范围内的费用，空值表示不限制；价格未知的记录不计入
+func QuerySpend(from, to string) ([]SpendRow, error) {
+	where := " WHERE currency != ''"
+	var args []any
+	if from != "" {
+		where += " AND created_at >= ?"
+		args = append(args, utcTime(from, false))
+	}
+	if to != "" {
+		where += " AND created_at <= ?"
+		args = append(args, utcTime(to, true))
+	}
+	var records []struct {
+		ProviderID string    `db:"provider_id"`
+		MaskedKey  string    `db:"masked_key"`
+		Currency   string    `db:"currency"`
+		Cost       float64   `db:"cost"`
+		CreatedAt  time.Time `db:"created_at"`
+	}
+	if err := DB.Select(&records, `
+		SELECT provider_id, masked_key, currency, cost, created_at FROM spend_records`+where+`
+		ORDER BY created_at DESC, id DESC
+	`, args...); err != nil {
+		return nil, err
+	}
+
+	// created_at 为 UTC，按本地日期分组，与历史筛选和统计图表一致
+	rows := []SpendRow{}
+	index := make(map[SpendRow]int)
+	for _, r := range records {
+		key := SpendRow{ProviderID: r.ProviderID, MaskedKey: r.MaskedKey, Day: r.CreatedAt.Local().Format("2006-01-02"), Currency: r.Currency}
+		i, ok := index[key]
+		if !ok {
+			i = len(rows)
+			index[key] = i
+			rows = append(rows, key)
+		}
+		rows[i].Cost += r.Cost
+		rows[i].Runs++
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Day != rows[j].Day {
+			return rows[i].Day > rows[j].Day
+		}
+		if rows[i].ProviderID != rows[j].ProviderID {
+			return rows[i].ProviderID < rows[j].ProviderID
+		}
+		return rows[i].MaskedKey < rows[j].MaskedKey
+	})
+	return rows, nil
+}
+
+// MonthSpend 返回 now 所在本地自然月各币种的累计费用
+func MonthSpend(now time.Time) (map[string]float64, error) {
+	now = now.Local()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	var rows []struct {
+		Currency string  `db:"currency"`
+		Cost     float64 `db:"cost"`
+	}
+	if err := DB.Select(&rows, `
+		SELECT currency, SUM(cost) AS cost FROM spend_records
+		WHERE currency != '' AND created_at >= ? AND created_at < ?
+		GROUP BY currency
+	`, start.UTC().Format(sqliteTimeFmt), start.AddDate(0, 1, 0).UTC().Format(sqliteTimeFmt)); err != nil {
+		return nil, err
+	}
+	spend := make(map[string]float64, len(rows))
+	for _, r := range rows {
+		spend[r.Currency] = r.Cost
+	}
+	return spend, nil
+}
+
+// 超出预算后的处理方式
+const (
+	BudgetWarn  = "warn"  // 仅提示
+	BudgetBlock = "block" // 暂停定时检测
+)
+
+const settingBudget = "budget.monthly"
+
+// Budget 月度预算，Amount 为 0 表示不限制
+type Budget struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"` // USD / CNY
+	Action   string  `json:"action"`   // BudgetWarn / BudgetBlock
+}
+
+// BudgetStatus 本月预算使用情况
+type BudgetStatus struct {
+	Budget   Budget  `json:"budget"`
+	Spent    float64 `json:"spent"` // 本月与预算同币种的费用
+	Exceeded bool    `json:"exceeded"`
+	// Uncounted 本月其他币种的费用，不做汇率换算，也不计入预算
+	Uncounted map[string]float64 `json:"uncounted,omitempty"`
+}
+
+// GetBudget 读取月度预算
+func GetBudget() (Budget, error) {
+	raw, err := GetSetting(settingBudget)
+	if err != nil || raw == "" {
+		return Budget{}, err
+	}
+	var b Budget
+	if err := json.Unmarshal([]byte(raw), &b); err != nil {
+		return Budget{}, err
+	}
+	return b, nil
+}
+
+// SetBudget 保存月度预算
+func SetBudget(b Budget) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return SetSetting(settingBudget, string(data))
+}
+
+// GetBudgetStatus 计算 now 所在月份的预算使用情况
+func GetBudgetStatus(now time.Time) (BudgetStatus, error) {
+	b, err := GetBudget()
+	if err != nil {
+		return BudgetStatus{}, err
+	}
+	status := BudgetStatus{Budget: b}
+	if b.Amount <= 0 {
+		return status, nil
+	}
+	spend, err := MonthSpend(now)
+	if err != nil {
+		return status, err
+	}
+	status.Spent = spend[b.Currency]
+	for currency, cost := range spend {
+		if currency != b.Currency {
+			if status.Uncounted == nil {
+				status.Uncounted = make(map[string]float64)
+			}
+			status.Uncounted[currency] = cost
+		}
+	}
+	status.Exceeded = status.Spent >= b.Amount
+	return status, nil
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpend(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	add := func(provider, key, currency string, cost float64, createdAt string) {
+		id, err := SaveHistory(HistoryRow{ProviderID: provider, ResultsJSON: "[]", Cost: cost, Currency: currency, MaskedKey: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+		DB.Exec("UPDATE check_history SET created_at = ? WHERE id = ?", createdAt, id)
+		DB.Exec("UPDATE spend_records SET created_at = ? WHERE id = (SELECT MAX(id) FROM spend_records)", createdAt)
+	}
+	add("openai", "sk-a...1111", "USD", 0.01, "2026-03-01 10:00:00")
+	add("openai", "sk-a...1111", "USD", 0.02, "2026-03-01 12:00:00")
+	add("openai", "sk-b...2222", "USD", 0.05, "2026-03-02 08:00:00")
+	add("deepseek", "sk-c...3333", "CNY", 0.3, "2026-03-02 09:00:00")
+	add("ollama", "", "", 0, "2026-03-02 09:00:00")
+	add("openai", "sk-a...1111", "USD", 1, "2026-02-28 23:00:00")
+
+	// 扫描等不保存历史的操作，价格未知的不记录
+	if err := RecordSpend(
+		SpendRecord{ProviderID: "openai", MaskedKey: "sk-b...2222", Model: "gpt-4o", Source: SpendSweep, Cost: 0.04, Currency: "USD"},
+		SpendRecord{ProviderID: "ollama", Model: "llama3", Source: SpendSweep},
+	); err != nil {
+		t.Fatal(err)
+	}
+	DB.Exec("UPDATE spend_records SET created_at = '2026-03-02 10:00:00' WHERE source = ?", SpendSweep)
+
+	rows, err := QuerySpend("2026-03-01", "2026-03-31")
+	if err != nil || len(rows) != 3 {
+		t.Fatalf("QuerySpend = %+v, %v", rows, err)
+	}
+	if r := rows[2]; r.Day != "2026-03-01" || r.Runs != 2 || r.Cost < 0.0299 || r.Cost > 0.0301 {
+		t.Errorf("同一天同一 Key 应合并: %+v", r)
+	}
+	if r := rows[1]; r.MaskedKey != "sk-b...2222" || r.Runs != 2 || r.Cost < 0.0899 || r.Cost > 0.0901 {
+		t.Errorf("应包含扫描费用: %+v", r)
+	}
+
+	now := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	spend, err := MonthSpend(now)
+	if err != nil || spend["CNY"] != 0.3 || spend["USD"] < 0.1199 || spend["USD"] > 0.1201 {
+		t.Errorf("MonthSpend = %v, %v", spend, err)
+	}
+
+	// 界面传入本地日期，按本地日期和月份分组；created_at 为 UTC
+	local := time.Local
+	time.Local = time.FixedZone("UTC+8", 8*3600)
+	rows, _ = QuerySpend("2026-03-01", "2026-03-01")
+	if len(rows) != 1 || rows[0].Day != "2026-03-01" || rows[0].Runs != 3 {
+		t.Errorf("UTC 2026-02-28 23:00 属于本地 2026-03-01: %+v", rows)
+	}
+	if m, _ := MonthSpend(now); m["USD"] < 1.1199 || m["USD"] > 1.1201 {
+		t.Errorf("本地三月应包含 UTC 二月末的记录: %v", m)
+	}
+	time.Local = local
+
+	// 费用单独记账，删除历史后仍计入
+	if err := DeleteAllHistory(); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := MonthSpend(now); after["USD"] != spend["USD"] || after["CNY"] != spend["CNY"] {
+		t.Errorf("删除历史不应减少费用: %v -> %v", spend, after)
+	}
+
+	if s, _ := GetBudgetStatus(now); s.Exceeded {
+		t.Errorf("未设置预算时不应超出: %+v", s)
+	}
+	SetBudget(Budget{Amount: 0.05, Currency: "USD", Action: BudgetBlock})
+	s, err := GetBudgetStatus(now)
+	if err != nil || !s.Exceeded || s.Budget.Action != BudgetBlock {
+		t.Errorf("GetBudgetStatus = %+v, %v", s, err)
+	}
+	SetBudget(Budget{Amount: 1, Currency: "CNY", Action: BudgetWarn})
+	if s, _ := GetBudgetStatus(now); s.Exceeded || s.Spent != 0.3 || s.Uncounted["USD"] < 0.1199 {
+		t.Errorf("只统计预算币种，其他币种单独列出: %+v", s)
+	}
+}
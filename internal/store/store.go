@@ -1,7 +1,10 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"os"
 	"path/filepath"
 
@@ -9,39 +12,64 @@ import (
 	_ "modernc.org/sqlite"
 )
 
-// DB 全局数据库实例
-var DB *sqlx.DB
+// DB 全局数据库实例，未打开时所有操作返回 ErrNotOpen
+var DB = notOpenDB()
+
+// ErrNotOpen 数据库未打开 (初始化或迁移失败)
+var ErrNotOpen = errors.New("数据库未打开")
+
+// notOpenDriver 每次建立连接都返回 ErrNotOpen，使未打开时的调用返回错误而不是空指针 panic
+type notOpenDriver struct{}
+
+func (notOpenDriver) Open(string) (driver.Conn, error) { return nil, ErrNotOpen }
+
+func (d notOpenDriver) Connect(context.Context) (driver.Conn, error) { return nil, ErrNotOpen }
+
+func (d notOpenDriver) Driver() driver.Driver { return d }
+
+func notOpenDB() *sqlx.DB {
+	return sqlx.NewDb(sql.OpenDB(notOpenDriver{}), "sqlite")
+}
+
+// IsOpen 数据库是否已成功打开
+func IsOpen() bool {
+	_, closed := DB.Driver().(notOpenDriver)
+	return !closed
+}
 
 // Init 初始化数据库
 func Init() error {
-	dbPath := getDBPath()
-	var err error
-	DB, err = sqlx.Open("sqlite", dbPath+"?_pragma=journal_mode(wal)&_pragma=busy_timeout(5000)")
-	if err != nil {
-		return err
-	}
-	DB.SetMaxOpenConns(1)
-	return migrate()
+	return open(getDBPath())
 }
 
 // InitWithPath 使用指定路径初始化数据库，供测试使用
 func InitWithPath(dbPath string) error {
 	dir := filepath.Dir(dbPath)
 	os.MkdirAll(dir, 0755)
-	var err error
-	DB, err = sqlx.Open("sqlite", dbPath+"?_pragma=journal_mode(wal)&_pragma=busy_timeout(5000)")
+	return open(dbPath)
+}
+
+func open(dbPath string) error {
+	db, err := sqlx.Open("sqlite", dbPath+"?_pragma=journal_mode(wal)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)")
 	if err != nil {
 		return err
 	}
-	DB.SetMaxOpenConns(1)
-	return migrate()
+	db.SetMaxOpenConns(1)
+	DB = db
+	if err := migrate(); err != nil {
+		db.Close()
+		DB = notOpenDB()
+		return err
+	}
+	// 主密钥文件与数据库放在同一目录
+	return openVault(filepath.Join(filepath.Dir(dbPath), "master.key"))
 }
 
 // Close 关闭数据库
 func Close() {
-	if DB != nil {
-		DB.Close()
-	}
+	DB.Close()
+	DB = notOpenDB()
+	closeVault()
 }
 
 func getDBPath() string {
@@ -51,53 +79,6 @@ func getDBPath() string {
 	return filepath.Join(dir, "data.db")
 }
 
-func migrate() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS providers (
-		id          TEXT PRIMARY KEY,
-		name        TEXT NOT NULL,
-		base_url    TEXT NOT NULL,
-		protocol    TEXT NOT NULL DEFAULT 'openai',
-		models      TEXT NOT NULL DEFAULT '[]',
-		is_builtin  INTEGER NOT NULL DEFAULT 0,
-		created_at  DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS provider_configs (
-		provider_id TEXT PRIMARY KEY,
-		api_key     TEXT NOT NULL DEFAULT '',
-		base_url    TEXT NOT NULL DEFAULT '',
-		model       TEXT NOT NULL DEFAULT '',
-		protocol    TEXT NOT NULL DEFAULT 'openai',
-		updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS check_history (
-		id            INTEGER PRIMARY KEY AUTOINCREMENT,
-		provider_id   TEXT NOT NULL,
-		provider_name TEXT NOT NULL,
-		base_url      TEXT NOT NULL,
-		model         TEXT NOT NULL,
-		protocol      TEXT NOT NULL DEFAULT 'openai',
-		results_json  TEXT NOT NULL,
-		model_list    TEXT NOT NULL DEFAULT '[]',
-		total_latency INTEGER NOT NULL DEFAULT 0,
-		status        TEXT NOT NULL DEFAULT 'mixed',
-		created_at    DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_history_provider ON check_history(provider_id);
-	CREATE INDEX IF NOT EXISTS idx_history_created ON check_history(created_at);
-
-	CREATE TABLE IF NOT EXISTS provider_visibility (
-		provider_id TEXT PRIMARY KEY,
-		visible     INTEGER NOT NULL DEFAULT 1
-	);
-	`
-	_, err := DB.Exec(schema)
-	return err
-}
-
 // ProviderRow 供应商数据行
 type ProviderRow struct {
 	ID        string `db:"id" json:"id"`
@@ -121,24 +102,31 @@ type ProviderConfigRow struct {
 
 // HistoryRow 历史记录行
 type HistoryRow struct {
-	ID           int64  `db:"id" json:"id"`
-	ProviderID   string `db:"provider_id" json:"providerID"`
-	ProviderName string `db:"provider_name" json:"providerName"`
-	BaseURL      string `db:"base_url" json:"baseURL"`
-	Model        string `db:"model" json:"model"`
-	Protocol     string `db:"protocol" json:"protocol"`
-	ResultsJSON  string `db:"results_json" json:"resultsJSON"`
-	ModelList    string `db:"model_list" json:"modelList"`
-	TotalLatency int64  `db:"total_latency" json:"totalLatency"`
-	Status       string `db:"status" json:"status"`
-	CreatedAt    string `db:"created_at" json:"createdAt"`
+	ID           int64   `db:"id" json:"id"`
+	ProviderID   string  `db:"provider_id" json:"providerID"`
+	ProviderName string  `db:"provider_name" json:"providerName"`
+	BaseURL      string  `db:"base_url" json:"baseURL"`
+	Model        string  `db:"model" json:"model"`
+	Protocol     string  `db:"protocol" json:"protocol"`
+	ResultsJSON  string  `db:"results_json" json:"resultsJSON"`
+	ModelList    string  `db:"model_list" json:"modelList"`
+	TotalLatency int64   `db:"total_latency" json:"totalLatency"`
+	Status       string  `db:"status" json:"status"`
+	CreatedAt    string  `db:"created_at" json:"createdAt"`
+	Cost         float64 `db:"cost" json:"cost"`
+	Currency     string  `db:"currency" json:"currency"` // 为空表示价格未知
+	MaskedKey    string  `db:"masked_key" json:"maskedKey"`
 }
 
 // --- 供应商配置 CRUD ---
 
-// SaveProviderConfig 保存供应商配置
+// SaveProviderConfig 保存供应商配置，API Key 加密后写入
 func SaveProviderConfig(cfg ProviderConfigRow) error {
-	_, err := DB.Exec(`
+	apiKey, err := encryptSecret(cfg.APIKey)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`
 		INSERT INTO provider_configs (provider_id, api_key, base_url, model, protocol, updated_at)
 		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 		ON CONFLICT(provider_id) DO UPDATE SET
@@ -147,7 +135,7 @@ func SaveProviderConfig(cfg ProviderConfigRow) error {
 			model = excluded.model,
 			protocol = excluded.protocol,
 			updated_at = CURRENT_TIMESTAMP
-	`, cfg.ProviderID, cfg.APIKey, cfg.BaseURL, cfg.Model, cfg.Protocol)
+	`, cfg.ProviderID, apiKey, cfg.BaseURL, cfg.Model, cfg.Protocol)
 	return err
 }
 
@@ -158,14 +146,61 @@ func GetProviderConfig(providerID string) (*ProviderConfigRow, error) {
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
+	if err != nil {
+		return nil, err
+	}
+	row.APIKey, err = decryptSecret(row.APIKey)
 	return &row, err
 }
 
 // GetAllProviderConfigs 获取所有供应商配置
+// 保险库锁定时仍返回其余字段，API Key 置空并返回 ErrVaultLocked
 func GetAllProviderConfigs() ([]ProviderConfigRow, error) {
 	var rows []ProviderConfigRow
-	err := DB.Select(&rows, "SELECT * FROM provider_configs ORDER BY updated_at DESC")
-	return rows, err
+	if err := DB.Select(&rows, "SELECT * FROM provider_configs ORDER BY updated_at DESC"); err != nil {
+		return nil, err
+	}
+	var firstErr error
+	for i := range rows {
+		key, err := decryptSecret(rows[i].APIKey)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		rows[i].APIKey = key
+	}
+	return rows, firstErr
+}
+
+// GetKeyOwners 获取已保存的 API Key -> 供应商 ID 列表
+func GetKeyOwners() (map[string][]string, error) {
+	configs, err := GetAllProviderConfigs()
+	if err != nil {
+		return nil, err
+	}
+	saved, err := GetAllProviderKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make(map[string][]string)
+	add := func(key, providerID string) {
+		if key == "" {
+			return
+		}
+		for _, id := range owners[key] {
+			if id == providerID {
+				return
+			}
+		}
+		owners[key] = append(owners[key], providerID)
+	}
+	for _, c := range configs {
+		add(c.APIKey, c.ProviderID)
+	}
+	for _, k := range saved {
+		add(k.APIKey, k.ProviderID)
+	}
+	return owners, nil
 }
 
 // --- 自定义供应商 CRUD ---
@@ -201,21 +236,42 @@ func DeleteCustomProvider(id string) error {
 
 	tx.Exec("DELETE FROM providers WHERE id = ? AND is_builtin = 0", id)
 	tx.Exec("DELETE FROM provider_configs WHERE provider_id = ?", id)
+	tx.Exec("DELETE FROM provider_keys WHERE provider_id = ?", id)
+	tx.Exec("DELETE FROM schedules WHERE provider_id = ?", id)
 	return tx.Commit()
 }
 
 // --- 历史记录 CRUD ---
 
-// SaveHistory 保存检测历史
+// SaveHistory 保存检测历史，并将各检测项写入 check_results、费用写入 spend_records
 func SaveHistory(h HistoryRow) (int64, error) {
-	result, err := DB.Exec(`
-		INSERT INTO check_history (provider_id, provider_name, base_url, model, protocol, results_json, model_list, total_latency, status)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, h.ProviderID, h.ProviderName, h.BaseURL, h.Model, h.Protocol, h.ResultsJSON, h.ModelList, h.TotalLatency, h.Status)
+	tx, err := DB.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO check_history (provider_id, provider_name, base_url, model, protocol, results_json, model_list, total_latency, status, cost, currency, masked_key)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, h.ProviderID, h.ProviderName, h.BaseURL, h.Model, h.Protocol, h.ResultsJSON, h.ModelList, h.TotalLatency, h.Status, h.Cost, h.Currency, h.MaskedKey)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
 	if err != nil {
 		return 0, err
 	}
-	return result.LastInsertId()
+	if err := insertCheckResults(tx, id, h.ResultsJSON); err != nil {
+		return 0, err
+	}
+	if err := insertSpend(tx, SpendRecord{
+		ProviderID: h.ProviderID, MaskedKey: h.MaskedKey, Model: h.Model,
+		Source: SpendCheck, Cost: h.Cost, Currency: h.Currency,
+	}); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
 }
 
 // GetHistory 获取历史记录列表
@@ -225,6 +281,19 @@ func GetHistory(limit, offset int) ([]HistoryRow, error) {
 	return rows, err
 }
 
+// ErrHistoryNotFound 历史记录不存在
+var ErrHistoryNotFound = errors.New("历史记录不存在")
+
+// GetHistoryByID 获取单条历史
+func GetHistoryByID(id int64) (*HistoryRow, error) {
+	var row HistoryRow
+	err := DB.Get(&row, "SELECT * FROM check_history WHERE id = ?", id)
+	if err == sql.ErrNoRows {
+		return nil, ErrHistoryNotFound
+	}
+	return &row, err
+}
+
 // GetHistoryCount 获取历史总数
 func GetHistoryCount() (int, error) {
 	var count int
@@ -285,7 +354,7 @@ func ResetProviderVisibility() error {
 	return err
 }
 
-// ResetAll 重置全部数据：删除自定义供应商、配置、可见性
+// ResetAll 重置全部数据：删除自定义供应商、配置、已保存的 Key、可见性、定时检测
 func ResetAll() error {
 	tx, err := DB.Begin()
 	if err != nil {
@@ -294,6 +363,8 @@ func ResetAll() error {
 	defer tx.Rollback()
 	tx.Exec("DELETE FROM providers WHERE is_builtin = 0")
 	tx.Exec("DELETE FROM provider_configs")
+	tx.Exec("DELETE FROM provider_keys")
 	tx.Exec("DELETE FROM provider_visibility")
+	tx.Exec("DELETE FROM schedules")
 	return tx.Commit()
 }
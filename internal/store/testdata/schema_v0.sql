@@ -0,0 +1,58 @@
+-- 引入版本化迁移之前 (v0) 的数据库结构与样例数据
+CREATE TABLE providers (
+	id          TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	base_url    TEXT NOT NULL,
+	protocol    TEXT NOT NULL DEFAULT 'openai',
+	models      TEXT NOT NULL DEFAULT '[]',
+	is_builtin  INTEGER NOT NULL DEFAULT 0,
+	created_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE provider_configs (
+	provider_id TEXT PRIMARY KEY,
+	api_key     TEXT NOT NULL DEFAULT '',
+	base_url    TEXT NOT NULL DEFAULT '',
+	model       TEXT NOT NULL DEFAULT '',
+	protocol    TEXT NOT NULL DEFAULT 'openai',
+	updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE check_history (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	provider_id   TEXT NOT NULL,
+	provider_name TEXT NOT NULL,
+	base_url      TEXT NOT NULL,
+	model         TEXT NOT NULL,
+	protocol      TEXT NOT NULL DEFAULT 'openai',
+	results_json  TEXT NOT NULL,
+	model_list    TEXT NOT NULL DEFAULT '[]',
+	total_latency INTEGER NOT NULL DEFAULT 0,
+	status        TEXT NOT NULL DEFAULT 'mixed',
+	created_at    DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_history_provider ON check_history(provider_id);
+CREATE INDEX idx_history_created ON check_history(created_at);
+
+CREATE TABLE provider_visibility (
+	provider_id TEXT PRIMARY KEY,
+	visible     INTEGER NOT NULL DEFAULT 1
+);
+
+INSERT INTO providers (id, name, base_url, protocol, models) VALUES
+	('relay', 'My Relay', 'https://relay.example.com/v1', 'openai', '["gpt-4o"]');
+
+INSERT INTO provider_configs (provider_id, api_key, base_url, model, protocol) VALUES
+	('relay', 'sk-fixture-key', 'https://relay.example.com/v1', 'gpt-4o', 'openai'),
+	('groq', '', 'https://api.groq.com/openai/v1', 'llama-3.1-8b-instant', 'openai');
+
+INSERT INTO check_history (provider_id, provider_name, base_url, model, protocol, results_json, model_list, total_latency, status, created_at) VALUES
+	('relay', 'My Relay', 'https://relay.example.com/v1', 'gpt-4o', 'openai',
+	 '[{"item":"connectivity","status":"success","latency":120},{"item":"chat","status":"success","latency":800,"tokenIn":12,"tokenOut":3},{"item":"stream","status":"failed","latency":900,"message":"HTTP 429"}]',
+	 '["gpt-4o"]', 1500, 'failed', '2026-01-10 08:00:00'),
+	('groq', 'Groq', 'https://api.groq.com/openai/v1', 'llama-3.1-8b-instant', 'openai',
+	 '[{"item":"connectivity","status":"success","latency":90},{"item":"chat","status":"success","latency":300}]',
+	 '[]', 400, 'success', '2026-01-11 09:30:00');
+
+INSERT INTO provider_visibility (provider_id, visible) VALUES ('ollama', 0);
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ErrTrafficNotFound 该历史记录没有录制流量
+var ErrTrafficNotFound = errors.New("没有录制该次检测的流量")
+
+// SaveTraffic 保存一次检测的录制内容 (JSONL)，随历史记录一起删除
+func SaveTraffic(historyID int64, cassette string) error {
+	_, err := DB.Exec(`
+		INSERT INTO check_traffic (history_id, cassette) VALUES (?, ?)
+		ON CONFLICT(history_id) DO UPDATE SET cassette = excluded.cassette
+	`, historyID, cassette)
+	return err
+}
+
+// GetTraffic 读取一次检测的录制内容
+func GetTraffic(historyID int64) (string, error) {
+	var cassette string
+	err := DB.Get(&cassette, "SELECT cassette FROM check_traffic WHERE history_id = ?", historyID)
+	if err == sql.ErrNoRows {
+		return "", ErrTrafficNotFound
+	}
+	return cassette, err
+}
+
+// HistoryIDsWithTraffic 返回有录制内容的历史记录 ID
+func HistoryIDsWithTraffic() ([]int64, error) {
+	var ids []int64
+	err := DB.Select(&ids, "SELECT history_id FROM check_traffic ORDER BY history_id")
+	return ids, err
+}
@@ -0,0 +1,28 @@
+package store
+
+import "testing"
+
+func TestTraffic(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	id := seedHistory(t, HistoryRow{ProviderID: "groq", Model: "m", ResultsJSON: "[]", Status: "success"}, "2026-01-01 00:00:00")
+	if _, err := GetTraffic(id); err != ErrTrafficNotFound {
+		t.Errorf("未录制时应返回 ErrTrafficNotFound, 得到 %v", err)
+	}
+	SaveTraffic(id, "a\n")
+	if err := SaveTraffic(id, "b\n"); err != nil {
+		t.Fatalf("SaveTraffic 失败: %v", err)
+	}
+	if c, _ := GetTraffic(id); c != "b\n" {
+		t.Errorf("录制内容 = %q, 期望覆盖为 b", c)
+	}
+	if ids, _ := HistoryIDsWithTraffic(); len(ids) != 1 || ids[0] != id {
+		t.Errorf("HistoryIDsWithTraffic = %v", ids)
+	}
+
+	DeleteHistoryByID(id)
+	if _, err := GetTraffic(id); err != ErrTrafficNotFound {
+		t.Errorf("删除历史后录制内容应一并删除, 得到 %v", err)
+	}
+}
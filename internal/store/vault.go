@@ -0,0 +1,363 @@
+package store
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"pingai/internal/secretbox"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 保险库模式
+const (
+	VaultModeKeyFile    = "keyfile"    // 本地密钥文件，启动时自动解锁
+	VaultModePassphrase = "passphrase" // 用户口令，需手动解锁
+)
+
+const (
+	settingVaultMode  = "vault.mode"
+	settingVaultSalt  = "vault.salt"
+	settingVaultCheck = "vault.check"
+
+	// vaultCheckText 校验值明文，用于验证口令/密钥文件是否正确
+	vaultCheckText = "pingai-vault"
+)
+
+var (
+	// ErrVaultLocked 保险库未解锁，无法读写 API Key
+	ErrVaultLocked = errors.New("保险库已锁定")
+	// ErrWrongPassphrase 口令错误
+	ErrWrongPassphrase = errors.New("口令错误")
+	// ErrKeyFileMismatch 密钥文件与数据库不匹配 (丢失或被替换)
+	ErrKeyFileMismatch = errors.New("主密钥文件与数据库不匹配")
+)
+
+// secretColumn 需要加密存储的列
+type secretColumn struct {
+	table string
+	idCol string
+	col   string
+}
+
+// secretColumns 所有存放 API Key 的列，新增含 Key 的表需在此登记
+var secretColumns = []secretColumn{
+	{table: "provider_configs", idCol: "provider_id", col: "api_key"},
+	{table: "provider_keys", idCol: "id", col: "api_key"},
+}
+
+var vault struct {
+	mu      sync.RWMutex
+	key     []byte
+	keyFile string
+}
+
+// VaultStatus 保险库状态
+type VaultStatus struct {
+	Mode   string `json:"mode"`
+	Locked bool   `json:"locked"`
+}
+
+// GetVaultStatus 获取保险库状态
+func GetVaultStatus() VaultStatus {
+	vault.mu.RLock()
+	locked := vault.key == nil
+	vault.mu.RUnlock()
+	return VaultStatus{Mode: vaultMode(), Locked: locked}
+}
+
+func vaultMode() string {
+	mode, _ := GetSetting(settingVaultMode)
+	if mode == "" {
+		return VaultModeKeyFile
+	}
+	return mode
+}
+
+// openVault 数据库打开后初始化保险库，密钥文件模式下自动解锁
+func openVault(keyFile string) error {
+	vault.mu.Lock()
+	vault.keyFile = keyFile
+	vault.key = nil
+	vault.mu.Unlock()
+
+	if vaultMode() == VaultModePassphrase {
+		return nil
+	}
+	return unlockWithKeyFile()
+}
+
+func closeVault() {
+	vault.mu.Lock()
+	vault.key = nil
+	vault.mu.Unlock()
+}
+
+// UnlockVault 解锁保险库，密钥文件模式忽略口令
+func UnlockVault(passphrase string) error {
+	if vaultMode() != VaultModePassphrase {
+		return unlockWithKeyFile()
+	}
+
+	saltHex, err := GetSetting(settingVaultSalt)
+	if err != nil {
+		return err
+	}
+	salt, err := hex.DecodeString(saltHex)
+	if err != nil || len(salt) == 0 {
+		return fmt.Errorf("保险库盐值损坏")
+	}
+	key, err := secretbox.DeriveKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	if err := verifyVaultKey(key, ErrWrongPassphrase); err != nil {
+		return err
+	}
+	setVaultKey(key)
+	return encryptPlaintextSecrets()
+}
+
+// LockVault 锁定保险库，清除内存中的主密钥
+func LockVault() {
+	closeVault()
+}
+
+// SetVaultPassphrase 切换保险库模式并用新密钥重新加密全部 Key
+// passphrase 为空时切换为密钥文件模式
+func SetVaultPassphrase(passphrase string) error {
+	vault.mu.RLock()
+	oldKey := vault.key
+	keyFile := vault.keyFile
+	vault.mu.RUnlock()
+	if oldKey == nil {
+		return ErrVaultLocked
+	}
+
+	var newKey []byte
+	mode := VaultModeKeyFile
+	saltHex := ""
+	if passphrase == "" {
+		k, err := loadOrCreateKeyFile(keyFile)
+		if err != nil {
+			return err
+		}
+		newKey = k
+	} else {
+		salt, err := secretbox.NewSalt()
+		if err != nil {
+			return err
+		}
+		k, err := secretbox.DeriveKey(passphrase, salt)
+		if err != nil {
+			return err
+		}
+		newKey = k
+		mode = VaultModePassphrase
+		saltHex = hex.EncodeToString(salt)
+	}
+
+	check, err := secretbox.Seal(newKey, vaultCheckText)
+	if err != nil {
+		return err
+	}
+
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, sc := range secretColumns {
+		rows, err := selectSecrets(tx, sc, false)
+		if err != nil {
+			return err
+		}
+		for _, r := range rows {
+			plain := r.Value
+			if secretbox.IsSealed(plain) {
+				if plain, err = secretbox.Open(oldKey, r.Value); err != nil {
+					return err
+				}
+			}
+			sealed, err := secretbox.Seal(newKey, plain)
+			if err != nil {
+				return err
+			}
+			update := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", sc.table, sc.col, sc.idCol)
+			if _, err := tx.Exec(update, sealed, r.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 模式、盐和校验值必须与重新加密的数据一同提交，否则保险库无法解锁
+	for _, kv := range [][2]string{
+		{settingVaultMode, mode},
+		{settingVaultSalt, saltHex},
+		{settingVaultCheck, check},
+	} {
+		if err := setSetting(tx, kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	setVaultKey(newKey)
+	return nil
+}
+
+func setVaultKey(key []byte) {
+	vault.mu.Lock()
+	vault.key = key
+	vault.mu.Unlock()
+}
+
+func unlockWithKeyFile() error {
+	vault.mu.RLock()
+	keyFile := vault.keyFile
+	vault.mu.RUnlock()
+
+	check, err := GetSetting(settingVaultCheck)
+	if err != nil {
+		return err
+	}
+	// 已有加密数据但密钥文件不存在：不生成新密钥，避免覆盖
+	if _, statErr := os.Stat(keyFile); os.IsNotExist(statErr) && check != "" {
+		return ErrKeyFileMismatch
+	}
+
+	key, err := loadOrCreateKeyFile(keyFile)
+	if err != nil {
+		return err
+	}
+	if err := verifyVaultKey(key, ErrKeyFileMismatch); err != nil {
+		return err
+	}
+	if err := SetSetting(settingVaultMode, VaultModeKeyFile); err != nil {
+		return err
+	}
+	setVaultKey(key)
+	return encryptPlaintextSecrets()
+}
+
+// verifyVaultKey 校验主密钥，首次使用时写入校验值
+func verifyVaultKey(key []byte, mismatch error) error {
+	check, err := GetSetting(settingVaultCheck)
+	if err != nil {
+		return err
+	}
+	if check == "" {
+		sealed, err := secretbox.Seal(key, vaultCheckText)
+		if err != nil {
+			return err
+		}
+		return SetSetting(settingVaultCheck, sealed)
+	}
+	plain, err := secretbox.Open(key, check)
+	if err != nil || plain != vaultCheckText {
+		return mismatch
+	}
+	return nil
+}
+
+// loadOrCreateKeyFile 读取主密钥文件，不存在时生成 (权限 0600)
+func loadOrCreateKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != secretbox.KeySize {
+			return nil, fmt.Errorf("主密钥文件格式错误: %s", path)
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0077 != 0 {
+			os.Chmod(path, 0600)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key, err := secretbox.NewKey()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// encryptPlaintextSecrets 将历史明文 Key 迁移为密文
+func encryptPlaintextSecrets() error {
+	for _, sc := range secretColumns {
+		rows, err := selectSecrets(DB, sc, true)
+		if err != nil {
+			return err
+		}
+		for _, r := range rows {
+			sealed, err := encryptSecret(r.Value)
+			if err != nil {
+				return err
+			}
+			update := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ? AND %s = ?", sc.table, sc.col, sc.idCol, sc.col)
+			if _, err := DB.Exec(update, sealed, r.ID, r.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type secretRow struct {
+	ID    string `db:"id"`
+	Value string `db:"value"`
+}
+
+// selectSecrets 读取某列全部非空值，plainOnly 时只返回尚未加密的值
+func selectSecrets(q sqlx.Queryer, sc secretColumn, plainOnly bool) ([]secretRow, error) {
+	query := fmt.Sprintf("SELECT %s AS id, %s AS value FROM %s WHERE %s != ''", sc.idCol, sc.col, sc.table, sc.col)
+	if plainOnly {
+		query += fmt.Sprintf(" AND %s NOT LIKE 'enc:%%'", sc.col)
+	}
+	var rows []secretRow
+	err := sqlx.Select(q, &rows, query)
+	return rows, err
+}
+
+// encryptSecret 加密 API Key，空值保持为空
+func encryptSecret(plain string) (string, error) {
+	if plain == "" {
+		return "", nil
+	}
+	vault.mu.RLock()
+	key := vault.key
+	vault.mu.RUnlock()
+	if key == nil {
+		return "", ErrVaultLocked
+	}
+	return secretbox.Seal(key, plain)
+}
+
+// decryptSecret 解密 API Key，尚未迁移的明文原样返回
+func decryptSecret(value string) (string, error) {
+	if value == "" || !secretbox.IsSealed(value) {
+		return value, nil
+	}
+	vault.mu.RLock()
+	key := vault.key
+	vault.mu.RUnlock()
+	if key == nil {
+		return "", ErrVaultLocked
+	}
+	return secretbox.Open(key, value)
+}
@@ -0,0 +1,144 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// rawAPIKey 直接读取数据库中的原始列值
+func rawAPIKey(t *testing.T, providerID string) string {
+	t.Helper()
+	var v string
+	if err := DB.Get(&v, "SELECT api_key FROM provider_configs WHERE provider_id = ?", providerID); err != nil {
+		t.Fatalf("读取原始 api_key 失败: %v", err)
+	}
+	return v
+}
+
+func TestAPIKeyEncryptedAtRest(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	SaveProviderConfig(ProviderConfigRow{ProviderID: "p1", APIKey: "sk-secret", Protocol: "openai"})
+
+	raw := rawAPIKey(t, "p1")
+	if !strings.HasPrefix(raw, "enc:v1:") || strings.Contains(raw, "sk-secret") {
+		t.Errorf("数据库中 Key 未加密: %q", raw)
+	}
+	got, _ := GetProviderConfig("p1")
+	if got.APIKey != "sk-secret" {
+		t.Errorf("解密后 APIKey = %q", got.APIKey)
+	}
+
+	// 密钥文件权限为 0600
+	info, err := os.Stat(vault.keyFile)
+	if err != nil {
+		t.Fatalf("主密钥文件不存在: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("主密钥文件权限 = %o, 期望 600", info.Mode().Perm())
+	}
+}
+
+func TestPlaintextKeysMigrated(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "test.db")
+	if err := InitWithPath(dbPath); err != nil {
+		t.Fatalf("初始化失败: %v", err)
+	}
+
+	// 模拟旧版本写入的明文 Key，并重新打开数据库
+	DB.Exec("INSERT INTO provider_configs (provider_id, api_key) VALUES ('legacy', 'sk-legacy')")
+	got, _ := GetProviderConfig("legacy")
+	if got.APIKey != "sk-legacy" {
+		t.Errorf("明文 Key 读取 = %q", got.APIKey)
+	}
+	Close()
+	if err := InitWithPath(dbPath); err != nil {
+		t.Fatalf("重新打开失败: %v", err)
+	}
+	defer Close()
+
+	if raw := rawAPIKey(t, "legacy"); !strings.HasPrefix(raw, "enc:v1:") {
+		t.Errorf("明文 Key 未迁移: %q", raw)
+	}
+	got, _ = GetProviderConfig("legacy")
+	if got.APIKey != "sk-legacy" {
+		t.Errorf("迁移后 APIKey = %q", got.APIKey)
+	}
+}
+
+func TestVaultPassphraseLockUnlock(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "test.db")
+	if err := InitWithPath(dbPath); err != nil {
+		t.Fatalf("初始化失败: %v", err)
+	}
+	SaveProviderConfig(ProviderConfigRow{ProviderID: "p1", APIKey: "sk-one", Protocol: "openai"})
+
+	if err := SetVaultPassphrase("correct horse"); err != nil {
+		t.Fatalf("SetVaultPassphrase 失败: %v", err)
+	}
+	Close()
+
+	// 口令模式重新打开后处于锁定状态
+	if err := InitWithPath(dbPath); err != nil {
+		t.Fatalf("重新打开失败: %v", err)
+	}
+	defer Close()
+	status := GetVaultStatus()
+	if !status.Locked || status.Mode != VaultModePassphrase {
+		t.Fatalf("状态 = %+v, 期望口令模式且已锁定", status)
+	}
+	if _, err := GetProviderConfig("p1"); err != ErrVaultLocked {
+		t.Errorf("锁定时读取应返回 ErrVaultLocked, 得到 %v", err)
+	}
+	if err := SaveProviderConfig(ProviderConfigRow{ProviderID: "p2", APIKey: "sk-two"}); err != ErrVaultLocked {
+		t.Errorf("锁定时写入应返回 ErrVaultLocked, 得到 %v", err)
+	}
+
+	if err := UnlockVault("wrong"); err != ErrWrongPassphrase {
+		t.Errorf("错误口令应返回 ErrWrongPassphrase, 得到 %v", err)
+	}
+	if err := UnlockVault("correct horse"); err != nil {
+		t.Fatalf("UnlockVault 失败: %v", err)
+	}
+	got, err := GetProviderConfig("p1")
+	if err != nil || got.APIKey != "sk-one" {
+		t.Errorf("解锁后 APIKey = %q, err = %v", got.APIKey, err)
+	}
+
+	// 切回密钥文件模式
+	if err := SetVaultPassphrase(""); err != nil {
+		t.Fatalf("切换密钥文件模式失败: %v", err)
+	}
+	LockVault()
+	if err := UnlockVault(""); err != nil {
+		t.Fatalf("密钥文件模式解锁失败: %v", err)
+	}
+	got, _ = GetProviderConfig("p1")
+	if got.APIKey != "sk-one" {
+		t.Errorf("切换模式后 APIKey = %q", got.APIKey)
+	}
+}
+
+func TestMissingKeyFileDoesNotOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "test.db")
+	if err := InitWithPath(dbPath); err != nil {
+		t.Fatalf("初始化失败: %v", err)
+	}
+	SaveProviderConfig(ProviderConfigRow{ProviderID: "p1", APIKey: "sk-one"})
+	Close()
+
+	os.Remove(filepath.Join(dir, "master.key"))
+	if err := InitWithPath(dbPath); err != ErrKeyFileMismatch {
+		t.Errorf("密钥文件丢失应返回 ErrKeyFileMismatch, 得到 %v", err)
+	}
+	defer Close()
+	if _, err := os.Stat(filepath.Join(dir, "master.key")); !os.IsNotExist(err) {
+		t.Error("密钥文件丢失时不应生成新密钥")
+	}
+}
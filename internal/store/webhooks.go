@@ -0,0 +1,88 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// WebhookRow 状态变化通知的 Webhook 配置
+type WebhookRow struct {
+	ID            int64  `db:"id" json:"id"`
+	Name          string `db:"name" json:"name"`
+	Kind          string `db:"kind" json:"kind"` // slack / feishu / dingtalk / wecom / discord / generic
+	URL           string `db:"url" json:"url"`
+	Enabled       int    `db:"enabled" json:"enabled"`
+	ProviderIDs   string `db:"provider_ids" json:"providerIDs"`     // JSON 数组，空数组表示全部供应商
+	FailThreshold int    `db:"fail_threshold" json:"failThreshold"` // 连续失败多少次后通知
+	QuietStart    string `db:"quiet_start" json:"quietStart"`       // "22:00"，为空表示不启用免打扰
+	QuietEnd      string `db:"quiet_end" json:"quietEnd"`
+	CreatedAt     string `db:"created_at" json:"createdAt"`
+}
+
+// ErrWebhookNotFound Webhook 不存在
+var ErrWebhookNotFound = errors.New("webhook 不存在")
+
+// SaveWebhook 新建 (ID 为 0) 或更新 Webhook，返回 ID
+func SaveWebhook(w WebhookRow) (int64, error) {
+	if w.ProviderIDs == "" {
+		w.ProviderIDs = "[]"
+	}
+	if w.ID == 0 {
+		res, err := DB.Exec(`
+			INSERT INTO webhooks (name, kind, url, enabled, provider_ids, fail_threshold, quiet_start, quiet_end)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, w.Name, w.Kind, w.URL, w.Enabled, w.ProviderIDs, w.FailThreshold, w.QuietStart, w.QuietEnd)
+		if err != nil {
+			return 0, err
+		}
+		return res.LastInsertId()
+	}
+
+	res, err := DB.Exec(`
+		UPDATE webhooks SET name = ?, kind = ?, url = ?, enabled = ?, provider_ids = ?,
+			fail_threshold = ?, quiet_start = ?, quiet_end = ?
+		WHERE id = ?
+	`, w.Name, w.Kind, w.URL, w.Enabled, w.ProviderIDs, w.FailThreshold, w.QuietStart, w.QuietEnd, w.ID)
+	if err != nil {
+		return 0, err
+	}
+	if affected(res) == 0 {
+		return 0, ErrWebhookNotFound
+	}
+	return w.ID, nil
+}
+
+// GetWebhooks 获取全部 Webhook
+func GetWebhooks() ([]WebhookRow, error) {
+	var rows []WebhookRow
+	err := DB.Select(&rows, "SELECT * FROM webhooks ORDER BY id")
+	return rows, err
+}
+
+// GetWebhook 获取单个 Webhook
+func GetWebhook(id int64) (*WebhookRow, error) {
+	var row WebhookRow
+	err := DB.Get(&row, "SELECT * FROM webhooks WHERE id = ?", id)
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	return &row, err
+}
+
+// DeleteWebhook 删除 Webhook
+func DeleteWebhook(id int64) error {
+	_, err := DB.Exec("DELETE FROM webhooks WHERE id = ?", id)
+	return err
+}
+
+// RecentStatuses 获取供应商+模型+Key 最近 n 次检测的整体状态，最新的在前
+// 同一模型的多个 Key 各自判断，避免好坏 Key 交替检测时误报状态变化
+func RecentStatuses(providerID, model, maskedKey string, n int) ([]string, error) {
+	var statuses []string
+	err := DB.Select(&statuses, `
+		SELECT status FROM check_history
+		WHERE provider_id = ? AND model = ? AND masked_key = ?
+		ORDER BY created_at DESC, id DESC LIMIT ?
+	`, providerID, model, maskedKey, n)
+	return statuses, err
+}
@@ -0,0 +1,53 @@
+package store
+
+import "testing"
+
+func TestWebhooksCRUD(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	id, err := SaveWebhook(WebhookRow{Name: "team", Kind: "slack", URL: "https://hooks.slack.com/x", Enabled: 1, FailThreshold: 3})
+	if err != nil {
+		t.Fatalf("SaveWebhook 失败: %v", err)
+	}
+	w, _ := GetWebhook(id)
+	if w.ProviderIDs != "[]" || w.FailThreshold != 3 {
+		t.Errorf("Webhook = %+v", w)
+	}
+
+	w.QuietStart, w.QuietEnd = "22:00", "08:00"
+	if _, err := SaveWebhook(*w); err != nil {
+		t.Fatalf("更新失败: %v", err)
+	}
+	if rows, _ := GetWebhooks(); len(rows) != 1 || rows[0].QuietStart != "22:00" {
+		t.Errorf("更新后 = %+v", rows)
+	}
+	if _, err := SaveWebhook(WebhookRow{ID: 999, URL: "x"}); err != ErrWebhookNotFound {
+		t.Errorf("更新不存在的 Webhook 应返回 ErrWebhookNotFound, 得到 %v", err)
+	}
+
+	DeleteWebhook(id)
+	if _, err := GetWebhook(id); err != ErrWebhookNotFound {
+		t.Errorf("删除后应返回 ErrWebhookNotFound, 得到 %v", err)
+	}
+}
+
+func TestRecentStatuses(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for i, st := range []string{"success", "failed", "failed"} {
+		seedHistory(t, HistoryRow{ProviderID: "groq", Model: "m", ResultsJSON: "[]", Status: st},
+			"2026-03-1"+string(rune('0'+i))+" 10:00:00")
+	}
+	seedHistory(t, HistoryRow{ProviderID: "groq", Model: "other", ResultsJSON: "[]", Status: "success"}, "2026-03-20 10:00:00")
+	seedHistory(t, HistoryRow{ProviderID: "groq", Model: "m", MaskedKey: "sk-b...2222", ResultsJSON: "[]", Status: "success"}, "2026-03-20 10:00:00")
+
+	got, err := RecentStatuses("groq", "m", "", 2)
+	if err != nil || len(got) != 2 || got[0] != "failed" || got[1] != "failed" {
+		t.Errorf("RecentStatuses = %v (%v)", got, err)
+	}
+	if got, _ := RecentStatuses("groq", "m", "sk-b...2222", 2); len(got) != 1 || got[0] != "success" {
+		t.Errorf("其他 Key 的状态应分开统计: %v", got)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"os"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -14,6 +15,14 @@ import (
 var assets embed.FS
 
 func main() {
+	if isDaemonMode(os.Args[1:]) {
+		if err := runDaemon(os.Args[1:]); err != nil {
+			println("Error:", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	app := NewApp()
 
 	err := wails.Run(&options.App{